@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+import (
+	msconfig "mockserver/config"
+	mslogger "mockserver/logger"
+)
+
+const (
+	openTargetConsole = "console"
+	openTargetDocs    = "docs"
+)
+
+// buildOpenURL resolves the local URL for the requested --open target.
+// The console target falls back to the docs page when the console is disabled.
+func buildOpenURL(cfg *msconfig.Config, target string) (string, error) {
+	base := fmt.Sprintf("http://localhost:%d", cfg.Server.Port)
+
+	switch strings.ToLower(strings.TrimSpace(target)) {
+	case "", openTargetConsole:
+		if cfg.Server.Console != nil && cfg.Server.Console.Enabled {
+			return base + cfg.Server.Console.Path, nil
+		}
+		return base + cfg.Server.SwaggerUIPath, nil
+	case openTargetDocs:
+		return base + cfg.Server.SwaggerUIPath, nil
+	default:
+		return "", fmt.Errorf("unknown --open target '%s', must be '%s' or '%s'", target, openTargetConsole, openTargetDocs)
+	}
+}
+
+// isHeadless reports whether there is no desktop session to open a browser in (CI, SSH, containers).
+func isHeadless() bool {
+	if os.Getenv("CI") != "" {
+		return true
+	}
+	if runtime.GOOS == "linux" {
+		return os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == ""
+	}
+	return false
+}
+
+// openBrowserCommand returns the platform specific command used to open a URL.
+func openBrowserCommand(url string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url)
+	case "windows":
+		return exec.Command("cmd", "/c", "start", "", url)
+	default:
+		return exec.Command("xdg-open", url)
+	}
+}
+
+// openBrowser launches the default browser without blocking startup.
+// It is a no-op in headless environments.
+func openBrowser(url string) {
+	if isHeadless() {
+		mslogger.LogInfo("Headless environment detected, skipping browser launch")
+		return
+	}
+
+	cmd := openBrowserCommand(url)
+	if err := cmd.Start(); err != nil {
+		mslogger.LogWarn(fmt.Sprintf("Failed to open browser: %v", err))
+		return
+	}
+	go cmd.Wait()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	msconfig "mockserver/config"
+)
+
+// TestBuildOpenURL verifies the --open target resolution, including the docs fallback
+// when the console is disabled.
+func TestBuildOpenURL(t *testing.T) {
+	cfg := &msconfig.Config{
+		Server: msconfig.ServerConfig{
+			Port:          5000,
+			SwaggerUIPath: "/docs",
+			Console:       &msconfig.ConsoleConfig{Enabled: true, Path: "/console"},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		target         string
+		consoleEnabled bool
+		expected       string
+		expectError    bool
+	}{
+		{name: "Default target opens console", target: "", consoleEnabled: true, expected: "http://localhost:5000/console"},
+		{name: "Console target", target: "console", consoleEnabled: true, expected: "http://localhost:5000/console"},
+		{name: "Docs target", target: "docs", consoleEnabled: true, expected: "http://localhost:5000/docs"},
+		{name: "Console disabled falls back to docs", target: "console", consoleEnabled: false, expected: "http://localhost:5000/docs"},
+		{name: "Unknown target", target: "admin", consoleEnabled: true, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Server.Console.Enabled = tt.consoleEnabled
+
+			url, err := buildOpenURL(cfg, tt.target)
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, url)
+		})
+	}
+}
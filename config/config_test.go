@@ -3,7 +3,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,6 +20,31 @@ func createTempFile(t *testing.T, dir string, name string, content string) strin
 	return path
 }
 
+// newStrictTestConfig returns a config with the given routes and explicit console credentials, so
+// the default-credentials check never masks the one under test.
+func newStrictTestConfig(strict bool, routes ...RouteConfig) *Config {
+	return &Config{
+		Server: ServerConfig{
+			Strict:  strict,
+			Console: &ConsoleConfig{Auth: &ConsoleAuthConfig{Enabled: true, Username: "ops", Password: "s3cret"}},
+		},
+		Routes: routes,
+	}
+}
+
+// assertStrictOnly checks that the config built by newConfig fails in strict mode with every msg
+// and only warns in normal mode.
+func assertStrictOnly(t *testing.T, newConfig func(strict bool) *Config, msgs ...string) {
+	t.Helper()
+	err := validateAndApplyDefaults(newConfig(true), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "strict mode")
+	for _, msg := range msgs {
+		assert.Contains(t, err.Error(), msg)
+	}
+	assert.NoError(t, validateAndApplyDefaults(newConfig(false), ""), "normal mode only warns")
+}
+
 // TestValidateAndApplyDefaults verifies that the system applies safe default values
 // (e.g., Port 5000, JSON headers) when the configuration is empty.
 func TestValidateAndApplyDefaults(t *testing.T) {
@@ -165,6 +192,57 @@ routes:
 	assert.Equal(t, 200, logicRoute.Cases[1].Then.Status)
 }
 
+// TestLoadConfig_SecretFiles verifies "@file:" references and keys_file are read at load time,
+// relative to the config file, with the trailing newline of secret mounts dropped.
+func TestLoadConfig_SecretFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "secrets"), 0755))
+	passwordFile := createTempFile(t, tmpDir, "secrets/console_password", "s3cret-from-file\n")
+	createTempFile(t, tmpDir, "secrets/api_key", "key-from-file\n")
+	createTempFile(t, tmpDir, "secrets/keys", "# rotated weekly\nkey-a\n\nkey-b\n")
+
+	yamlContent := `
+server:
+  console:
+    auth:
+      enabled: true
+      username: "ops"
+      password: "@file:` + passwordFile + `"
+  auth:
+    enabled: true
+    type: "apiKey"
+    in: "header"
+    name: "X-API-Key"
+    keys:
+      - "inline-key"
+      - "@file:secrets/api_key"
+    keys_file: "secrets/keys"
+
+routes:
+  - name: "Ping"
+    method: "GET"
+    path: "/ping"
+    mock:
+      body: { "ok": true }
+`
+	configFile := createTempFile(t, tmpDir, "mockserver.yaml", yamlContent)
+
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+
+	assert.Equal(t, "s3cret-from-file", cfg.Server.Console.Auth.Password)
+	assert.Equal(t, []string{"inline-key", "key-from-file", "key-a", "key-b"}, cfg.Server.Auth.Keys)
+
+	t.Run("Missing secret file fails the load", func(t *testing.T) {
+		missing := createTempFile(t, tmpDir, "missing.yaml", strings.Replace(yamlContent, passwordFile, "secrets/nope", 1))
+
+		_, err := LoadConfig(missing)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "server.console.auth.password")
+		assert.Contains(t, err.Error(), "secrets/nope")
+	})
+}
+
 // TestRouteValidation_Rules uses table-driven tests to verify various user errors
 // such as missing files, invalid extensions, or missing required fields.
 func TestRouteValidation_Rules(t *testing.T) {
@@ -203,6 +281,34 @@ func TestRouteValidation_Rules(t *testing.T) {
 			expectError: true,
 			errorMsg:    "unsupported mock file extension",
 		},
+		{
+			name: "Mock cookie without name",
+			mockConfig: &MockConfig{
+				Body:    map[string]interface{}{},
+				Cookies: []CookieConfig{{Value: "abc"}},
+			},
+			expectError: true,
+			errorMsg:    "name is required",
+		},
+		{
+			name: "Mock cookie with invalid same_site",
+			mockConfig: &MockConfig{
+				Body:    map[string]interface{}{},
+				Cookies: []CookieConfig{{Name: "session", Value: "abc", SameSite: "loose"}},
+			},
+			expectError: true,
+			errorMsg:    "same_site must be one of",
+		},
+		{
+			name: "Mock slow_request_rate above 1",
+			mockConfig: &MockConfig{
+				Body:            map[string]interface{}{},
+				SlowRequestRate: 5,
+				SlowDelayMs:     500,
+			},
+			expectError: true,
+			errorMsg:    "between 0 and 1",
+		},
 		{
 			name: "Fetch without URL",
 			fetchConfig: &FetchConfig{
@@ -268,3 +374,464 @@ func TestValidateStateful_RequiredFields(t *testing.T) {
 }
 
 
+
+// TestValidateAuth_FailFast ensures misconfigured auth blocks (global or route-level)
+// are rejected at load time instead of surfacing as 500 errors per request.
+func TestValidateAuth_FailFast(t *testing.T) {
+	t.Run("Route auth without type fails validation", func(t *testing.T) {
+		cfg := &Config{
+			Routes: []RouteConfig{
+				{
+					Name:   "Secure",
+					Method: "GET",
+					Path:   "/secure",
+					Mock:   &MockConfig{Body: map[string]interface{}{"ok": true}},
+					Auth:   &AuthConfig{Enabled: true, In: "header", Name: "X-Key"},
+				},
+			},
+		}
+
+		err := validateAndApplyDefaults(cfg, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "auth.type is required")
+	})
+
+	t.Run("Global auth with unsupported type fails validation", func(t *testing.T) {
+		cfg := &Config{
+			Server: ServerConfig{
+				Auth: &AuthConfig{Enabled: true, Type: "oauth", In: "header", Name: "Authorization"},
+			},
+		}
+
+		err := validateAndApplyDefaults(cfg, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not supported")
+	})
+
+	t.Run("Disabled route auth is ignored", func(t *testing.T) {
+		cfg := &Config{
+			Routes: []RouteConfig{
+				{
+					Name:   "Open",
+					Method: "GET",
+					Path:   "/open",
+					Mock:   &MockConfig{Body: map[string]interface{}{"ok": true}},
+					Auth:   &AuthConfig{Enabled: false},
+				},
+			},
+		}
+
+		assert.NoError(t, validateAndApplyDefaults(cfg, ""))
+	})
+
+	t.Run("hmac auth requires a secret and defaults to a header", func(t *testing.T) {
+		auth := &AuthConfig{Enabled: true, Type: "hmac", Name: "X-Signature"}
+		err := validateAuth(auth)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "auth.secret is required")
+
+		auth.Secret = "s3cret"
+		require.NoError(t, validateAuth(auth))
+		assert.Equal(t, "header", auth.In)
+		assert.Equal(t, "sha256", auth.HMACAlgorithm())
+
+		auth.Algorithm = "md5"
+		assert.Error(t, validateAuth(auth))
+	})
+
+	t.Run("basic auth requires user:pass keys", func(t *testing.T) {
+		auth := &AuthConfig{Enabled: true, Type: "basic", Keys: []string{"admin"}}
+		err := validateAuth(auth)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "auth.keys[0] must be a 'user:pass' pair")
+
+		auth.Keys = []string{"admin:s3cret"}
+		require.NoError(t, validateAuth(auth))
+		assert.Equal(t, "header", auth.In)
+		assert.Equal(t, "Authorization", auth.Name)
+	})
+
+	t.Run("Invalid auth_any scheme is reported with its index", func(t *testing.T) {
+		cfg := &Config{
+			Routes: []RouteConfig{
+				{
+					Name:   "Dual",
+					Method: "GET",
+					Path:   "/dual",
+					Mock:   &MockConfig{Body: map[string]interface{}{"ok": true}},
+					AuthAny: []AuthConfig{
+						{Enabled: true, Type: "apiKey", In: "header", Name: "X-Key"},
+						{Enabled: true, Type: "bearer", In: "cookie", Name: "Authorization"},
+					},
+				},
+			},
+		}
+
+		err := validateAndApplyDefaults(cfg, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "auth_any[1]")
+	})
+
+	t.Run("auth and auth_any are mutually exclusive", func(t *testing.T) {
+		cfg := &Config{
+			Routes: []RouteConfig{
+				{
+					Name:    "Dual",
+					Method:  "GET",
+					Path:    "/dual",
+					Mock:    &MockConfig{Body: map[string]interface{}{"ok": true}},
+					Auth:    &AuthConfig{Enabled: false},
+					AuthAny: []AuthConfig{{Enabled: true, Type: "apiKey", In: "header", Name: "X-Key"}},
+				},
+			},
+		}
+
+		err := validateAndApplyDefaults(cfg, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot be used together")
+	})
+}
+
+// TestValidateConditionExpression_Grouping checks parentheses are accepted when balanced,
+// along with list literals and the in / contains operators.
+func TestValidateConditionExpression_Grouping(t *testing.T) {
+	assert.NoError(t, validateConditionExpression("NOT (request.body.age < 18) AND request.query.type == 'adult'"))
+	assert.NoError(t, validateConditionExpression("!((request.body.a == 1) || type(request.body.b) == 'number')"))
+	assert.NoError(t, validateConditionExpression("request.query.q == ')'"))
+
+	assert.NoError(t, validateConditionExpression("request.body.country in ['US','CA','MX']"))
+	assert.NoError(t, validateConditionExpression("request.headers.accept contains 'json'"))
+
+	assert.Error(t, validateConditionExpression("(request.body.age < 18"))
+	assert.Error(t, validateConditionExpression("request.body.age < 18)"))
+}
+
+// TestValidateTemplateDelimiters checks the [open, close] shape of server.template_delimiters.
+func TestValidateTemplateDelimiters(t *testing.T) {
+	assert.NoError(t, validateTemplateDelimiters(nil))
+	assert.NoError(t, validateTemplateDelimiters([]string{"<%", "%>"}))
+	assert.Error(t, validateTemplateDelimiters([]string{"<%"}))
+	assert.Error(t, validateTemplateDelimiters([]string{"", "%>"}))
+	assert.Error(t, validateTemplateDelimiters([]string{"##", "##"}))
+}
+
+// TestStrictMode verifies that configs which only warn in normal mode are rejected in strict mode.
+func TestStrictMode(t *testing.T) {
+	assertStrictOnly(t, func(strict bool) *Config {
+		return newStrictTestConfig(strict, RouteConfig{
+			Name:   "Ambiguous",
+			Method: "GET",
+			Path:   "/ambiguous",
+			Mock:   &MockConfig{Body: map[string]interface{}{"ok": true}},
+			Cases: []CaseConfig{
+				{When: "query.id == '1'", Then: CResponse{Status: 200, Body: map[string]interface{}{"id": 1}}},
+			},
+		})
+	}, "mock will be used only if no case matches")
+
+	t.Run("ForceStrict rejects default console credentials", func(t *testing.T) {
+		ForceStrict = true
+		defer func() { ForceStrict = false }()
+
+		err := validateAndApplyDefaults(&Config{}, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "default credentials")
+	})
+}
+
+// TestMockWithDefaultWarning verifies a default response next to a mock is reported as dead config.
+func TestMockWithDefaultWarning(t *testing.T) {
+	profile := func(mock *MockConfig) RouteConfig {
+		return RouteConfig{
+			Name:    "Profile",
+			Method:  "GET",
+			Path:    "/profile",
+			Mock:    mock,
+			Default: &CResponse{Status: 404, Body: map[string]interface{}{"error": "not found"}},
+		}
+	}
+
+	assertStrictOnly(t, func(strict bool) *Config {
+		return newStrictTestConfig(strict, profile(&MockConfig{Body: map[string]interface{}{"ok": true}}))
+	}, "Route '/profile': both mock and default defined, default will never be used")
+
+	// A default without a mock is reachable
+	assert.NoError(t, validateAndApplyDefaults(newStrictTestConfig(true, profile(nil)), ""))
+}
+
+// TestMockBodyWithResponsesWarning verifies a body next to weighted responses or a sequence is reported as dead config.
+func TestMockBodyWithResponsesWarning(t *testing.T) {
+	assertStrictOnly(t, func(strict bool) *Config {
+		return newStrictTestConfig(strict, RouteConfig{
+			Name:   "Chaos",
+			Method: "GET",
+			Path:   "/chaos",
+			Mock: &MockConfig{
+				Body:      map[string]interface{}{"ok": true},
+				Responses: []WeightedResponse{{Weight: 1, Status: 200, Body: map[string]interface{}{"ok": true}}},
+			},
+		})
+	}, "mock.responses is set, mock.body and mock.file will never be served")
+
+	assertStrictOnly(t, func(strict bool) *Config {
+		return newStrictTestConfig(strict, RouteConfig{
+			Name:   "Polling",
+			Method: "GET",
+			Path:   "/polling",
+			Mock: &MockConfig{
+				Body:     map[string]interface{}{"ok": true},
+				Sequence: &SequenceConfig{Responses: []CResponse{{Status: 202, Body: map[string]interface{}{"state": "pending"}}}},
+			},
+		})
+	}, "mock.sequence is set, mock.body and mock.file will never be served")
+}
+
+// TestInlineBodySizeBudget verifies oversized inline mock bodies are flagged (errors in strict mode).
+func TestInlineBodySizeBudget(t *testing.T) {
+	assert.Equal(t, len(`{"ok":true}`), mockBodySize(map[string]interface{}{"ok": true}))
+
+	newConfig := func(strict bool, body interface{}) *Config {
+		cfg := newStrictTestConfig(strict, RouteConfig{Name: "Catalog", Method: "GET", Path: "/catalog", Mock: &MockConfig{Body: body}})
+		cfg.Server.MaxInlineBodyKB = 1
+		return cfg
+	}
+
+	assertStrictOnly(t, func(strict bool) *Config {
+		return newConfig(strict, map[string]interface{}{"blob": strings.Repeat("x", 2048)})
+	}, "inline mock.body is 3 KB (limit 1 KB)", "mock.file")
+
+	assert.NoError(t, validateAndApplyDefaults(newConfig(true, map[string]interface{}{"blob": "small"}), ""))
+
+	defaults := &ServerConfig{}
+	defaults.ApplyServerDefaults()
+	assert.Equal(t, 256, defaults.MaxInlineBodyKB)
+}
+
+// TestCollectionSchemas verifies shared collection schemas are applied to stateful writes,
+// while route-level body_schema takes precedence.
+func TestCollectionSchemas(t *testing.T) {
+	shared := &JSONSchema{Type: "object", Required: []string{"name"}}
+	override := &JSONSchema{Type: "object"}
+
+	cfg := &Config{
+		Collections: map[string]*JSONSchema{"users": shared},
+		Routes: []RouteConfig{
+			{
+				Name: "Create", Method: "POST", Path: "/users",
+				Stateful: &StatefulConfig{Collection: "users", Action: "create", IDField: "id"},
+				Mock:     &MockConfig{Body: "{{state.created}}"},
+			},
+			{
+				Name: "Update", Method: "PUT", Path: "/users/{id}",
+				Stateful:   &StatefulConfig{Collection: "users", Action: "update", IDField: "id"},
+				Mock:       &MockConfig{Body: "{{state.updated}}"},
+				BodySchema: override,
+			},
+			{
+				Name: "List", Method: "GET", Path: "/users",
+				Stateful: &StatefulConfig{Collection: "users", Action: "list"},
+				Mock:     &MockConfig{Body: "{{state.list}}"},
+			},
+		},
+	}
+
+	require.NoError(t, validateAndApplyDefaults(cfg, ""))
+	assert.Same(t, shared, cfg.Routes[0].BodySchema)
+	assert.Same(t, override, cfg.Routes[1].BodySchema)
+	assert.Nil(t, cfg.Routes[2].BodySchema, "read actions do not get a body schema")
+
+	t.Run("Write without any schema still fails", func(t *testing.T) {
+		cfg := &Config{
+			Routes: []RouteConfig{
+				{
+					Name: "Create", Method: "POST", Path: "/orders",
+					Stateful: &StatefulConfig{Collection: "orders", Action: "create", IDField: "id"},
+					Mock:     &MockConfig{Body: "{{state.created}}"},
+				},
+			},
+		}
+		assert.Error(t, validateAndApplyDefaults(cfg, ""))
+	})
+}
+
+func TestCORSOriginPatterns(t *testing.T) {
+	cors := &CORSConfig{AllowOrigins: []string{"https://app.example.com", "https://*.example.com", `regex:^https://pr-\d+\.example\.org$`}}
+
+	exact, patterns, err := cors.OriginPatterns()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://app.example.com"}, exact)
+	require.Len(t, patterns, 2)
+	assert.True(t, patterns[0].MatchString("https://preview-1.example.com"))
+	assert.False(t, patterns[0].MatchString("https://example.com"))
+	assert.True(t, patterns[1].MatchString("https://pr-7.example.org"))
+
+	t.Run("Invalid regex is rejected at load", func(t *testing.T) {
+		cfg := &Config{Server: ServerConfig{CORS: &CORSConfig{Enabled: true, AllowOrigins: []string{"regex:^https://(unclosed"}}}}
+		err := validateAndApplyDefaults(cfg, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid regex")
+	})
+
+	t.Run("Credentials with a wildcard origin are rejected", func(t *testing.T) {
+		newConfig := func(origins ...string) *Config {
+			cfg := newStrictTestConfig(false)
+			cfg.Server.CORS = &CORSConfig{Enabled: true, AllowOrigins: origins, AllowCredentials: true}
+			return cfg
+		}
+
+		assert.ErrorContains(t, validateAndApplyDefaults(newConfig("*"), ""), "allow_credentials")
+		assert.Error(t, validateAndApplyDefaults(newConfig(), ""), "an empty list defaults to '*'")
+		assert.NoError(t, validateAndApplyDefaults(newConfig("https://app.example.com", "https://*.example.com"), ""))
+	})
+}
+
+// TestBodyExampleWithoutValidator verifies config-only callers still check examples structurally.
+func TestBodyExampleWithoutValidator(t *testing.T) {
+	original := SchemaValidator
+	defer func() { SchemaValidator = original }()
+	SchemaValidator = nil
+
+	route := &RouteConfig{
+		Name: "Create", Method: "POST", Path: "/items",
+		Mock: &MockConfig{Body: map[string]interface{}{"ok": true}},
+		BodySchema: &JSONSchema{
+			Type:     "object",
+			Required: []string{"name"},
+			Properties: map[string]*JSONSchema{
+				"name": {Type: "string"},
+				"qty":  {Type: "integer"},
+				"tags": {Type: "array", Items: &JSONSchema{Type: "string", Enum: []interface{}{"new", "sale"}}},
+			},
+		},
+		BodyExample: map[string]interface{}{"name": "pen", "qty": 3, "tags": []interface{}{"sale"}},
+	}
+	assert.NoError(t, validateRoute(route, "", false))
+
+	route.BodyExample = map[string]interface{}{"name": "pen", "qty": 1.5}
+	assert.ErrorContains(t, validateRoute(route, "", false), "body_example.qty: expected integer, got number")
+
+	route.BodyExample = map[string]interface{}{"name": "pen", "tags": []interface{}{"old"}}
+	assert.ErrorContains(t, validateRoute(route, "", false), "body_example.tags[0]: invalid value 'old'")
+
+	route.BodyExample = map[string]interface{}{"qty": 3}
+	assert.ErrorContains(t, validateRoute(route, "", false), "missing required field 'name'")
+}
+
+func TestValidatePersistence(t *testing.T) {
+	p := &PersistenceConfig{Path: "data/state.json"}
+	require.NoError(t, validatePersistence(p, "/srv/mock/mockserver.yaml"))
+	assert.Equal(t, "/srv/mock/data/state.json", p.Path, "relative paths follow the config file")
+	assert.Equal(t, 1000, p.DebounceMs)
+
+	assert.ErrorContains(t, validatePersistence(&PersistenceConfig{}, ""), "path is required")
+	assert.ErrorContains(t, validatePersistence(&PersistenceConfig{Path: "s.json", DebounceMs: -1}, ""), "cannot be negative")
+}
+
+func TestStatefulAutoID(t *testing.T) {
+	route := func(autoID string, required []string) *Config {
+		return &Config{
+			Server: ServerConfig{Strict: true, Console: &ConsoleConfig{Auth: &ConsoleAuthConfig{Enabled: true}}},
+			Routes: []RouteConfig{{
+				Name: "Create", Method: "POST", Path: "/tasks",
+				BodySchema: &JSONSchema{Type: "object", Required: required},
+				Stateful:   &StatefulConfig{Collection: "tasks", Action: "create", AutoID: autoID},
+				Mock:       &MockConfig{Body: "{{state.created}}"},
+			}},
+		}
+	}
+
+	assert.NoError(t, validateAndApplyDefaults(route("increment", []string{"title"}), ""))
+	assert.ErrorContains(t, validateAndApplyDefaults(route("sequence", nil), ""), "auto_id must be 'uuid' or 'increment'")
+	assert.ErrorContains(t, validateAndApplyDefaults(route("uuid", []string{"id", "title"}), ""), "body_schema requires 'id'")
+}
+
+func TestFrozenTime(t *testing.T) {
+	cfg := &Config{Server: ServerConfig{FrozenTime: "2024-01-15T10:00:00Z"}}
+	require.NoError(t, validateAndApplyDefaults(cfg, ""))
+
+	now, ok := cfg.Server.FrozenNow()
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), now)
+
+	_, ok = (&ServerConfig{}).FrozenNow()
+	assert.False(t, ok, "no frozen_time uses the real clock")
+
+	bad := &Config{Server: ServerConfig{FrozenTime: "2024-01-15"}}
+	assert.ErrorContains(t, validateAndApplyDefaults(bad, ""), "RFC3339")
+}
+
+func TestValidateWeightedResponses(t *testing.T) {
+	assert.NoError(t, validateWeightedResponses(nil, "/r"))
+	assert.NoError(t, validateWeightedResponses([]WeightedResponse{
+		{Weight: 0, Body: "never"},
+		{Weight: 0.5, Status: 503, Body: map[string]interface{}{"error": "unavailable"}},
+	}, "/r"))
+
+	assert.ErrorContains(t, validateWeightedResponses([]WeightedResponse{{Weight: -1, Body: "x"}}, "/r"), "cannot be negative")
+	assert.ErrorContains(t, validateWeightedResponses([]WeightedResponse{{Weight: 0, Body: "x"}}, "/r"), "sum to a positive number")
+	assert.ErrorContains(t, validateWeightedResponses([]WeightedResponse{{Weight: 1}}, "/r"), "must define a body")
+	assert.ErrorContains(t, validateWeightedResponses([]WeightedResponse{{Weight: 1, Status: 99, Body: "x"}}, "/r"), "between 100 and 599")
+}
+
+func TestValidateSequence(t *testing.T) {
+	assert.NoError(t, validateSequence(nil, "/r"))
+	assert.NoError(t, validateSequence(&SequenceConfig{
+		Responses: []CResponse{{Status: 503, Body: "down"}, {Body: map[string]interface{}{"ok": true}}},
+		Cycle:     true,
+	}, "/r"))
+
+	assert.ErrorContains(t, validateSequence(&SequenceConfig{}, "/r"), "at least one response")
+	assert.ErrorContains(t, validateSequence(&SequenceConfig{Responses: []CResponse{{Status: 200}}}, "/r"), "must define a body")
+	assert.ErrorContains(t, validateSequence(&SequenceConfig{Responses: []CResponse{{Status: 600, Body: "x"}}}, "/r"), "between 100 and 599")
+	assert.ErrorContains(t, validateSequence(&SequenceConfig{Responses: []CResponse{{Body: "x", DelayMs: -5}}}, "/r"), "cannot be negative")
+
+	mock := &MockConfig{
+		Sequence:  &SequenceConfig{Responses: []CResponse{{Body: "x"}}},
+		Responses: []WeightedResponse{{Weight: 1, Body: "y"}},
+	}
+	assert.ErrorContains(t, validateMock(mock, "/r", ""), "cannot be combined")
+}
+
+func TestPaginatedStatefulRejected(t *testing.T) {
+	cfg := &Config{
+		Routes: []RouteConfig{{
+			Name: "List", Method: "GET", Path: "/tasks",
+			Stateful: &StatefulConfig{Collection: "tasks", Action: "list"},
+			Mock:     &MockConfig{Body: "{{state.list}}", Paginated: true},
+		}},
+	}
+	assert.ErrorContains(t, validateAndApplyDefaults(cfg, ""), "mock.paginated is not supported on stateful routes")
+}
+
+func TestValidateFetchBodyTemplate(t *testing.T) {
+	fetch := &FetchConfig{URL: "http://upstream.local/users", BodyTemplate: map[string]interface{}{"name": "{{request.body.name}}"}}
+	assert.NoError(t, validateFetch(fetch, "/users"))
+
+	fetch.RequestTransform = map[string]interface{}{"source": "mock"}
+	assert.ErrorContains(t, validateFetch(fetch, "/users"), "cannot be combined with fetch.request_transform")
+}
+
+func TestValidateResponseTransform(t *testing.T) {
+	tr := &ResponseTransformConfig{Extract: "$.data.user", Rename: map[string]string{"profile.email": "email"}, Pick: []string{"email"}, Wrap: "data"}
+	require.NoError(t, validateResponseTransform(tr, "/r"))
+	assert.Equal(t, "data.user", tr.Extract, "a leading '$.' is stripped")
+
+	assert.ErrorContains(t, validateResponseTransform(&ResponseTransformConfig{}, "/r"), "must define extract, rename, pick or wrap")
+	assert.ErrorContains(t, validateResponseTransform(&ResponseTransformConfig{Extract: "data..user"}, "/r"), "dotted path")
+	assert.ErrorContains(t, validateResponseTransform(&ResponseTransformConfig{Rename: map[string]string{"a": "b.c"}}, "/r"), "top-level key")
+	assert.ErrorContains(t, validateResponseTransform(&ResponseTransformConfig{Pick: []string{"user.id"}}, "/r"), "top-level key")
+	assert.ErrorContains(t, validateResponseTransform(&ResponseTransformConfig{Wrap: "  "}, "/r"), "cannot be blank")
+}
+
+func TestValidateFetchRetry(t *testing.T) {
+	fetch := &FetchConfig{Retries: 2}
+	require.NoError(t, validateFetchRetry(fetch, "/r"))
+	assert.Equal(t, 100, fetch.RetryBackoffMs)
+	assert.Equal(t, []string{"connection", "5xx"}, fetch.RetryOn)
+
+	assert.NoError(t, validateFetchRetry(&FetchConfig{Retries: 1, RetryOn: []string{"503", "connection"}}, "/r"))
+	assert.ErrorContains(t, validateFetchRetry(&FetchConfig{Retries: 1, RetryOn: []string{"404"}}, "/r"), "5xx status")
+	assert.ErrorContains(t, validateFetchRetry(&FetchConfig{Retries: 11}, "/r"), "between 0 and 10")
+	assert.ErrorContains(t, validateFetchRetry(&FetchConfig{Retries: 1, RetryBackoffMs: -1}, "/r"), "cannot be negative")
+	assert.ErrorContains(t, validateFetchRetry(&FetchConfig{RetryOn: []string{"5xx"}}, "/r"), "require fetch.retries")
+}
@@ -36,6 +36,11 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("unsupported config file extension '%s', must be .json, .yaml or .yml", ext)
 	}
 
+	// Read "@file:" secrets before validation so credentials checks see the real values
+	if err := resolveSecretFiles(&cfg, path); err != nil {
+		return nil, fmt.Errorf("failed to load secrets in '%s': %w", path, err)
+	}
+
 	// Apply defaults and validate
 	if err := validateAndApplyDefaults(&cfg, path); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
@@ -44,3 +49,24 @@ func LoadConfig(path string) (*Config, error) {
 	mslogger.LogSuccess(fmt.Sprintf("Config loaded successfully from %s", path), 1, -1)
 	return &cfg, nil
 }
+
+// ValidateConfig runs the load-time validation against a copy of cfg, so defaults
+// applied during validation are not written back to the caller's config.
+func ValidateConfig(cfg *Config, configFilePath string) error {
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to copy config for validation: %w", err)
+	}
+
+	var clone Config
+	if err := json.Unmarshal(raw, &clone); err != nil {
+		return fmt.Errorf("failed to copy config for validation: %w", err)
+	}
+
+	return validateAndApplyDefaults(&clone, configFilePath)
+}
+
+// ApplyDefaults validates cfg and applies the server/route defaults in place.
+func ApplyDefaults(cfg *Config, configFilePath string) error {
+	return validateAndApplyDefaults(cfg, configFilePath)
+}
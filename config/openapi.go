@@ -0,0 +1,377 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	mslogger "mockserver/logger"
+)
+
+// openAPIMethods lists the operation keys of an OpenAPI path item, in route registration order.
+var openAPIMethods = []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+// openAPISampleDepth bounds schema-derived samples so recursive $refs terminate.
+const openAPISampleDepth = 6
+
+// LoadOpenAPI reads an OpenAPI 3 document (.json, .yaml, .yml) and builds a config that mocks every operation.
+// Each route answers with the lowest 2xx response (or "default" as 200), using its example when present
+// and faker templates derived from the response schema otherwise. The first server URL path becomes api_prefix.
+func LoadOpenAPI(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI file '%s': %w", path, err)
+	}
+
+	var spec map[string]interface{}
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".json":
+		if err := json.Unmarshal(data, &spec); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON in '%s': %w", path, err)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &spec); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML in '%s': %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported OpenAPI file extension '%s', must be .json, .yaml or .yml", ext)
+	}
+
+	cfg, err := ConfigFromOpenAPI(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document '%s': %w", path, err)
+	}
+
+	if err := validateAndApplyDefaults(cfg, path); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	mslogger.LogSuccess(fmt.Sprintf("Mounted %d routes from OpenAPI file %s", len(cfg.Routes), path), 1, -1)
+	return cfg, nil
+}
+
+// ConfigFromOpenAPI converts a decoded OpenAPI 3 document into an unvalidated config.
+func ConfigFromOpenAPI(spec map[string]interface{}) (*Config, error) {
+	version, _ := spec["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("only OpenAPI 3.x documents are supported, got openapi '%v'", spec["openapi"])
+	}
+
+	paths, ok := spec["paths"].(map[string]interface{})
+	if !ok || len(paths) == 0 {
+		return nil, fmt.Errorf("document has no paths")
+	}
+
+	cfg := &Config{
+		Server: ServerConfig{APIPrefix: openAPIServerPrefix(spec)},
+	}
+
+	pathKeys := make([]string, 0, len(paths))
+	for p := range paths {
+		pathKeys = append(pathKeys, p)
+	}
+	sort.Strings(pathKeys)
+
+	for _, p := range pathKeys {
+		item, _ := resolveOpenAPIRef(spec, paths[p]).(map[string]interface{})
+		for _, method := range openAPIMethods {
+			op, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			cfg.Routes = append(cfg.Routes, openAPIRoute(spec, p, method, op))
+		}
+	}
+
+	if len(cfg.Routes) == 0 {
+		return nil, fmt.Errorf("document has no operations")
+	}
+	return cfg, nil
+}
+
+// openAPIServerPrefix returns the path of the first server URL (e.g. "https://api.example.com/v1" → "/v1").
+func openAPIServerPrefix(spec map[string]interface{}) string {
+	servers, _ := spec["servers"].([]interface{})
+	if len(servers) == 0 {
+		return ""
+	}
+	server, _ := servers[0].(map[string]interface{})
+	raw, _ := server["url"].(string)
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path, "/")
+}
+
+// openAPIRoute builds the mock route of a single operation.
+func openAPIRoute(spec map[string]interface{}, path, method string, op map[string]interface{}) RouteConfig {
+	route := RouteConfig{
+		Name:   strings.ToUpper(method) + " " + path,
+		Method: strings.ToUpper(method),
+		Path:   path,
+	}
+	if id, ok := op["operationId"].(string); ok && id != "" {
+		route.Name = id
+	}
+	route.Description, _ = op["summary"].(string)
+	if tags, ok := op["tags"].([]interface{}); ok && len(tags) > 0 {
+		route.Tag, _ = tags[0].(string)
+	}
+
+	status, response := openAPIPickResponse(spec, op)
+	mock := &MockConfig{Status: status}
+
+	content, _ := response["content"].(map[string]interface{})
+	if contentType, media := openAPIPickMedia(spec, content); media != nil {
+		mock.Body = openAPIMediaBody(spec, media)
+		if contentType != "application/json" {
+			route.Produces = contentType
+		}
+	}
+
+	route.Mock = mock
+	return route
+}
+
+// openAPIPickResponse selects the lowest 2xx response, then "default" (as 200), then the lowest listed code.
+func openAPIPickResponse(spec map[string]interface{}, op map[string]interface{}) (int, map[string]interface{}) {
+	responses, _ := op["responses"].(map[string]interface{})
+
+	best, fallback := 0, 0
+	for code := range responses {
+		n, err := strconv.Atoi(code)
+		if err != nil {
+			continue
+		}
+		if n >= 200 && n < 300 && (best == 0 || n < best) {
+			best = n
+		}
+		if fallback == 0 || n < fallback {
+			fallback = n
+		}
+	}
+
+	pick := func(key string) map[string]interface{} {
+		resp, _ := resolveOpenAPIRef(spec, responses[key]).(map[string]interface{})
+		return resp
+	}
+
+	switch {
+	case best != 0:
+		return best, pick(strconv.Itoa(best))
+	case responses["default"] != nil:
+		return 200, pick("default")
+	case fallback != 0:
+		return fallback, pick(strconv.Itoa(fallback))
+	}
+	return 200, nil
+}
+
+// openAPIPickMedia prefers application/json, then any JSON-like type, then the first content type alphabetically.
+func openAPIPickMedia(spec map[string]interface{}, content map[string]interface{}) (string, map[string]interface{}) {
+	if len(content) == 0 {
+		return "", nil
+	}
+
+	types := make([]string, 0, len(content))
+	for t := range content {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	chosen := types[0]
+	if _, ok := content["application/json"]; ok {
+		chosen = "application/json"
+	} else {
+		for _, t := range types {
+			if strings.Contains(t, "json") {
+				chosen = t
+				break
+			}
+		}
+	}
+
+	media, _ := resolveOpenAPIRef(spec, content[chosen]).(map[string]interface{})
+	return chosen, media
+}
+
+// openAPIMediaBody returns the media example, the first named example or a sample generated from the schema.
+func openAPIMediaBody(spec map[string]interface{}, media map[string]interface{}) interface{} {
+	if example, ok := media["example"]; ok {
+		return example
+	}
+
+	if examples, ok := media["examples"].(map[string]interface{}); ok && len(examples) > 0 {
+		names := make([]string, 0, len(examples))
+		for name := range examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if example, ok := resolveOpenAPIRef(spec, examples[names[0]]).(map[string]interface{}); ok {
+			if value, ok := example["value"]; ok {
+				return value
+			}
+		}
+	}
+
+	if schema, ok := media["schema"]; ok {
+		return openAPISample(spec, schema, "", 0)
+	}
+	return nil
+}
+
+// openAPISample derives a response value from a schema. Strings become faker template tokens
+// (e.g. "{{email}}") resolved on every request; numbers and booleans are typed literals, since
+// template tokens always render as strings.
+func openAPISample(spec map[string]interface{}, raw interface{}, field string, depth int) interface{} {
+	schema, ok := resolveOpenAPIRef(spec, raw).(map[string]interface{})
+	if !ok || depth > openAPISampleDepth {
+		return nil
+	}
+
+	if example, ok := schema["example"]; ok {
+		return example
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		merged := map[string]interface{}{}
+		for _, part := range all {
+			if obj, ok := openAPISample(spec, part, field, depth+1).(map[string]interface{}); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if variants, ok := schema[key].([]interface{}); ok && len(variants) > 0 {
+			return openAPISample(spec, variants[0], field, depth+1)
+		}
+	}
+
+	schemaType, _ := schema["type"].(string)
+	if schemaType == "" && schema["properties"] != nil {
+		schemaType = "object"
+	}
+
+	switch schemaType {
+	case "object":
+		obj := map[string]interface{}{}
+		props, _ := schema["properties"].(map[string]interface{})
+		for name, prop := range props {
+			obj[name] = openAPISample(spec, prop, name, depth+1)
+		}
+		return obj
+
+	case "array":
+		return []interface{}{openAPISample(spec, schema["items"], field, depth+1)}
+
+	case "integer", "number":
+		// 1, moved inside the schema bounds
+		value := 1.0
+		if min, ok := openAPINumber(schema["minimum"]); ok && value < min {
+			value = min
+		}
+		if max, ok := openAPINumber(schema["maximum"]); ok && value > max {
+			value = max
+		}
+		if schemaType == "integer" {
+			return int(math.Ceil(value))
+		}
+		return value
+
+	case "boolean":
+		return true
+
+	case "string":
+		return openAPIStringToken(schema, field)
+	}
+	return nil
+}
+
+// openAPINumber reads a bound decoded from JSON (float64) or YAML (int or float64).
+func openAPINumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// openAPIDateTimeToken renders an RFC 3339 timestamp (midnight UTC of the generated date).
+const openAPIDateTimeToken = "{{date}}T00:00:00Z"
+
+// openAPIStringToken picks a faker token from the string format, then from the property name,
+// falling back to a random word.
+func openAPIStringToken(schema map[string]interface{}, field string) string {
+	switch format, _ := schema["format"].(string); format {
+	case "email":
+		return "{{email}}"
+	case "uuid":
+		return "{{uuid}}"
+	case "date":
+		return "{{date}}"
+	case "date-time":
+		return openAPIDateTimeToken
+	}
+
+	name := strings.ToLower(field)
+	switch {
+	case strings.Contains(name, "email"):
+		return "{{email}}"
+	case strings.Contains(name, "name"):
+		return "{{name}}"
+	case name == "id" || strings.HasSuffix(name, "_id") || strings.HasSuffix(field, "Id"):
+		return "{{uuid}}"
+	case strings.HasSuffix(name, "_at"):
+		return openAPIDateTimeToken
+	case strings.Contains(name, "date"):
+		return "{{date}}"
+	}
+	return "{{word}}"
+}
+
+// resolveOpenAPIRef follows local "#/..." references (JSON pointers into the same document).
+// Values without $ref, or with a ref that cannot be resolved, are returned unchanged.
+func resolveOpenAPIRef(spec map[string]interface{}, value interface{}) interface{} {
+	for i := 0; i < openAPISampleDepth; i++ {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		ref, ok := obj["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return value
+		}
+
+		var current interface{} = spec
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			m, ok := current.(map[string]interface{})
+			if !ok {
+				return value
+			}
+			current = m[part]
+		}
+		if current == nil {
+			return value
+		}
+		value = current
+	}
+	return value
+}
@@ -1,6 +1,11 @@
 package config
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
 	mslogger "mockserver/logger"
 )
 
@@ -34,7 +39,8 @@ type CORSConfig struct {
 	// Enable or disable CORS globally
 	Enabled bool `json:"enabled" yaml:"enabled"`
 
-	// List of allowed origins (e.g., ["*"] for all)
+	// List of allowed origins (e.g., ["*"] for all). Entries may be wildcard patterns
+	// ("https://*.example.com") or regular expressions prefixed with "regex:"
 	AllowOrigins []string `json:"allow_origins" yaml:"allow_origins"`
 
 	// Allowed HTTP methods (e.g., GET, POST)
@@ -47,11 +53,45 @@ type CORSConfig struct {
 	AllowCredentials bool `json:"allow_credentials" yaml:"allow_credentials"`
 }
 
+// OriginRegexPrefix marks an allow_origins entry as a regular expression (e.g. "regex:^https://pr-[0-9]+\.example\.com$")
+const OriginRegexPrefix = "regex:"
+
+// OriginPatterns splits allow_origins into plain origins and compiled patterns.
+// A '*' inside an origin matches one or more characters of the host (it never crosses '/' or ':').
+func (c *CORSConfig) OriginPatterns() ([]string, []*regexp.Regexp, error) {
+	var exact []string
+	var patterns []*regexp.Regexp
+
+	for _, origin := range c.AllowOrigins {
+		origin = strings.TrimSpace(origin)
+
+		switch {
+		case strings.HasPrefix(origin, OriginRegexPrefix):
+			re, err := regexp.Compile(strings.TrimPrefix(origin, OriginRegexPrefix))
+			if err != nil {
+				return nil, nil, fmt.Errorf("cors.allow_origins: invalid regex '%s': %w", origin, err)
+			}
+			patterns = append(patterns, re)
+
+		case origin != "*" && strings.Contains(origin, "*"):
+			parts := strings.Split(strings.ToLower(origin), "*")
+			for i, part := range parts {
+				parts[i] = regexp.QuoteMeta(part)
+			}
+			patterns = append(patterns, regexp.MustCompile("^"+strings.Join(parts, "[^/:]+")+"$"))
+
+		default:
+			exact = append(exact, origin)
+		}
+	}
+	return exact, patterns, nil
+}
+
 type AuthConfig struct {
 	// Enable or disable authentication
 	Enabled bool `json:"enabled" yaml:"enabled"`
 
-	// Authentication type: "apikey" or "bearer"
+	// Authentication type: "apikey", "bearer", "hmac" or "basic"
 	Type string `json:"type,omitempty" yaml:"type,omitempty"`
 
 	// Where to pass the key: "header" or "query"
@@ -60,13 +100,40 @@ type AuthConfig struct {
 	// Parameter name (e.g., "Authorization", "X-API-Key")
 	Name string `json:"name,omitempty" yaml:"name,omitempty"`
 
-	// List of valid API keys or tokens
+	// List of valid API keys or tokens, or "user:pass" pairs for "basic" (entries may be "@file:/path" secret references)
 	Keys []string `json:"keys,omitempty" yaml:"keys,omitempty"`
+
+	// File with additional keys, one per line (e.g. a mounted Docker/Kubernetes secret)
+	KeysFile string `json:"keys_file,omitempty" yaml:"keys_file,omitempty"`
+
+	// Shared secret for "hmac": the header named by Name carries the hex HMAC of the raw body
+	Secret string `json:"secret,omitempty" yaml:"secret,omitempty"`
+
+	// Hash for "hmac": "sha256" (default), "sha1" or "sha512"
+	Algorithm string `json:"algorithm,omitempty" yaml:"algorithm,omitempty"`
+}
+
+// Hash functions accepted by AuthConfig.Algorithm for "hmac"
+var SupportedHMACAlgorithms = map[string]bool{"sha1": true, "sha256": true, "sha512": true}
+
+// HMACAlgorithm returns the configured HMAC hash, defaulting to sha256.
+func (a *AuthConfig) HMACAlgorithm() string {
+	if a.Algorithm == "" {
+		return "sha256"
+	}
+	return strings.ToLower(a.Algorithm)
 }
 
 type DebugConfig struct {
 	Enabled bool   `json:"enabled" yaml:"enabled"`
 	Path    string `json:"path" yaml:"path"`
+
+	// Request headers whose values are masked in captured request logs (case-insensitive).
+	// Set-Cookie and the header/query names used by auth schemes are always masked as well.
+	RedactHeaders []string `json:"redact_headers,omitempty" yaml:"redact_headers,omitempty"`
+
+	// Merge consecutive identical requests (method/path/status) into one entry with a count and first_time
+	CoalesceLogs bool `json:"coalesce_logs,omitempty" yaml:"coalesce_logs,omitempty"`
 }
 
 type ConsoleAuthConfig struct {
@@ -95,6 +162,10 @@ type ServerConfig struct {
 	// Headers applied to every response by default
 	DefaultHeaders map[string]string `json:"default_headers" yaml:"default_headers"`
 
+	// Headers applied automatically when a response ends with the given status code
+	// (e.g., 429: {"Retry-After": "30"}). Headers already set by the route take precedence.
+	StatusHeaders map[int]map[string]string `json:"status_headers,omitempty" yaml:"status_headers,omitempty"`
+
 	// Global response delay (in milliseconds)
 	DefaultDelayMs int `json:"default_delay_ms" yaml:"default_delay_ms"`
 
@@ -106,6 +177,79 @@ type ServerConfig struct {
 
 	// Global authentication configuration
 	Auth *AuthConfig `json:"auth,omitempty" yaml:"auth,omitempty"`
+
+	// Template token delimiters as [open, close] (default: ["{{", "}}"])
+	TemplateDelimiters []string `json:"template_delimiters,omitempty" yaml:"template_delimiters,omitempty"`
+
+	// Handling of unresolved request references in templates: "blank" (default) or "keep"
+	TemplateMissingRefs string `json:"template_missing_refs,omitempty" yaml:"template_missing_refs,omitempty"`
+
+	// Handling of template evaluation errors: "fail" (default, 500 TEMPLATE_ERROR) or "lenient" (keep the token as-is)
+	TemplateErrorMode string `json:"template_error_mode,omitempty" yaml:"template_error_mode,omitempty"`
+
+	// Maximum JSON nodes a response template may produce, including inserted {{state.*}} / {{global.*}} values (0 = unlimited)
+	MaxTemplateNodes int `json:"max_template_nodes,omitempty" yaml:"max_template_nodes,omitempty"`
+
+	// Maximum nesting depth of JSON request bodies; deeper bodies are rejected with 400 BODY_TOO_DEEP (0 = unlimited)
+	MaxBodyDepth int `json:"max_body_depth,omitempty" yaml:"max_body_depth,omitempty"`
+
+	// Fixed "now" (RFC3339, e.g. "2024-01-15T10:00:00Z") for date templates, time.* conditions and error timestamps
+	FrozenTime string `json:"frozen_time,omitempty" yaml:"frozen_time,omitempty"`
+
+	// Duplicate slashes in request paths: "redirect" (default, 301), "rewrite" (route transparently) or "off"
+	NormalizePaths string `json:"normalize_paths,omitempty" yaml:"normalize_paths,omitempty"`
+
+	// Serve HTTP/2 cleartext (prior knowledge) alongside HTTP/1.1 via a net/http bridge
+	H2C bool `json:"h2c,omitempty" yaml:"h2c,omitempty"`
+
+	// Add a Server-Timing header with per-stage durations (delay, template, total...) to route responses
+	ServerTiming bool `json:"server_timing,omitempty" yaml:"server_timing,omitempty"`
+
+	// Default mock status per HTTP method when none is configured (e.g. {"POST": 201, "DELETE": 204})
+	MethodDefaultStatus map[string]int `json:"method_default_status,omitempty" yaml:"method_default_status,omitempty"`
+
+	// Promote validation warnings (default console credentials, ambiguous handlers) to errors
+	Strict bool `json:"strict,omitempty" yaml:"strict,omitempty"`
+
+	// Default item limit for stateful collections without their own max_items (0 = unlimited)
+	StateMaxItems int `json:"state_max_items,omitempty" yaml:"state_max_items,omitempty"`
+
+	// Inline mock.body size (KB) above which validation warns to use mock.file instead (default: 256)
+	MaxInlineBodyKB int `json:"max_inline_body_kb,omitempty" yaml:"max_inline_body_kb,omitempty"`
+
+	// HTTP server tuning (timeouts, concurrency)
+	Performance *PerformanceConfig `json:"performance,omitempty" yaml:"performance,omitempty"`
+
+	// Shared variables available in templates as {{global.<name>}}, updatable at runtime via the debug endpoint
+	Globals map[string]interface{} `json:"globals,omitempty" yaml:"globals,omitempty"`
+
+	// Snapshots stateful collections to a JSON file and restores them on startup
+	Persistence *PersistenceConfig `json:"persistence,omitempty" yaml:"persistence,omitempty"`
+}
+
+// PersistenceConfig controls the on-disk snapshot of the stateful store.
+type PersistenceConfig struct {
+	// Snapshot file (relative paths are resolved against the config file)
+	Path string `json:"path" yaml:"path"`
+
+	// Wait after a write before the snapshot is saved; writes in between share one save (default: 1000)
+	DebounceMs int `json:"debounce_ms,omitempty" yaml:"debounce_ms,omitempty"`
+}
+
+// PerformanceConfig maps server tuning knobs onto the Fiber/fasthttp server.
+// Zero timeouts mean no limit.
+type PerformanceConfig struct {
+	// Maximum time to read a full request, including the body
+	ReadTimeoutMs int `json:"read_timeout_ms,omitempty" yaml:"read_timeout_ms,omitempty"`
+
+	// Maximum time to write a response (should exceed the longest configured delay)
+	WriteTimeoutMs int `json:"write_timeout_ms,omitempty" yaml:"write_timeout_ms,omitempty"`
+
+	// How long keep-alive connections may stay idle (default: 60000)
+	IdleTimeoutMs int `json:"idle_timeout_ms,omitempty" yaml:"idle_timeout_ms,omitempty"`
+
+	// Maximum number of concurrent connections (default: 262144)
+	Concurrency int `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
 }
 
 // JSONSchema: Represents a standard JSON Schema (Draft 7 compatible).
@@ -160,12 +304,69 @@ type CResponse struct {
 
 	// Response delay (in milliseconds)
 	DelayMs int `json:"delay_ms,omitempty" yaml:"delay_ms,omitempty"`
+
+	// Cookies set on the response
+	Cookies []CookieConfig `json:"cookies,omitempty" yaml:"cookies,omitempty"`
+}
+
+type CookieConfig struct {
+	// Cookie name
+	Name string `json:"name" yaml:"name"`
+
+	// Cookie value, supports templates (e.g. "{{request.body.username}}-session")
+	Value string `json:"value" yaml:"value"`
+
+	// Cookie path (default: "/")
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// Cookie domain
+	Domain string `json:"domain,omitempty" yaml:"domain,omitempty"`
+
+	// Lifetime in seconds; 0 makes it a session cookie
+	MaxAge int `json:"max_age,omitempty" yaml:"max_age,omitempty"`
+
+	// Hides the cookie from client-side scripts
+	HTTPOnly bool `json:"http_only,omitempty" yaml:"http_only,omitempty"`
+
+	// Restricts the cookie to HTTPS (required by browsers when same_site is "None")
+	Secure bool `json:"secure,omitempty" yaml:"secure,omitempty"`
+
+	// "Lax", "Strict" or "None"
+	SameSite string `json:"same_site,omitempty" yaml:"same_site,omitempty"`
+}
+
+type VariantsConfig struct {
+	// Request header whose value selects the variant (case-insensitive name)
+	Header string `json:"header" yaml:"header"`
+
+	// Response per exact header value; unmatched values fall through to the base handler
+	Values map[string]CResponse `json:"values" yaml:"values"`
 }
 
 type StatefulConfig struct {
 	Collection string `json:"collection" yaml:"collection"`
-	Action     string `json:"action" yaml:"action"` // create|get|update|delete|list
+	Action     string `json:"action" yaml:"action"` // create|get|update|patch|delete|list
 	IDField    string `json:"id_field" yaml:"id_field"`
+
+	// Generates the id when a create body omits it: "uuid" or "increment" (per collection, starting at 1)
+	AutoID string `json:"auto_id,omitempty" yaml:"auto_id,omitempty"`
+
+	// Maximum number of items the collection may hold; creates beyond it are rejected (0 = server default)
+	MaxItems int `json:"max_items,omitempty" yaml:"max_items,omitempty"`
+
+	// Custom error responses replacing the built-in STATE_* bodies
+	Responses *StatefulResponses `json:"responses,omitempty" yaml:"responses,omitempty"`
+}
+
+type StatefulResponses struct {
+	// Returned when the item does not exist (default status 404)
+	NotFound *CResponse `json:"not_found,omitempty" yaml:"not_found,omitempty"`
+
+	// Returned when creating an item whose id already exists (default status 409)
+	Conflict *CResponse `json:"conflict,omitempty" yaml:"conflict,omitempty"`
+
+	// Returned when the request cannot be applied, e.g. a non-object body (default status 400)
+	BadInput *CResponse `json:"bad_input,omitempty" yaml:"bad_input,omitempty"`
 }
 
 type CaseConfig struct {
@@ -191,6 +392,68 @@ type MockConfig struct {
 
 	// Artificial delay
 	DelayMs int `json:"delay_ms,omitempty" yaml:"delay_ms,omitempty"`
+
+	// Additional delay per KB of response body (ms/KB), simulates bandwidth-bound endpoints
+	DelayPerKb int `json:"delay_per_kb,omitempty" yaml:"delay_per_kb,omitempty"`
+
+	// Deliberately corrupts the serialized body: "truncate", "invalid-json" or "extra-comma"
+	Malformed string `json:"malformed,omitempty" yaml:"malformed,omitempty"`
+
+	// Streams array bodies as a JSON array one element at a time, waiting this long between elements
+	StreamItemDelayMs int `json:"stream_item_delay_ms,omitempty" yaml:"stream_item_delay_ms,omitempty"`
+
+	// Sends only this many bytes of the body (with the full Content-Length), then drops the connection
+	AbortAfterBytes int `json:"abort_after_bytes,omitempty" yaml:"abort_after_bytes,omitempty"`
+
+	// Cookies set on the response (values support templates)
+	Cookies []CookieConfig `json:"cookies,omitempty" yaml:"cookies,omitempty"`
+
+	// Fraction of requests (0-1, e.g. 0.05 = 5%) that incur slow_delay_ms on top of the regular delay
+	SlowRequestRate float64 `json:"slow_request_rate,omitempty" yaml:"slow_request_rate,omitempty"`
+
+	// Extra delay applied to slowed requests (in milliseconds)
+	SlowDelayMs int `json:"slow_delay_ms,omitempty" yaml:"slow_delay_ms,omitempty"`
+
+	// Seed for choosing slowed requests, making the sequence reproducible (0 = random)
+	SlowSeed int64 `json:"slow_seed,omitempty" yaml:"slow_seed,omitempty"`
+
+	// Alternative bodies selected with ?__variant=<name> (e.g. "empty"); the main body is used otherwise
+	NamedVariants map[string]interface{} `json:"named_variants,omitempty" yaml:"named_variants,omitempty"`
+
+	// Honors ?__variant even when debug mode is disabled
+	VariantQuery bool `json:"variant_query,omitempty" yaml:"variant_query,omitempty"`
+
+	// Alternative responses picked at random per request in proportion to their weight (chaos testing)
+	Responses []WeightedResponse `json:"responses,omitempty" yaml:"responses,omitempty"`
+
+	// Wraps array bodies as {"data": [...], "meta": {"total", "page", "limit", "has_next"}}, applying the
+	// query filters, sorting and _page/_limit pagination to the items
+	Paginated bool `json:"paginated,omitempty" yaml:"paginated,omitempty"`
+
+	// Ordered responses returned on successive requests (e.g. 503, 503, 200 to exercise client retries)
+	Sequence *SequenceConfig `json:"sequence,omitempty" yaml:"sequence,omitempty"`
+}
+
+type SequenceConfig struct {
+	// Responses in order; status 0 uses the mock status
+	Responses []CResponse `json:"responses" yaml:"responses"`
+
+	// Past the last response: start over from the first (true) or keep returning the last one (false, default)
+	Cycle bool `json:"cycle,omitempty" yaml:"cycle,omitempty"`
+}
+
+type WeightedResponse struct {
+	// Relative probability of this response (e.g. 90 and 10 → 90% / 10%); 0 disables it
+	Weight float64 `json:"weight" yaml:"weight"`
+
+	// HTTP status code (default: the mock status)
+	Status int `json:"status,omitempty" yaml:"status,omitempty"`
+
+	// Response body, supports templates
+	Body interface{} `json:"body" yaml:"body"`
+
+	// Headers added on top of the mock headers
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
 }
 
 type FetchConfig struct {
@@ -206,6 +469,9 @@ type FetchConfig struct {
 	// Extra query params to append to the request
 	QueryParams map[string]string `json:"query_params" yaml:"query_params"`
 
+	// Maps incoming path param names to upstream query keys (e.g. {"id": "userId"} → ?userId=123)
+	PathParamsAsQuery map[string]string `json:"path_params_as_query,omitempty" yaml:"path_params_as_query,omitempty"`
+
 	// If true, pass through upstream HTTP status
 	PassStatus bool `json:"pass_status" yaml:"pass_status"`
 
@@ -214,6 +480,82 @@ type FetchConfig struct {
 
 	// Timeout for the external request
 	TimeoutMs int `json:"timeout_ms,omitempty" yaml:"timeout_ms,omitempty"`
+
+	// Limit for establishing the upstream connection (504 FETCH_CONNECT_TIMEOUT)
+	ConnectTimeoutMs int `json:"connect_timeout_ms,omitempty" yaml:"connect_timeout_ms,omitempty"`
+
+	// Limit for the upstream response headers once the request is sent (504 FETCH_RESPONSE_TIMEOUT)
+	ResponseTimeoutMs int `json:"response_timeout_ms,omitempty" yaml:"response_timeout_ms,omitempty"`
+
+	// Extra attempts for idempotent requests (GET, HEAD, OPTIONS, PUT, DELETE) that hit a retry_on failure
+	Retries int `json:"retries,omitempty" yaml:"retries,omitempty"`
+
+	// Wait before the first retry, doubled for each further retry (default: 100); bounded by timeout_ms
+	RetryBackoffMs int `json:"retry_backoff_ms,omitempty" yaml:"retry_backoff_ms,omitempty"`
+
+	// Failures that trigger a retry: "connection", "5xx" or specific 5xx statuses such as "503" (default: ["connection", "5xx"])
+	RetryOn []string `json:"retry_on,omitempty" yaml:"retry_on,omitempty"`
+
+	// Serve repeated GET/HEAD requests for the same target URL from memory for this long; only 2xx responses are cached
+	CacheTTLMs int `json:"cache_ttl_ms,omitempty" yaml:"cache_ttl_ms,omitempty"`
+
+	// Fields merged into a JSON object request body before it is sent upstream.
+	// Values are templates ("{{request.body.name}}", "= request.body.qty * 2"); null removes the field.
+	RequestTransform map[string]interface{} `json:"request_transform,omitempty" yaml:"request_transform,omitempty"`
+
+	// Replaces the forwarded client body with this processed template (e.g. {"user": {"name": "{{request.body.name}}"}}).
+	// Strings are sent as-is, other values as JSON
+	BodyTemplate interface{} `json:"body_template,omitempty" yaml:"body_template,omitempty"`
+
+	// Reshapes JSON upstream responses (extract, rename, pick, wrap); non-JSON responses pass through untouched
+	ResponseTransform *ResponseTransformConfig `json:"response_transform,omitempty" yaml:"response_transform,omitempty"`
+
+	// Replacement bodies for upstream error statuses (e.g. {404: {...}}), sent with that status instead of the upstream body
+	ErrorResponses map[int]interface{} `json:"error_responses,omitempty" yaml:"error_responses,omitempty"`
+}
+
+// ResponseTransformConfig rules run in order: extract, rename, pick, wrap.
+// Rename and pick apply to each object when the (extracted) value is an array.
+type ResponseTransformConfig struct {
+	// Dotted path of the value returned instead of the whole body (e.g. "data.user", "items.0"; a leading "$." is allowed)
+	Extract string `json:"extract,omitempty" yaml:"extract,omitempty"`
+
+	// Moves fields to top-level keys: dotted source path → new key (e.g. {"profile.email": "email"}).
+	// Rules run in sorted source path order, so when two sources target the same key the later path wins
+	Rename map[string]string `json:"rename,omitempty" yaml:"rename,omitempty"`
+
+	// Top-level keys to keep; all other keys are dropped
+	Pick []string `json:"pick,omitempty" yaml:"pick,omitempty"`
+
+	// Key under which the result is wrapped (e.g. "data" → {"data": ...})
+	Wrap string `json:"wrap,omitempty" yaml:"wrap,omitempty"`
+}
+
+type QuotaConfig struct {
+	// Maximum number of requests served
+	Max int `json:"max" yaml:"max"`
+
+	// Window length in seconds after which the counter starts over (0 = total, reset only via the debug endpoint)
+	WindowSeconds int `json:"window_seconds,omitempty" yaml:"window_seconds,omitempty"`
+}
+
+type MaskRule struct {
+	// Dotted path of the field (e.g. "payment.card_number"); arrays along the path are masked element by element
+	Field string `json:"field" yaml:"field"`
+
+	// "full" (default), "partial" (only the last characters stay visible) or "hash" (SHA-256 hex digest)
+	Strategy string `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+
+	// Visible trailing characters for the partial strategy (default: 4)
+	Keep int `json:"keep,omitempty" yaml:"keep,omitempty"`
+}
+
+type StaticConfig struct {
+	// Directory to serve (relative paths are resolved against the config file)
+	Dir string `json:"dir" yaml:"dir"`
+
+	// If true, directory listings are rendered for folders without an index file
+	Browse bool `json:"browse,omitempty" yaml:"browse,omitempty"`
 }
 
 type RouteConfig struct {
@@ -237,15 +579,30 @@ type RouteConfig struct {
 	// Custom response headers for this route
 	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
 
+	// Server default_headers left out on this route (case-insensitive, e.g. ["Content-Type"]);
+	// route and mock headers with the same name still apply
+	RemoveHeaders []string `json:"remove_headers,omitempty" yaml:"remove_headers,omitempty"`
+
 	// Response delay specific to this route
 	DelayMs int `json:"delay_ms,omitempty" yaml:"delay_ms,omitempty"`
 
+	// Upper bound (ms) for the whole route (delays, stateful writes, template rendering, cases and fetch); exceeding it returns 504
+	// ROUTE_TIMEOUT_ERROR. fetch.timeout_ms still bounds the upstream call on its own
+	TimeoutMs int `json:"timeout_ms,omitempty" yaml:"timeout_ms,omitempty"`
+
 	// Path parameters definition
 	PathParams map[string]ParamDef `json:"path_params,omitempty" yaml:"path_params,omitempty"`
 
 	// Query parameters definition
 	Query map[string]ParamDef `json:"query,omitempty" yaml:"query,omitempty"`
 
+	// Query values required for this route to match (e.g. {"type": "summary"}).
+	// Otherwise the request falls through to the next route with the same method and path (or 404)
+	MatchQuery map[string]string `json:"match_query,omitempty" yaml:"match_query,omitempty"`
+
+	// Reject query params not declared in query or match_query (filtering params stay allowed)
+	StrictQuery bool `json:"strict_query,omitempty" yaml:"strict_query,omitempty"`
+
 	// Expected request headers definition
 	RequestHeaders map[string]ParamDef `json:"request_headers,omitempty" yaml:"request_headers,omitempty"`
 
@@ -256,20 +613,80 @@ type RouteConfig struct {
 	// Example body for documentation/testing
 	BodyExample interface{} `json:"body_example,omitempty" yaml:"body_example,omitempty"`
 
+	// Example success response for documentation (OpenAPI), used even when cases/stateful generate none
+	ResponseExample interface{} `json:"response_example,omitempty" yaml:"response_example,omitempty"`
+
 	// Static mock response configuration
 	Mock *MockConfig `json:"mock,omitempty" yaml:"mock,omitempty"`
 
 	// Proxy/fetch response configuration
 	Fetch *FetchConfig `json:"fetch,omitempty" yaml:"fetch,omitempty"`
 
+	// Response content type (e.g. "text/html"); string mock, case, variant and default bodies are sent as-is with it.
+	// Defaults to the Content-Type header of the mock/route, then "application/json"; a response's own Content-Type header wins
+	Produces string `json:"produces,omitempty" yaml:"produces,omitempty"`
+
+	// If true, responds with the received request (method, path, query, headers, body)
+	Echo bool `json:"echo,omitempty" yaml:"echo,omitempty"`
+
+	// Serves a directory of static files under the route path
+	Static *StaticConfig `json:"static,omitempty" yaml:"static,omitempty"`
+
 	// Conditional responses (rule-based behavior)
 	Cases    []CaseConfig    `json:"cases,omitempty" yaml:"cases,omitempty"`
 	Stateful *StatefulConfig `json:"stateful,omitempty" yaml:"stateful,omitempty"`
 
 	Default *CResponse `json:"default,omitempty" yaml:"default,omitempty"`
 
+	// Responses selected by a request header value (e.g. per X-Tenant), checked after cases
+	Variants *VariantsConfig `json:"variants,omitempty" yaml:"variants,omitempty"`
+
 	// Route-specific authentication override
 	Auth *AuthConfig `json:"auth,omitempty" yaml:"auth,omitempty"`
+
+	// Alternative auth schemes; the request passes if any one validates (overrides auth)
+	AuthAny []AuthConfig `json:"auth_any,omitempty" yaml:"auth_any,omitempty"`
+
+	// Cumulative request cap; further requests get 429 QUOTA_EXCEEDED until the window ends or a debug reset
+	Quota *QuotaConfig `json:"quota,omitempty" yaml:"quota,omitempty"`
+
+	// Response fields hidden before sending (mock, case, variant, default, stateful and JSON fetch bodies)
+	Mask []MaskRule `json:"mask,omitempty" yaml:"mask,omitempty"`
+
+	// Record requests in the console log and debug request buffer (default: true)
+	LogRequests *bool `json:"log_requests,omitempty" yaml:"log_requests,omitempty"`
+}
+
+// ShouldLogRequests reports whether traffic for this route is recorded (defaults to true).
+func (r *RouteConfig) ShouldLogRequests() bool {
+	return r.LogRequests == nil || *r.LogRequests
+}
+
+// ResponseContentType resolves the content type a route responds with:
+// produces, then the mock/route Content-Type header, then application/json.
+func (r *RouteConfig) ResponseContentType() string {
+	if r.Produces != "" {
+		return r.Produces
+	}
+	if r.Mock != nil {
+		if ct := headerValue(r.Mock.Headers, "Content-Type"); ct != "" {
+			return ct
+		}
+	}
+	if ct := headerValue(r.Headers, "Content-Type"); ct != "" {
+		return ct
+	}
+	return "application/json"
+}
+
+// headerValue looks up a header case-insensitively
+func headerValue(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
 }
 
 type Config struct {
@@ -284,12 +701,56 @@ type Config struct {
 
 	// List of all API routes
 	Routes []RouteConfig `json:"routes" yaml:"routes"`
+
+	// Shared body schemas per stateful collection (route-level body_schema takes precedence)
+	Collections map[string]*JSONSchema `json:"collections,omitempty" yaml:"collections,omitempty"`
+
+	// Optional smoke assertions executed by `mockserver test`
+	Tests []TestConfig `json:"tests,omitempty" yaml:"tests,omitempty"`
+}
+
+// TestConfig describes a single smoke assertion run in-memory against the configured routes
+type TestConfig struct {
+	// Optional label shown in the report (defaults to "METHOD path")
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	// HTTP method (defaults to GET)
+	Method string `json:"method,omitempty" yaml:"method,omitempty"`
+
+	// Full request path including api_prefix and query string (e.g. /v1/users?page=2)
+	Path string `json:"path" yaml:"path"`
+
+	// Optional request headers
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// Optional request body, sent as JSON
+	Body interface{} `json:"body,omitempty" yaml:"body,omitempty"`
+
+	// Expected outcome
+	Expect TestExpectConfig `json:"expect" yaml:"expect"`
+}
+
+// TestExpectConfig lists the checks applied to a smoke assertion response
+type TestExpectConfig struct {
+	// Expected HTTP status code (0 = not checked)
+	Status int `json:"status,omitempty" yaml:"status,omitempty"`
+
+	// Substring the response body must contain (empty = not checked)
+	BodyContains string `json:"body_contains,omitempty" yaml:"body_contains,omitempty"`
+}
+
+// FrozenNow returns the parsed frozen_time; ok is false when the real clock should be used.
+func (s *ServerConfig) FrozenNow() (time.Time, bool) {
+	if s.FrozenTime == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, s.FrozenTime)
+	return t, err == nil
 }
 
 // helpers
 func (s *ServerConfig) ApplyServerDefaults() {
 
-	
 	if s.Port == 0 {
 		s.Port = 5000
 		mslogger.LogWarn("Config: server.port not set → using default 5000")
@@ -310,11 +771,38 @@ func (s *ServerConfig) ApplyServerDefaults() {
 		// [OPTIONAL_LOG] mslogger.LogWarn("Config: server.default_headers not set → using default 'Content-Type: application/json'")
 	}
 
+	if s.TemplateMissingRefs == "" {
+		s.TemplateMissingRefs = "blank"
+	}
+
+	if s.TemplateErrorMode == "" {
+		s.TemplateErrorMode = "fail"
+	}
+
+	if s.NormalizePaths == "" {
+		s.NormalizePaths = "redirect"
+	}
+
 	if s.SwaggerUIPath == "" {
 		s.SwaggerUIPath = "/docs"
 		// [OPTIONAL_LOG] mslogger.LogInfo("Config: server.swagger_ui_path not set → using default '/docs'")
 	}
 
+	if s.MaxInlineBodyKB == 0 {
+		s.MaxInlineBodyKB = 256
+	}
+
+	// --- Performance ---
+	if s.Performance == nil {
+		s.Performance = &PerformanceConfig{}
+	}
+	if s.Performance.IdleTimeoutMs == 0 {
+		s.Performance.IdleTimeoutMs = 60000
+	}
+	if s.Performance.Concurrency == 0 {
+		s.Performance.Concurrency = 256 * 1024
+	}
+
 	// --- Debug ---
 	if s.Debug == nil {
 		s.Debug = &DebugConfig{}
@@ -322,6 +810,9 @@ func (s *ServerConfig) ApplyServerDefaults() {
 	if s.Debug.Path == "" {
 		s.Debug.Path = "/__debug"
 	}
+	if s.Debug.RedactHeaders == nil {
+		s.Debug.RedactHeaders = []string{"Authorization", "Cookie"}
+	}
 
 	if s.Console == nil {
 		s.Console = &ConsoleConfig{
@@ -341,8 +832,6 @@ func (s *ServerConfig) ApplyServerDefaults() {
 			Password: "123",
 		}
 
-		mslogger.LogWarn("Console auth default credentials are in use (admin/1**)")
-
 	}
 
 	if s.CORS == nil {
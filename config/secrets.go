@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+import (
+	msUtils "mockserver/utils"
+)
+
+// SecretFilePrefix marks a secret field whose value is read from a file at load time,
+// e.g. "@file:/run/secrets/api_key" (relative paths are resolved against the config file).
+const SecretFilePrefix = "@file:"
+
+// resolveSecretFiles replaces "@file:" references in secret-bearing fields (console credentials,
+// auth keys and secrets, fetch headers) with the referenced file contents and appends auth keys_file entries.
+func resolveSecretFiles(cfg *Config, configFilePath string) error {
+	if cfg.Server.Console != nil && cfg.Server.Console.Auth != nil {
+		auth := cfg.Server.Console.Auth
+		if err := resolveSecretField(&auth.Username, configFilePath, "server.console.auth.username"); err != nil {
+			return err
+		}
+		if err := resolveSecretField(&auth.Password, configFilePath, "server.console.auth.password"); err != nil {
+			return err
+		}
+	}
+
+	if err := resolveAuthSecrets(cfg.Server.Auth, configFilePath, "server.auth"); err != nil {
+		return err
+	}
+
+	for i := range cfg.Routes {
+		route := &cfg.Routes[i]
+		if err := resolveAuthSecrets(route.Auth, configFilePath, fmt.Sprintf("routes[%d].auth", i)); err != nil {
+			return err
+		}
+		for j := range route.AuthAny {
+			if err := resolveAuthSecrets(&route.AuthAny[j], configFilePath, fmt.Sprintf("routes[%d].auth_any[%d]", i, j)); err != nil {
+				return err
+			}
+		}
+
+		if route.Fetch != nil {
+			for name, value := range route.Fetch.Headers {
+				if err := resolveSecretField(&value, configFilePath, fmt.Sprintf("routes[%d].fetch.headers.%s", i, name)); err != nil {
+					return err
+				}
+				route.Fetch.Headers[name] = value
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveAuthSecrets resolves auth keys and secret, then loads keys_file (one key per line, '#' comments).
+func resolveAuthSecrets(auth *AuthConfig, configFilePath, field string) error {
+	if auth == nil {
+		return nil
+	}
+
+	for i := range auth.Keys {
+		if err := resolveSecretField(&auth.Keys[i], configFilePath, fmt.Sprintf("%s.keys[%d]", field, i)); err != nil {
+			return err
+		}
+	}
+	if err := resolveSecretField(&auth.Secret, configFilePath, field+".secret"); err != nil {
+		return err
+	}
+
+	if auth.KeysFile != "" {
+		data, err := os.ReadFile(msUtils.ResolveMockFilePath(configFilePath, auth.KeysFile))
+		if err != nil {
+			return fmt.Errorf("%s.keys_file: failed to read '%s': %w", field, auth.KeysFile, err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if key := strings.TrimSpace(line); key != "" && !strings.HasPrefix(key, "#") {
+				auth.Keys = append(auth.Keys, key)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveSecretField reads the file referenced by an "@file:" value, dropping the trailing newline
+// secret mounts usually carry. Other values are left untouched.
+func resolveSecretField(value *string, configFilePath, field string) error {
+	if !strings.HasPrefix(*value, SecretFilePrefix) {
+		return nil
+	}
+
+	path := strings.TrimSpace(strings.TrimPrefix(*value, SecretFilePrefix))
+	if path == "" {
+		return fmt.Errorf("%s: '%s' requires a file path", field, SecretFilePrefix)
+	}
+
+	data, err := os.ReadFile(msUtils.ResolveMockFilePath(configFilePath, path))
+	if err != nil {
+		return fmt.Errorf("%s: failed to read secret file '%s': %w", field, path, err)
+	}
+	*value = strings.TrimRight(string(data), "\r\n")
+	return nil
+}
@@ -1,10 +1,14 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"net/url"
 )
@@ -17,11 +21,17 @@ import (
 // Route validation regex (path must start with / and contain only valid chars)
 var validPathRegex = regexp.MustCompile(`^\/[a-zA-Z0-9\/\-_{}]*$`)
 
+// Global variable names are template identifiers, so '.' is reserved for nested access
+var validGlobalNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
 // Cases Conf
 const maxCasesPerRoute = 20
 
+// ForceStrict enables strict validation regardless of server.strict (set by the --strict flag)
+var ForceStrict bool
+
 var rootRegex = regexp.MustCompile(
-	`(request\.)?(body|query|headers|path)\.[a-zA-Z0-9_]+|method\b`,
+	`(request\.)?(body|query|headers|path)\.[a-zA-Z0-9_]+|method\b|request\.proto\b|time\.[a-z_]+`,
 )
 var allowedConditionRoots = []string{
 	"body.",
@@ -29,13 +39,24 @@ var allowedConditionRoots = []string{
 	"headers.",
 	"path.",
 	"method",
+	"proto",
+	"time.",
 }
 
 // [IMP_FUNC]
 func validateAndApplyDefaults(cfg *Config, configFilePath string) error {
 
+	strict := cfg.Server.Strict || ForceStrict
+	defaultConsoleCreds := cfg.Server.Console == nil || cfg.Server.Console.Auth == nil
+
 	cfg.Server.ApplyServerDefaults()
 
+	if defaultConsoleCreds {
+		if err := warnOrFail(strict, "Console auth default credentials are in use (admin/1**)"); err != nil {
+			return err
+		}
+	}
+
 	// Auth validation
 	if cfg.Server.Auth != nil && cfg.Server.Auth.Enabled {
 		if err := validateAuth(cfg.Server.Auth); err != nil {
@@ -43,20 +64,144 @@ func validateAndApplyDefaults(cfg *Config, configFilePath string) error {
 		}
 	}
 
+	if cfg.Server.CORS.Enabled {
+		exact, _, err := cfg.Server.CORS.OriginPatterns()
+		if err != nil {
+			return err
+		}
+		if cfg.Server.CORS.AllowCredentials && slices.Contains(exact, "*") {
+			return fmt.Errorf("cors.allow_credentials cannot be used with allow_origins '*' (the default); list the allowed origins explicitly")
+		}
+	}
+
+	if err := validateTemplateDelimiters(cfg.Server.TemplateDelimiters); err != nil {
+		return err
+	}
+
+	if m := cfg.Server.TemplateMissingRefs; m != "blank" && m != "keep" {
+		return fmt.Errorf("server.template_missing_refs must be 'blank' or 'keep', got '%s'", m)
+	}
+	if m := cfg.Server.TemplateErrorMode; m != "fail" && m != "lenient" {
+		return fmt.Errorf("server.template_error_mode must be 'fail' or 'lenient', got '%s'", m)
+	}
+	if cfg.Server.MaxTemplateNodes < 0 {
+		return fmt.Errorf("server.max_template_nodes cannot be negative, got %d", cfg.Server.MaxTemplateNodes)
+	}
+	if cfg.Server.MaxBodyDepth < 0 {
+		return fmt.Errorf("server.max_body_depth cannot be negative, got %d", cfg.Server.MaxBodyDepth)
+	}
+	if cfg.Server.FrozenTime != "" {
+		if _, err := time.Parse(time.RFC3339, cfg.Server.FrozenTime); err != nil {
+			return fmt.Errorf("server.frozen_time must be an RFC3339 timestamp (e.g. '2024-01-15T10:00:00Z'), got '%s'", cfg.Server.FrozenTime)
+		}
+	}
+	if m := cfg.Server.NormalizePaths; m != "redirect" && m != "rewrite" && m != "off" {
+		return fmt.Errorf("server.normalize_paths must be 'redirect', 'rewrite' or 'off', got '%s'", m)
+	}
+
+	if err := validateMethodDefaultStatus(cfg.Server.MethodDefaultStatus); err != nil {
+		return err
+	}
+
+	if err := validatePerformance(cfg.Server.Performance); err != nil {
+		return err
+	}
+
+	if err := validatePersistence(cfg.Server.Persistence, configFilePath); err != nil {
+		return err
+	}
+
 	if cfg.Server.Debug != nil {
 		if !validPathRegex.MatchString(cfg.Server.Debug.Path) {
 			return fmt.Errorf("invalid debug path '%s': must start with '/' ...", cfg.Server.Debug.Path)
 		}
 	}
 
+	for name := range cfg.Server.Globals {
+		if err := ValidateGlobalName(name); err != nil {
+			return fmt.Errorf("server.globals: %w", err)
+		}
+	}
+
+	// Shared collection schemas
+	for name, schema := range cfg.Collections {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("collections: collection name cannot be empty")
+		}
+		if schema == nil {
+			return fmt.Errorf("collections['%s']: schema is required", name)
+		}
+	}
+
+	if cfg.Server.StateMaxItems < 0 {
+		return fmt.Errorf("server.state_max_items cannot be negative, got %d", cfg.Server.StateMaxItems)
+	}
+	if cfg.Server.MaxInlineBodyKB < 0 {
+		return fmt.Errorf("server.max_inline_body_kb cannot be negative, got %d", cfg.Server.MaxInlineBodyKB)
+	}
+
 	// Routes validation
 	for i, route := range cfg.Routes {
-		if err := validateRoute(&route, configFilePath); err != nil {
+		applyCollectionSchema(&route, cfg.Collections)
+		if route.Stateful != nil && route.Stateful.MaxItems == 0 {
+			route.Stateful.MaxItems = cfg.Server.StateMaxItems
+		}
+
+		if err := validateRoute(&route, configFilePath, strict); err != nil {
+			return fmt.Errorf("route[%d] '%s' validation failed: %w", i, route.Name, err)
+		}
+		if err := checkInlineBodySize(&route, cfg.Server.MaxInlineBodyKB, strict); err != nil {
 			return fmt.Errorf("route[%d] '%s' validation failed: %w", i, route.Name, err)
 		}
 		cfg.Routes[i] = route
 	}
 
+	// Smoke assertions validation
+	for i := range cfg.Tests {
+		if err := validateTest(&cfg.Tests[i]); err != nil {
+			return fmt.Errorf("tests[%d] validation failed: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func validateTest(test *TestConfig) error {
+	if test.Method == "" {
+		test.Method = "GET"
+	}
+	test.Method = strings.ToUpper(test.Method)
+	if _, ok := msUtils.AllowedMethods[test.Method]; !ok {
+		return fmt.Errorf("invalid method '%s'", test.Method)
+	}
+
+	if !strings.HasPrefix(test.Path, "/") {
+		return fmt.Errorf("invalid path '%s': must start with '/'", test.Path)
+	}
+
+	if test.Expect.Status == 0 && test.Expect.BodyContains == "" {
+		return fmt.Errorf("expect must define at least 'status' or 'body_contains'")
+	}
+
+	if test.Name == "" {
+		test.Name = test.Method + " " + test.Path
+	}
+	return nil
+}
+
+// Supported authentication schemes (compared case-insensitively)
+var supportedAuthTypes = map[string]bool{
+	"apikey": true,
+	"bearer": true,
+	"hmac":   true,
+	"basic":  true,
+}
+
+// ValidateGlobalName checks that a global variable name can be referenced as {{global.<name>}}.
+func ValidateGlobalName(name string) error {
+	if !validGlobalNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid global name '%s': use only letters, numbers, '_' and '-'", name)
+	}
 	return nil
 }
 
@@ -64,13 +209,198 @@ func validateAuth(auth *AuthConfig) error {
 	if auth.Type == "" {
 		return fmt.Errorf("auth.type is required when auth.enabled = true")
 	}
+	if !supportedAuthTypes[strings.ToLower(auth.Type)] {
+		return fmt.Errorf("auth.type '%s' is not supported, must be 'apiKey', 'bearer', 'hmac' or 'basic'", auth.Type)
+	}
+	if strings.ToLower(auth.Type) == "hmac" {
+		return validateHMACAuth(auth)
+	}
+	if strings.ToLower(auth.Type) == "basic" {
+		return validateBasicAuth(auth)
+	}
 	if auth.In != "header" && auth.In != "query" {
 		return fmt.Errorf("auth.in must be either 'header' or 'query'")
 	}
 	return nil
 }
 
-func validateRoute(route *RouteConfig, configFilePath string) error {
+// validateHMACAuth checks signature verification settings; the signature is always read from a header.
+func validateHMACAuth(auth *AuthConfig) error {
+	if auth.Secret == "" {
+		return fmt.Errorf("auth.secret is required for hmac auth")
+	}
+	if auth.Name == "" {
+		return fmt.Errorf("auth.name (signature header) is required for hmac auth")
+	}
+	if auth.In == "" {
+		auth.In = "header"
+	}
+	if auth.In != "header" {
+		return fmt.Errorf("auth.in must be 'header' for hmac auth")
+	}
+	if !SupportedHMACAlgorithms[auth.HMACAlgorithm()] {
+		return fmt.Errorf("auth.algorithm '%s' is not supported, must be 'sha1', 'sha256' or 'sha512'", auth.Algorithm)
+	}
+	return nil
+}
+
+// validateBasicAuth checks the "user:pass" credential pairs; Basic credentials are always read from the Authorization header.
+func validateBasicAuth(auth *AuthConfig) error {
+	if len(auth.Keys) == 0 {
+		return fmt.Errorf("auth.keys must list at least one 'user:pass' pair for basic auth")
+	}
+	for i, key := range auth.Keys {
+		if user, _, ok := strings.Cut(key, ":"); !ok || user == "" {
+			return fmt.Errorf("auth.keys[%d] must be a 'user:pass' pair for basic auth", i)
+		}
+	}
+	if auth.In == "" {
+		auth.In = "header"
+	}
+	if auth.Name == "" {
+		auth.Name = "Authorization"
+	}
+	if auth.In != "header" {
+		return fmt.Errorf("auth.in must be 'header' for basic auth")
+	}
+	return nil
+}
+
+// validateMethodDefaultStatus checks method keys and status ranges, normalizing keys to upper case
+func validateMethodDefaultStatus(statuses map[string]int) error {
+	for method, status := range statuses {
+		upper := strings.ToUpper(method)
+		if _, ok := msUtils.AllowedMethods[upper]; !ok {
+			return fmt.Errorf("server.method_default_status: invalid method '%s'", method)
+		}
+		if status < 100 || status > 599 {
+			return fmt.Errorf("server.method_default_status[%s] must be between 100 and 599, got %d", method, status)
+		}
+		if upper != method {
+			delete(statuses, method)
+			statuses[upper] = status
+		}
+	}
+	return nil
+}
+
+// validatePersistence resolves the snapshot path against the config file and applies the debounce default.
+func validatePersistence(p *PersistenceConfig, configFilePath string) error {
+	if p == nil {
+		return nil
+	}
+	if strings.TrimSpace(p.Path) == "" {
+		return fmt.Errorf("server.persistence.path is required")
+	}
+	if p.DebounceMs < 0 {
+		return fmt.Errorf("server.persistence.debounce_ms cannot be negative, got %d", p.DebounceMs)
+	}
+	if p.DebounceMs == 0 {
+		p.DebounceMs = 1000
+	}
+	if configFilePath != "" {
+		p.Path = msUtils.ResolveMockFilePath(configFilePath, p.Path)
+	}
+	return nil
+}
+
+func validatePerformance(perf *PerformanceConfig) error {
+	if perf == nil {
+		return nil
+	}
+	timeouts := map[string]int{
+		"read_timeout_ms":  perf.ReadTimeoutMs,
+		"write_timeout_ms": perf.WriteTimeoutMs,
+		"idle_timeout_ms":  perf.IdleTimeoutMs,
+	}
+	for name, value := range timeouts {
+		if value < 0 {
+			return fmt.Errorf("server.performance.%s cannot be negative, got %d", name, value)
+		}
+	}
+	if perf.Concurrency < 0 {
+		return fmt.Errorf("server.performance.concurrency cannot be negative, got %d", perf.Concurrency)
+	}
+	return nil
+}
+
+func validateTemplateDelimiters(delims []string) error {
+	if delims == nil {
+		return nil
+	}
+	if len(delims) != 2 {
+		return fmt.Errorf("server.template_delimiters must contain exactly 2 values [open, close], got %d", len(delims))
+	}
+	if strings.TrimSpace(delims[0]) == "" || strings.TrimSpace(delims[1]) == "" {
+		return fmt.Errorf("server.template_delimiters cannot be empty")
+	}
+	if delims[0] == delims[1] {
+		return fmt.Errorf("server.template_delimiters open and close must differ, got '%s'", delims[0])
+	}
+	return nil
+}
+
+// SchemaValidator checks documentation examples against their schemas at load time.
+// The server utils package owns the JSON schema validator and registers it here
+// (config cannot import it without a cycle); when unset, config-only callers fall back
+// to checkExampleShape, which covers types, required fields and enums.
+var SchemaValidator func(schema *JSONSchema, data interface{}, path string) error
+
+// checkExampleShape is the structural subset of JSON schema validation used when no
+// SchemaValidator is registered: types, required fields, enums, nested properties and items.
+func checkExampleShape(schema *JSONSchema, data interface{}, path string) error {
+	if schema == nil {
+		return nil
+	}
+
+	got := ""
+	switch data.(type) {
+	case nil:
+		got = "null"
+	case string:
+		got = "string"
+	case bool:
+		got = "boolean"
+	case map[string]interface{}:
+		got = "object"
+	case []interface{}:
+		got = "array"
+	case int, int64, uint64, float64, json.Number:
+		got = "number"
+		if f, err := strconv.ParseFloat(fmt.Sprint(data), 64); err == nil && f == float64(int64(f)) {
+			got = "integer"
+		}
+	}
+	if schema.Type != "" && got != schema.Type && !(schema.Type == "number" && got == "integer") {
+		return fmt.Errorf("%s: expected %s, got %s", path, schema.Type, got)
+	}
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(v interface{}) bool { return fmt.Sprint(v) == fmt.Sprint(data) }) {
+		return fmt.Errorf("%s: invalid value '%v'. allowed: %v", path, data, schema.Enum)
+	}
+
+	switch val := data.(type) {
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := val[name]; !ok {
+				return fmt.Errorf("%s: missing required field '%s'", path, name)
+			}
+		}
+		for key, item := range val {
+			if err := checkExampleShape(schema.Properties[key], item, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, item := range val {
+			if err := checkExampleShape(schema.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateRoute(route *RouteConfig, configFilePath string, strict bool) error {
 
 	// Method validation
 	if _, ok := msUtils.AllowedMethods[strings.ToUpper(route.Method)]; !ok {
@@ -82,6 +412,54 @@ func validateRoute(route *RouteConfig, configFilePath string) error {
 		return fmt.Errorf("invalid path '%s': must start with '/' and contain only letters, numbers, '-', '_', '{', '}'", route.Path)
 	}
 
+	for key := range route.MatchQuery {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("[Route %s] match_query keys cannot be empty", route.Path)
+		}
+	}
+	if len(route.MatchQuery) > 0 && route.Static != nil {
+		return fmt.Errorf("[Route %s] match_query is not supported on static routes", route.Path)
+	}
+
+	if route.TimeoutMs < 0 {
+		return fmt.Errorf("[Route %s] timeout_ms cannot be negative, got %d", route.Path, route.TimeoutMs)
+	}
+
+	for i, name := range route.RemoveHeaders {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("[Route %s] remove_headers[%d] cannot be empty", route.Path, i)
+		}
+	}
+
+	// Route auth validation (fail fast instead of returning 500 at request time)
+	if route.Auth != nil && route.Auth.Enabled {
+		if err := validateAuth(route.Auth); err != nil {
+			return fmt.Errorf("[Route %s] %w", route.Path, err)
+		}
+	}
+	if len(route.AuthAny) > 0 && route.Auth != nil {
+		return fmt.Errorf("[Route %s] auth and auth_any cannot be used together", route.Path)
+	}
+	for i := range route.AuthAny {
+		if !route.AuthAny[i].Enabled {
+			continue
+		}
+		if err := validateAuth(&route.AuthAny[i]); err != nil {
+			return fmt.Errorf("[Route %s] auth_any[%d]: %w", route.Path, i, err)
+		}
+	}
+
+	// Examples must honor the route's own contract
+	if route.BodySchema != nil && route.BodyExample != nil {
+		validate := SchemaValidator
+		if validate == nil {
+			validate = checkExampleShape
+		}
+		if err := validate(route.BodySchema, route.BodyExample, "body_example"); err != nil {
+			return fmt.Errorf("[Route %s] body_example does not match body_schema: %w", route.Path, err)
+		}
+	}
+
 	// Stateful Validation
 	if route.Stateful != nil {
 
@@ -94,8 +472,27 @@ func validateRoute(route *RouteConfig, configFilePath string) error {
 			return fmt.Errorf("stateful route '%s' must define a 'mock' response or 'cases' to return the state", route.Path)
 		}
 
+		// The schema runs before the id is generated, so a required id rejects every auto_id request
+		if route.Stateful.AutoID != "" && route.BodySchema != nil {
+			idField := route.Stateful.IDField
+			if idField == "" {
+				idField = "id"
+			}
+			for _, required := range route.BodySchema.Required {
+				if required == idField {
+					msg := fmt.Sprintf("Route '%s': auto_id is set but body_schema requires '%s', so requests without it are rejected", route.Path, idField)
+					if err := warnOrFail(strict, msg); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
 		if route.Fetch != nil {
-			mslogger.LogWarn(fmt.Sprintf("Route '%s': both stateful and fetch defined. Stateful logic will run before proxying.", route.Path))
+			msg := fmt.Sprintf("Route '%s': both stateful and fetch defined. Stateful logic will run before proxying.", route.Path)
+			if err := warnOrFail(strict, msg); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -110,6 +507,37 @@ func validateRoute(route *RouteConfig, configFilePath string) error {
 		}
 	}
 
+	// Variants validation
+	if route.Variants != nil {
+		if err := validateVariants(route.Variants, route.Path); err != nil {
+			return err
+		}
+	}
+
+	// Quota validation
+	if route.Quota != nil {
+		if route.Quota.Max <= 0 {
+			return fmt.Errorf("[Route %s] quota.max must be greater than 0, got %d", route.Path, route.Quota.Max)
+		}
+		if route.Quota.WindowSeconds < 0 {
+			return fmt.Errorf("[Route %s] quota.window_seconds cannot be negative, got %d", route.Path, route.Quota.WindowSeconds)
+		}
+	}
+
+	// Mask validation
+	for i := range route.Mask {
+		if err := validateMaskRule(&route.Mask[i], route.Path, i); err != nil {
+			return err
+		}
+	}
+
+	// Default response validation
+	if route.Default != nil {
+		if err := validateCookies(route.Default.Cookies, route.Path, "default.cookies"); err != nil {
+			return err
+		}
+	}
+
 	// Fetch validation
 	if route.Fetch != nil {
 		if err := validateFetch(route.Fetch, route.Path); err != nil {
@@ -117,6 +545,18 @@ func validateRoute(route *RouteConfig, configFilePath string) error {
 		}
 	}
 
+	// Echo validation
+	if route.Echo && (route.Mock != nil || route.Fetch != nil) {
+		return fmt.Errorf("[Route %s] 'echo' cannot be combined with 'mock' or 'fetch'", route.Path)
+	}
+
+	// Static validation
+	if route.Static != nil {
+		if err := validateStatic(route, configFilePath); err != nil {
+			return err
+		}
+	}
+
 	// Mock validation
 	if route.Mock != nil {
 		if err := validateMock(route.Mock, route.Path, configFilePath); err != nil {
@@ -124,27 +564,103 @@ func validateRoute(route *RouteConfig, configFilePath string) error {
 		}
 
 		if route.Stateful != nil && len(route.Cases) == 0 {
-			if route.Mock.Body == nil && route.Mock.File == "" {
+			if route.Mock.Body == nil && route.Mock.File == "" && len(route.Mock.Responses) == 0 && route.Mock.Sequence == nil {
 				return fmt.Errorf("stateful route '%s' requires a mock body (e.g. '{{state.created}}') to return results", route.Path)
 			}
 		}
+		if route.Stateful != nil && route.Mock.Paginated {
+			return fmt.Errorf("[Route %s] mock.paginated is not supported on stateful routes, the list action already paginates with _page and _limit", route.Path)
+		}
+	}
+
+	// Weighted responses and sequences always provide the body, so an inline body or file next to them is dead config
+	if route.Mock != nil && (route.Mock.Body != nil || route.Mock.File != "") {
+		source := ""
+		if len(route.Mock.Responses) > 0 {
+			source = "mock.responses"
+		} else if route.Mock.Sequence != nil {
+			source = "mock.sequence"
+		}
+		if source != "" {
+			msg := fmt.Sprintf("Route '%s': %s is set, mock.body and mock.file will never be served", route.Path, source)
+			if err := warnOrFail(strict, msg); err != nil {
+				return err
+			}
+		}
 	}
 
 	if len(route.Cases) > 0 && route.Mock != nil {
-		mslogger.LogWarn(
-			fmt.Sprintf("Route '%s': cases defined, mock will be used only if no case matches", route.Path),
-		)
+		msg := fmt.Sprintf("Route '%s': cases defined, mock will be used only if no case matches", route.Path)
+		if err := warnOrFail(strict, msg); err != nil {
+			return err
+		}
 	}
 
 	if len(route.Cases) > 0 && route.Fetch != nil {
-		mslogger.LogWarn(
-			fmt.Sprintf("Route '%s': cases defined, fetch will be used only if no case matches", route.Path),
-		)
+		msg := fmt.Sprintf("Route '%s': cases defined, fetch will be used only if no case matches", route.Path)
+		if err := warnOrFail(strict, msg); err != nil {
+			return err
+		}
+	}
+
+	// The mock handler always responds, so the default response can never fire
+	if route.Default != nil && route.Mock != nil && route.Fetch == nil {
+		msg := fmt.Sprintf("Route '%s': both mock and default defined, default will never be used (mock always responds)", route.Path)
+		if err := warnOrFail(strict, msg); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// applyCollectionSchema gives stateful write routes without their own body_schema
+// the shared schema of the collection they write to.
+func applyCollectionSchema(route *RouteConfig, collections map[string]*JSONSchema) {
+	if route.Stateful == nil || route.BodySchema != nil {
+		return
+	}
+	if route.Stateful.Action != "create" && route.Stateful.Action != "update" && route.Stateful.Action != "patch" {
+		return
+	}
+	if schema, ok := collections[route.Stateful.Collection]; ok {
+		route.BodySchema = schema
+	}
+}
+
+// checkInlineBodySize warns (or fails in strict mode) when an inline mock.body
+// serializes to more than limitKB, since large payloads belong in a mock.file.
+func checkInlineBodySize(route *RouteConfig, limitKB int, strict bool) error {
+	if route.Mock == nil || route.Mock.Body == nil || limitKB <= 0 {
+		return nil
+	}
+	size := mockBodySize(route.Mock.Body)
+	if size <= limitKB*1024 {
+		return nil
+	}
+	msg := fmt.Sprintf("Route '%s': inline mock.body is %d KB (limit %d KB), consider moving it to a mock.file",
+		route.Path, (size+1023)/1024, limitKB)
+	return warnOrFail(strict, msg)
+}
+
+// mockBodySize returns the serialized JSON size of a mock body in bytes (0 if it cannot be encoded).
+func mockBodySize(body interface{}) int {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// warnOrFail logs a validation warning, or returns it as an error when strict mode is on
+func warnOrFail(strict bool, msg string) error {
+	if strict {
+		return fmt.Errorf("strict mode: %s", msg)
+	}
+	mslogger.LogWarn(msg)
+	return nil
+}
+
 func validateFetch(fetch *FetchConfig, routePath string) error {
 	if fetch.URL == "" {
 		return fmt.Errorf("[Route %s] fetch.url is required", routePath)
@@ -155,6 +671,143 @@ func validateFetch(fetch *FetchConfig, routePath string) error {
 		return fmt.Errorf("[Route %s] fetch.url is invalid: '%s'", routePath, fetch.URL)
 	}
 
+	for param, queryKey := range fetch.PathParamsAsQuery {
+		if !strings.Contains(routePath, "{"+param+"}") {
+			return fmt.Errorf("[Route %s] fetch.path_params_as_query references unknown path param '%s'", routePath, param)
+		}
+		if queryKey == "" {
+			return fmt.Errorf("[Route %s] fetch.path_params_as_query['%s'] must map to a query key", routePath, param)
+		}
+	}
+
+	if fetch.ConnectTimeoutMs < 0 {
+		return fmt.Errorf("[Route %s] fetch.connect_timeout_ms cannot be negative, got %d", routePath, fetch.ConnectTimeoutMs)
+	}
+	if fetch.ResponseTimeoutMs < 0 {
+		return fmt.Errorf("[Route %s] fetch.response_timeout_ms cannot be negative, got %d", routePath, fetch.ResponseTimeoutMs)
+	}
+	if fetch.CacheTTLMs < 0 {
+		return fmt.Errorf("[Route %s] fetch.cache_ttl_ms cannot be negative, got %d", routePath, fetch.CacheTTLMs)
+	}
+
+	for status := range fetch.ErrorResponses {
+		if status < 400 || status > 599 {
+			return fmt.Errorf("[Route %s] fetch.error_responses keys must be error statuses (400-599), got %d", routePath, status)
+		}
+	}
+
+	if fetch.BodyTemplate != nil && len(fetch.RequestTransform) > 0 {
+		return fmt.Errorf("[Route %s] fetch.body_template cannot be combined with fetch.request_transform", routePath)
+	}
+
+	if err := validateResponseTransform(fetch.ResponseTransform, routePath); err != nil {
+		return err
+	}
+
+	if err := validateFetchRetry(fetch, routePath); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateFetchRetry checks the retry settings and applies the backoff and retry_on defaults.
+// 4xx statuses are never retried: the upstream rejected the request itself.
+func validateFetchRetry(fetch *FetchConfig, routePath string) error {
+	if fetch.Retries < 0 || fetch.Retries > 10 {
+		return fmt.Errorf("[Route %s] fetch.retries must be between 0 and 10, got %d", routePath, fetch.Retries)
+	}
+	if fetch.RetryBackoffMs < 0 {
+		return fmt.Errorf("[Route %s] fetch.retry_backoff_ms cannot be negative, got %d", routePath, fetch.RetryBackoffMs)
+	}
+	if fetch.Retries == 0 {
+		if fetch.RetryBackoffMs > 0 || len(fetch.RetryOn) > 0 {
+			return fmt.Errorf("[Route %s] fetch.retry_backoff_ms and fetch.retry_on require fetch.retries", routePath)
+		}
+		return nil
+	}
+
+	if fetch.RetryBackoffMs == 0 {
+		fetch.RetryBackoffMs = 100
+	}
+	if len(fetch.RetryOn) == 0 {
+		fetch.RetryOn = []string{"connection", "5xx"}
+	}
+	for _, cond := range fetch.RetryOn {
+		if cond == "connection" || cond == "5xx" {
+			continue
+		}
+		if status, err := strconv.Atoi(cond); err == nil && status >= 500 && status <= 599 {
+			continue
+		}
+		return fmt.Errorf("[Route %s] fetch.retry_on entries must be 'connection', '5xx' or a 5xx status, got '%s'", routePath, cond)
+	}
+	return nil
+}
+
+// validateResponseTransform checks the fetch.response_transform paths and keys, stripping a leading "$." from extract.
+func validateResponseTransform(t *ResponseTransformConfig, routePath string) error {
+	if t == nil {
+		return nil
+	}
+	if t.Extract == "" && len(t.Rename) == 0 && len(t.Pick) == 0 && t.Wrap == "" {
+		return fmt.Errorf("[Route %s] fetch.response_transform must define extract, rename, pick or wrap", routePath)
+	}
+
+	isDottedPath := func(p string) bool {
+		return p != "" && !strings.HasPrefix(p, ".") && !strings.HasSuffix(p, ".") && !strings.Contains(p, "..")
+	}
+
+	if t.Extract != "" {
+		t.Extract = strings.TrimPrefix(strings.TrimSpace(t.Extract), "$.")
+		if !isDottedPath(t.Extract) {
+			return fmt.Errorf("[Route %s] fetch.response_transform.extract must be a dotted path such as 'data.user', got '%s'", routePath, t.Extract)
+		}
+	}
+
+	for from, to := range t.Rename {
+		if !isDottedPath(from) {
+			return fmt.Errorf("[Route %s] fetch.response_transform.rename source must be a dotted path, got '%s'", routePath, from)
+		}
+		if strings.TrimSpace(to) == "" || strings.Contains(to, ".") {
+			return fmt.Errorf("[Route %s] fetch.response_transform.rename['%s'] must be a top-level key without dots, got '%s'", routePath, from, to)
+		}
+	}
+
+	for i, key := range t.Pick {
+		if strings.TrimSpace(key) == "" || strings.Contains(key, ".") {
+			return fmt.Errorf("[Route %s] fetch.response_transform.pick[%d] must be a top-level key without dots, got '%s'", routePath, i, key)
+		}
+	}
+
+	if t.Wrap != "" && strings.TrimSpace(t.Wrap) == "" {
+		return fmt.Errorf("[Route %s] fetch.response_transform.wrap cannot be blank", routePath)
+	}
+	return nil
+}
+
+func validateStatic(route *RouteConfig, configFilePath string) error {
+	if route.Mock != nil || route.Fetch != nil || route.Echo || route.Stateful != nil || len(route.Cases) > 0 {
+		return fmt.Errorf("[Route %s] 'static' cannot be combined with mock, fetch, echo, stateful or cases", route.Path)
+	}
+
+	if strings.ToUpper(route.Method) != "GET" {
+		return fmt.Errorf("[Route %s] static routes must use the GET method, got '%s'", route.Path, route.Method)
+	}
+
+	if route.Static.Dir == "" {
+		return fmt.Errorf("[Route %s] static.dir is required", route.Path)
+	}
+
+	dir := msUtils.ResolveMockFilePath(configFilePath, route.Static.Dir)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("[Route %s] static.dir not found: '%s'", route.Path, route.Static.Dir)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("[Route %s] static.dir must be a directory: '%s'", route.Path, route.Static.Dir)
+	}
+
 	return nil
 }
 
@@ -181,6 +834,170 @@ func validateMock(mock *MockConfig, routePath string, configFilePath string) err
 		return fmt.Errorf("[Route %s] mock.delay_ms cannot be negative, got %d", routePath, mock.DelayMs)
 	}
 
+	if mock.DelayPerKb < 0 {
+		return fmt.Errorf("[Route %s] mock.delay_per_kb cannot be negative, got %d", routePath, mock.DelayPerKb)
+	}
+
+	switch mock.Malformed {
+	case "", "truncate", "invalid-json", "extra-comma":
+	default:
+		return fmt.Errorf("[Route %s] mock.malformed must be one of truncate, invalid-json, extra-comma, got '%s'", routePath, mock.Malformed)
+	}
+
+	if mock.StreamItemDelayMs < 0 {
+		return fmt.Errorf("[Route %s] mock.stream_item_delay_ms cannot be negative, got %d", routePath, mock.StreamItemDelayMs)
+	}
+	if mock.StreamItemDelayMs > 0 && (mock.Malformed != "" || mock.DelayPerKb > 0) {
+		return fmt.Errorf("[Route %s] mock.stream_item_delay_ms cannot be combined with malformed or delay_per_kb", routePath)
+	}
+
+	if mock.AbortAfterBytes < 0 {
+		return fmt.Errorf("[Route %s] mock.abort_after_bytes cannot be negative, got %d", routePath, mock.AbortAfterBytes)
+	}
+	if mock.AbortAfterBytes > 0 && mock.StreamItemDelayMs > 0 {
+		return fmt.Errorf("[Route %s] mock.abort_after_bytes cannot be combined with stream_item_delay_ms", routePath)
+	}
+
+	if mock.SlowRequestRate < 0 || mock.SlowRequestRate > 1 {
+		return fmt.Errorf("[Route %s] mock.slow_request_rate must be between 0 and 1, got %v", routePath, mock.SlowRequestRate)
+	}
+	if mock.SlowDelayMs < 0 {
+		return fmt.Errorf("[Route %s] mock.slow_delay_ms cannot be negative, got %d", routePath, mock.SlowDelayMs)
+	}
+	if mock.SlowRequestRate > 0 && mock.SlowDelayMs == 0 {
+		return fmt.Errorf("[Route %s] mock.slow_request_rate requires mock.slow_delay_ms", routePath)
+	}
+
+	for name, body := range mock.NamedVariants {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("[Route %s] mock.named_variants keys cannot be empty", routePath)
+		}
+		if body == nil {
+			return fmt.Errorf("[Route %s] mock.named_variants['%s'] must define a body", routePath, name)
+		}
+	}
+	if mock.VariantQuery && len(mock.NamedVariants) == 0 {
+		return fmt.Errorf("[Route %s] mock.variant_query requires mock.named_variants", routePath)
+	}
+
+	if err := validateWeightedResponses(mock.Responses, routePath); err != nil {
+		return err
+	}
+
+	if err := validateSequence(mock.Sequence, routePath); err != nil {
+		return err
+	}
+	if mock.Sequence != nil && len(mock.Responses) > 0 {
+		return fmt.Errorf("[Route %s] mock.sequence cannot be combined with mock.responses", routePath)
+	}
+
+	return validateCookies(mock.Cookies, routePath, "mock.cookies")
+}
+
+// validateMaskRule checks a response mask rule and applies the strategy and keep defaults.
+func validateMaskRule(rule *MaskRule, routePath string, index int) error {
+	field := strings.TrimSpace(rule.Field)
+	if field == "" || strings.HasPrefix(field, ".") || strings.HasSuffix(field, ".") || strings.Contains(field, "..") {
+		return fmt.Errorf("[Route %s] mask[%d].field must be a dotted path such as 'card.number', got '%s'", routePath, index, rule.Field)
+	}
+	rule.Field = field
+
+	switch rule.Strategy {
+	case "":
+		rule.Strategy = "full"
+	case "full", "partial", "hash":
+	default:
+		return fmt.Errorf("[Route %s] mask[%d].strategy must be 'full', 'partial' or 'hash', got '%s'", routePath, index, rule.Strategy)
+	}
+
+	if rule.Keep < 0 {
+		return fmt.Errorf("[Route %s] mask[%d].keep cannot be negative, got %d", routePath, index, rule.Keep)
+	}
+	if rule.Keep == 0 && rule.Strategy == "partial" {
+		rule.Keep = 4
+	}
+	return nil
+}
+
+// validateWeightedResponses checks that weights are non-negative and that at least one response can be picked.
+func validateWeightedResponses(responses []WeightedResponse, routePath string) error {
+	if len(responses) == 0 {
+		return nil
+	}
+
+	total := 0.0
+	for i, resp := range responses {
+		if resp.Weight < 0 {
+			return fmt.Errorf("[Route %s] mock.responses[%d].weight cannot be negative, got %v", routePath, i, resp.Weight)
+		}
+		if resp.Status != 0 && (resp.Status < 100 || resp.Status > 599) {
+			return fmt.Errorf("[Route %s] mock.responses[%d].status must be between 100 and 599, got %d", routePath, i, resp.Status)
+		}
+		if resp.Body == nil {
+			return fmt.Errorf("[Route %s] mock.responses[%d] must define a body", routePath, i)
+		}
+		total += resp.Weight
+	}
+
+	if total <= 0 {
+		return fmt.Errorf("[Route %s] mock.responses weights must sum to a positive number", routePath)
+	}
+	return nil
+}
+
+// validateSequence checks the ordered responses of mock.sequence.
+func validateSequence(seq *SequenceConfig, routePath string) error {
+	if seq == nil {
+		return nil
+	}
+	if len(seq.Responses) == 0 {
+		return fmt.Errorf("[Route %s] mock.sequence.responses must contain at least one response", routePath)
+	}
+
+	for i, resp := range seq.Responses {
+		field := fmt.Sprintf("mock.sequence.responses[%d]", i)
+		if resp.Status != 0 && (resp.Status < 100 || resp.Status > 599) {
+			return fmt.Errorf("[Route %s] %s.status must be between 100 and 599, got %d", routePath, field, resp.Status)
+		}
+		if resp.Body == nil {
+			return fmt.Errorf("[Route %s] %s must define a body", routePath, field)
+		}
+		if resp.DelayMs < 0 {
+			return fmt.Errorf("[Route %s] %s.delay_ms cannot be negative, got %d", routePath, field, resp.DelayMs)
+		}
+		if err := validateCookies(resp.Cookies, routePath, field+".cookies"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateCookies checks cookie names, lifetimes and SameSite values, normalizing SameSite casing
+func validateCookies(cookies []CookieConfig, routePath, field string) error {
+	for i := range cookies {
+		ck := &cookies[i]
+		if strings.TrimSpace(ck.Name) == "" {
+			return fmt.Errorf("[Route %s] %s[%d].name is required", routePath, field, i)
+		}
+		if strings.ContainsAny(ck.Name, " \t;,=") {
+			return fmt.Errorf("[Route %s] %s[%d].name '%s' contains invalid characters", routePath, field, i, ck.Name)
+		}
+		if ck.MaxAge < 0 {
+			return fmt.Errorf("[Route %s] %s[%d].max_age cannot be negative, got %d", routePath, field, i, ck.MaxAge)
+		}
+
+		switch strings.ToLower(ck.SameSite) {
+		case "":
+		case "lax":
+			ck.SameSite = "Lax"
+		case "strict":
+			ck.SameSite = "Strict"
+		case "none":
+			ck.SameSite = "None"
+		default:
+			return fmt.Errorf("[Route %s] %s[%d].same_site must be one of Lax, Strict, None, got '%s'", routePath, field, i, ck.SameSite)
+		}
+	}
 	return nil
 }
 
@@ -197,10 +1014,45 @@ func validateStateful(cfg *StatefulConfig, routePath string) error {
 		return fmt.Errorf("stateful route '%s' missing required field: 'action'", routePath)
 	}
 	validActions := map[string]bool{
-		"create": true, "get": true, "update": true, "delete": true, "list": true,
+		"create": true, "get": true, "update": true, "patch": true, "delete": true, "list": true,
 	}
 	if !validActions[cfg.Action] {
-		return fmt.Errorf("stateful route '%s' has invalid action '%s'. Valid actions: create, get, update, delete, list", routePath, cfg.Action)
+		return fmt.Errorf("stateful route '%s' has invalid action '%s'. Valid actions: create, get, update, patch, delete, list", routePath, cfg.Action)
+	}
+
+	if cfg.MaxItems < 0 {
+		return fmt.Errorf("stateful route '%s' max_items cannot be negative, got %d", routePath, cfg.MaxItems)
+	}
+
+	switch cfg.AutoID {
+	case "", "uuid", "increment":
+	default:
+		return fmt.Errorf("stateful route '%s' auto_id must be 'uuid' or 'increment', got '%s'", routePath, cfg.AutoID)
+	}
+	if cfg.AutoID != "" && cfg.Action != "create" {
+		return fmt.Errorf("stateful route '%s' auto_id is only supported on the create action", routePath)
+	}
+
+	if cfg.Responses != nil {
+		custom := map[string]*CResponse{
+			"not_found": cfg.Responses.NotFound,
+			"conflict":  cfg.Responses.Conflict,
+			"bad_input": cfg.Responses.BadInput,
+		}
+		for name, resp := range custom {
+			if resp == nil {
+				continue
+			}
+			if resp.Status != 0 && (resp.Status < 100 || resp.Status > 599) {
+				return fmt.Errorf("stateful route '%s' responses.%s.status must be between 100 and 599, got %d", routePath, name, resp.Status)
+			}
+			if resp.DelayMs < 0 {
+				return fmt.Errorf("stateful route '%s' responses.%s.delay_ms cannot be negative", routePath, name)
+			}
+			if err := validateCookies(resp.Cookies, routePath, "stateful.responses."+name+".cookies"); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -229,6 +1081,55 @@ func validateCases(cases []CaseConfig, routePath string) error {
 	return nil
 }
 
+func validateVariants(variants *VariantsConfig, routePath string) error {
+	if strings.TrimSpace(variants.Header) == "" {
+		return fmt.Errorf("[Route %s] variants.header is required", routePath)
+	}
+	if len(variants.Values) == 0 {
+		return fmt.Errorf("[Route %s] variants.values must define at least one value", routePath)
+	}
+
+	for value, resp := range variants.Values {
+		if resp.Status < 100 || resp.Status > 599 {
+			return fmt.Errorf("[Route %s][variant '%s'] invalid status code %d", routePath, value, resp.Status)
+		}
+		if resp.DelayMs < 0 {
+			return fmt.Errorf("[Route %s][variant '%s'] delay_ms cannot be negative", routePath, value)
+		}
+		if err := validateCookies(resp.Cookies, routePath, fmt.Sprintf("variants.values['%s'].cookies", value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkBalancedParens reports unmatched parentheses outside of quoted strings.
+func checkBalancedParens(expr string) error {
+	depth := 0
+	var quote rune
+	for _, r := range expr {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			if depth == 0 {
+				return fmt.Errorf("unexpected ')' in condition")
+			}
+			depth--
+		}
+	}
+	if depth > 0 {
+		return fmt.Errorf("missing closing parenthesis in condition")
+	}
+	return nil
+}
+
 func validateConditionExpression(expr string) error {
 	expr = strings.TrimSpace(expr)
 
@@ -241,11 +1142,16 @@ func validateConditionExpression(expr string) error {
 		return fmt.Errorf("condition contains forbidden characters")
 	}
 
+	// Grouping parentheses are allowed but must balance (quoted text is ignored)
+	if err := checkBalancedParens(expr); err != nil {
+		return err
+	}
+
 	matches := rootRegex.FindAllString(expr, -1)
 
 	if len(matches) == 0 {
 		return fmt.Errorf(
-			"condition must reference one of: body, query, headers, path, method",
+			"condition must reference one of: body, query, headers, path, method, proto, time",
 		)
 	}
 
@@ -263,5 +1169,5 @@ func validateCaseResponse(resp *CResponse, routePath string, index int) error {
 			routePath, index)
 	}
 
-	return nil
+	return validateCookies(resp.Cookies, routePath, fmt.Sprintf("cases[%d].then.cookies", index))
 }
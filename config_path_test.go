@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStartConfigPath verifies --routes-from-openapi replaces the default config but conflicts with an explicit --config.
+func TestStartConfigPath(t *testing.T) {
+	path, err := startConfigPath("mockserver.json", "", false)
+	require.NoError(t, err)
+	assert.Equal(t, "mockserver.json", path)
+
+	path, err = startConfigPath("mockserver.json", "openapi.yaml", false)
+	require.NoError(t, err)
+	assert.Equal(t, "openapi.yaml", path, "the default --config value is not a conflict")
+
+	_, err = startConfigPath("custom.json", "openapi.yaml", true)
+	assert.ErrorContains(t, err, "cannot be used together")
+
+	_, err = startConfigPath("", "", true)
+	assert.Error(t, err)
+}
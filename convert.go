@@ -18,10 +18,21 @@ import (
 const jsonSchemaUrl string = "https://opensource.trymagic.xyz/schemas/mockserver.schema.json"
 
 type OrderedConfig struct {
-	Schema string      `json:"$schema,omitempty" yaml:"$schema,omitempty"`
-	Server interface{} `json:"server" yaml:"server"`
-	Groups interface{} `json:"groups,omitempty" yaml:"groups,omitempty"`
-	Routes interface{} `json:"routes" yaml:"routes"`
+	Schema      string      `json:"$schema,omitempty" yaml:"$schema,omitempty"`
+	Server      interface{} `json:"server" yaml:"server"`
+	Groups      interface{} `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Collections interface{} `json:"collections,omitempty" yaml:"collections,omitempty"`
+	Routes      interface{} `json:"routes" yaml:"routes"`
+	Tests       interface{} `json:"tests,omitempty" yaml:"tests,omitempty"`
+}
+
+// convertOptions controls the optional validation steps of the convert command.
+type convertOptions struct {
+	// Validate runs config validation and refuses to write invalid output
+	Validate bool
+
+	// ApplyDefaults bakes server/route defaults into the output (implies Validate)
+	ApplyDefaults bool
 }
 
 var convertCmd = &cobra.Command{
@@ -33,75 +44,94 @@ var convertCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		cfgData, err := os.ReadFile(inputFile)
-		if err != nil {
-			fmt.Printf("[ERROR] Failed to read input file: %v\n", err)
+		opts := convertOptions{Validate: convertValidate, ApplyDefaults: convertApplyDefaults}
+		if err := runConvert(inputFile, outputFile, opts); err != nil {
+			fmt.Printf("[ERROR] %v\n", err)
 			os.Exit(1)
 		}
 
-		var cfg msconfig.Config
-		ext := strings.ToLower(filepath.Ext(inputFile))
-		cfg.Schema = jsonSchemaUrl
-		switch ext {
-		case ".yaml", ".yml":
-			if err := yaml.Unmarshal(cfgData, &cfg); err != nil {
-				fmt.Printf("[ERROR] Failed to parse YAML: %v\n", err)
-				os.Exit(1)
-			}
-		case ".json":
-			if err := json.Unmarshal(cfgData, &cfg); err != nil {
-				fmt.Printf("[ERROR] Failed to parse JSON: %v\n", err)
-				os.Exit(1)
-			}
-		default:
-			fmt.Println("[ERROR] Unsupported input file format. Use .yaml/.yml or .json")
-			os.Exit(1)
-		}
+		fmt.Printf("✅ Successfully converted '%s' → '%s'\n", inputFile, outputFile)
+	},
+}
 
-		var outData []byte
-		outExt := strings.ToLower(filepath.Ext(outputFile))
+// runConvert reads the input config, optionally validates it and writes it in the output format.
+func runConvert(input, output string, opts convertOptions) error {
+	cfgData, err := os.ReadFile(input)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
 
-		ordered := OrderedConfig{
-			Schema: cfg.Schema,
-			Server: removeEmptyFields(cfg.Server),
-			Routes: removeEmptyFields(cfg.Routes),
+	var cfg msconfig.Config
+	ext := strings.ToLower(filepath.Ext(input))
+	cfg.Schema = jsonSchemaUrl
+	switch ext {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(cfgData, &cfg); err != nil {
+			return fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	case ".json":
+		if err := json.Unmarshal(cfgData, &cfg); err != nil {
+			return fmt.Errorf("failed to parse JSON: %w", err)
 		}
+	default:
+		return fmt.Errorf("unsupported input file format. Use .yaml/.yml or .json")
+	}
 
-		switch outExt {
-		case ".yaml", ".yml":
-			outData, err = yaml.Marshal(ordered)
-			if err != nil {
-				fmt.Printf("[ERROR] Failed to marshal to YAML: %v\n", err)
-				os.Exit(1)
-			}
-		case ".json":
-			outData, err = json.MarshalIndent(ordered, "", "  ")
-			if err != nil {
-				fmt.Printf("[ERROR] Failed to marshal to JSON: %v\n", err)
-				os.Exit(1)
-			}
-		default:
-			fmt.Println("[ERROR] Unsupported output file format. Use .yaml/.yml or .json")
-			os.Exit(1)
+	// Validation (before anything is written)
+	if opts.ApplyDefaults {
+		if err := msconfig.ApplyDefaults(&cfg, input); err != nil {
+			return fmt.Errorf("config validation failed, output not written: %w", err)
+		}
+	} else if opts.Validate {
+		if err := msconfig.ValidateConfig(&cfg, input); err != nil {
+			return fmt.Errorf("config validation failed, output not written: %w", err)
 		}
+	}
 
-		// Ensure output directory exists
-		outDir := filepath.Dir(outputFile)
-		if _, err := os.Stat(outDir); os.IsNotExist(err) {
-			if err := os.MkdirAll(outDir, 0755); err != nil {
-				fmt.Printf("[ERROR] Failed to create output directory: %v\n", err)
-				os.Exit(1)
-			}
+	var outData []byte
+	outExt := strings.ToLower(filepath.Ext(output))
+
+	ordered := OrderedConfig{
+		Schema: cfg.Schema,
+		Server: removeEmptyFields(cfg.Server),
+		Routes: removeEmptyFields(cfg.Routes),
+	}
+	if len(cfg.Collections) > 0 {
+		ordered.Collections = cfg.Collections
+	}
+	if len(cfg.Tests) > 0 {
+		ordered.Tests = removeEmptyFields(cfg.Tests)
+	}
+
+	switch outExt {
+	case ".yaml", ".yml":
+		outData, err = yaml.Marshal(ordered)
+		if err != nil {
+			return fmt.Errorf("failed to marshal to YAML: %w", err)
+		}
+	case ".json":
+		outData, err = json.MarshalIndent(ordered, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal to JSON: %w", err)
 		}
+	default:
+		return fmt.Errorf("unsupported output file format. Use .yaml/.yml or .json")
+	}
 
-		// Write output
-		if err := os.WriteFile(outputFile, outData, 0644); err != nil {
-			fmt.Printf("[ERROR] Failed to write output file: %v\n", err)
-			os.Exit(1)
+	// Ensure output directory exists
+	outDir := filepath.Dir(output)
+	if _, err := os.Stat(outDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
 		}
+	}
 
-		fmt.Printf("✅ Successfully converted '%s' → '%s'\n", inputFile, outputFile)
-	},
+	// Write output
+	if err := os.WriteFile(output, outData, 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	return nil
 }
 
 // Recursive cleanup function
@@ -145,8 +175,12 @@ func removeEmptyFields(i interface{}) interface{} {
 
 var inputFile string
 var outputFile string
+var convertValidate bool
+var convertApplyDefaults bool
 
 func init() {
 	convertCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input config file (yaml/json)")
 	convertCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output config file (yaml/json)")
+	convertCmd.Flags().BoolVar(&convertValidate, "validate", false, "Validate the config and refuse to write output if it is invalid")
+	convertCmd.Flags().BoolVar(&convertApplyDefaults, "apply-defaults", false, "Bake server/route defaults into the output (implies --validate)")
 }
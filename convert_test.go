@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validConvertYAML = `
+server:
+  port: 8080
+routes:
+  - name: "Hello"
+    method: "GET"
+    path: "/hello"
+    mock:
+      status: 200
+      body: { "message": "world" }
+`
+
+const invalidConvertYAML = `
+server:
+  port: 8080
+routes:
+  - name: "Broken"
+    method: "FETCHALL"
+    path: "/broken"
+    mock:
+      body: { "message": "never" }
+`
+
+// TestRunConvert_Validate verifies that --validate blocks invalid configs from being written
+// and that defaults are only baked into the output with --apply-defaults.
+func TestRunConvert_Validate(t *testing.T) {
+	dir := t.TempDir()
+	validIn := filepath.Join(dir, "valid.yaml")
+	invalidIn := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(validIn, []byte(validConvertYAML), 0644))
+	require.NoError(t, os.WriteFile(invalidIn, []byte(invalidConvertYAML), 0644))
+
+	t.Run("Valid config converts without baking defaults", func(t *testing.T) {
+		out := filepath.Join(dir, "valid.json")
+		require.NoError(t, runConvert(validIn, out, convertOptions{Validate: true}))
+
+		data, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"port": 8080`)
+		assert.Contains(t, string(data), `"swagger_ui_path": ""`)
+	})
+
+	t.Run("Valid config with defaults applied", func(t *testing.T) {
+		out := filepath.Join(dir, "defaults.json")
+		require.NoError(t, runConvert(validIn, out, convertOptions{ApplyDefaults: true}))
+
+		data, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"swagger_ui_path": "/docs"`)
+	})
+
+	t.Run("Invalid config is rejected and not written", func(t *testing.T) {
+		out := filepath.Join(dir, "invalid.json")
+		err := runConvert(invalidIn, out, convertOptions{Validate: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid method")
+
+		_, statErr := os.Stat(out)
+		assert.True(t, os.IsNotExist(statErr))
+	})
+
+	t.Run("Invalid config still converts without the flag", func(t *testing.T) {
+		out := filepath.Join(dir, "invalid-unchecked.json")
+		require.NoError(t, runConvert(invalidIn, out, convertOptions{}))
+	})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	msconfig "mockserver/config"
+	msServer "mockserver/server"
+)
+
+// TestServeRuntime_H2C verifies that server.h2c switches the listener to the bridge
+// and that it answers HTTP/2 cleartext requests.
+func TestServeRuntime_H2C(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/ping", func(c *fiber.Ctx) error {
+		return c.SendString("pong")
+	})
+
+	rt := &Runtime{
+		App: app,
+		Cfg: &msconfig.Config{Server: msconfig.ServerConfig{H2C: true}},
+	}
+	require.NoError(t, serveRuntime(rt, addr))
+	defer shutdownRuntime(rt)
+
+	require.NotNil(t, rt.H2C, "h2c option must select the bridge server")
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		resp, err = client.Get("http://" + addr + "/ping")
+		return err == nil
+	}, 2*time.Second, 20*time.Millisecond)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, 2, resp.ProtoMajor)
+	assert.Equal(t, "pong", string(body))
+}
+
+// TestServeRuntime_H2CProto verifies request.proto sees HTTP/2.0 through the bridge and that
+// clients cannot forge the bridge header on HTTP/1.1 requests.
+func TestServeRuntime_H2CProto(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	cfg := &msconfig.Config{
+		Server: msconfig.ServerConfig{H2C: true},
+		Routes: []msconfig.RouteConfig{{
+			Name:   "Proto",
+			Method: "GET",
+			Path:   "/proto",
+			Cases: []msconfig.CaseConfig{
+				{When: "request.proto == 'HTTP/2.0'", Then: msconfig.CResponse{Status: 200, Body: "h2"}},
+			},
+			Default: &msconfig.CResponse{Status: 200, Body: "h1"},
+		}},
+	}
+	require.NoError(t, msconfig.ApplyDefaults(cfg, ""))
+
+	rt := &Runtime{App: msServer.StartServer(cfg, "", embedDir, faviconFS), Cfg: cfg}
+	require.NoError(t, serveRuntime(rt, addr))
+	defer shutdownRuntime(rt)
+
+	get := func(client *http.Client, header string) string {
+		req, err := http.NewRequest("GET", "http://"+addr+"/proto", nil)
+		require.NoError(t, err)
+		if header != "" {
+			req.Header.Set(msServer.ProtoHeader, header)
+		}
+		var resp *http.Response
+		require.Eventually(t, func() bool {
+			resp, err = client.Do(req)
+			return err == nil
+		}, 2*time.Second, 20*time.Millisecond)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	h2Client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	assert.JSONEq(t, `"h2"`, get(h2Client, ""))
+
+	h1Client := &http.Client{Transport: &http.Transport{}}
+	assert.JSONEq(t, `"h1"`, get(h1Client, "HTTP/2.0"))
+}
@@ -0,0 +1,26 @@
+package logger
+
+import (
+	"fmt"
+)
+
+import "github.com/fatih/color"
+
+// autoNoColor is fatih/color's own detection: disabled when NO_COLOR is set, TERM is "dumb" or stdout is not a TTY.
+var autoNoColor = color.NoColor
+
+// SetColorMode controls escape codes in all log output: "auto" (default) detects the terminal,
+// "always" forces colors (e.g. for CI logs that render them) and "never" disables them.
+func SetColorMode(mode string) error {
+	switch mode {
+	case "", "auto":
+		color.NoColor = autoNoColor
+	case "always":
+		color.NoColor = false
+	case "never":
+		color.NoColor = true
+	default:
+		return fmt.Errorf("invalid --color value '%s', must be one of auto, always, never", mode)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureStdout returns everything fn prints to stdout, including color.Output (used by the banner).
+func captureStdout(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	original, originalOutput := os.Stdout, color.Output
+	os.Stdout, color.Output = w, w
+	defer func() { os.Stdout, color.Output = original, originalOutput }()
+
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestSetColorMode(t *testing.T) {
+	defer SetColorMode("auto")
+
+	logAll := func() {
+		LogSuccess("started")
+		LogError("failed")
+		LogRoute("GET", "/users", "127.0.0.1", 200, 0, "")
+		StartupMessage("1.0.0")
+	}
+
+	require.NoError(t, SetColorMode("never"))
+	out := captureStdout(t, logAll)
+	assert.Contains(t, out, "started")
+	assert.NotContains(t, out, "\x1b[")
+
+	require.NoError(t, SetColorMode("always"))
+	out = captureStdout(t, logAll)
+	assert.True(t, strings.Contains(out, "\x1b["), "expected escape codes when colors are forced")
+
+	assert.Error(t, SetColorMode("sometimes"))
+}
@@ -10,6 +10,7 @@ import "github.com/fatih/color"
 
 type Config struct {
 	ShowTimestamp bool
+	Quiet         bool // suppress all log output (e.g. when stdout carries machine-readable data)
 }
 
 var LoggerConfig = Config{
@@ -46,6 +47,10 @@ func printTimestamp() string {
 // msg: log message
 // addEmptyLines: optional parameters → [0]=number of lines, [1]=line insertion position, [2]=starting space
 func logWithType(prefix string, style *color.Color, msg string, addEmptyLines ...int) {
+	if LoggerConfig.Quiet {
+		return
+	}
+
 	n := 0        // number of blank lines
 	space := 0    // leading space
 	position := 1 // line insertion position (1=before, -1=after)
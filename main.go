@@ -7,17 +7,17 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
-	"sync"
 	"syscall"
 	"time"
 
-		"github.com/fsnotify/fsnotify"
 	"github.com/spf13/cobra"
 )
 
 import (
+	msconfig "mockserver/config"
 	appinfo "mockserver/pkg/appinfo"
 	mslogger "mockserver/logger"
+	msServer "mockserver/server"
 )
 
 //go:embed www
@@ -32,32 +32,66 @@ const (
 )
 
 var configFile string
+var openTarget string
+var strictMode bool
+var reloadCommand string
+var pidFile string
+var routesTable bool
+var dumpOpenAPI bool
+var routesFromOpenAPI string
+var colorMode string
 
 func main() {
-	mslogger.StartupMessage(appinfo.Version)
 	mslogger.LoggerConfig.ShowTimestamp = false
 
 	var rootCmd = &cobra.Command{
 		Use:   "mockserver",
 		Short: "MockServer CLI",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if err := mslogger.SetColorMode(colorMode); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		},
 	}
 
 	var startCmd = &cobra.Command{
 		Use:   "start",
 		Short: "Start the mock server",
 		Run: func(cmd *cobra.Command, args []string) {
-			if configFile == "" {
-				fmt.Println("Config file is required. Example: mockserver start --config mockserver.json")
+			path, err := startConfigPath(configFile, routesFromOpenAPI, cmd.Flags().Changed("config"))
+			if err != nil {
+				fmt.Println(err)
 				os.Exit(1)
 			}
+			configFile = path
 
+			msconfig.ForceStrict = strictMode
+			if dumpOpenAPI {
+				if err := dumpOpenAPISpec(configFile, os.Stdout); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				return
+			}
+			mslogger.StartupMessage(appinfo.Version)
 			startApp(configFile)
 		},
 	}
 
 	startCmd.Flags().StringVarP(&configFile, "config", "c", "mockserver.json", "Path to config file")
+	startCmd.Flags().StringVar(&openTarget, "open", "", "Open the console (default) or docs in a browser after startup (--open or --open=docs)")
+	startCmd.Flags().Lookup("open").NoOptDefVal = openTargetConsole
+	startCmd.Flags().StringVar(&reloadCommand, "reload-command", "", "Shell command executed after each successful config reload")
+	startCmd.Flags().StringVar(&pidFile, "pid-file", "", "Write the process id to this file on startup (removed on shutdown)")
+	startCmd.Flags().BoolVar(&routesTable, "routes-table", false, "Print a table of all routes (method, path, type, auth, tag) on startup")
+	startCmd.Flags().BoolVar(&dumpOpenAPI, "dump-openapi", false, "Print the generated OpenAPI spec (JSON) to stdout and exit without starting the server")
+	startCmd.Flags().StringVar(&routesFromOpenAPI, "routes-from-openapi", "", "Serve mocks generated from an OpenAPI 3 file (examples or schema-derived faker data) instead of a config file; cannot be combined with --config")
+	startCmd.Flags().BoolVar(&strictMode, "strict", false, "Treat config validation warnings as errors and refuse to start")
+	rootCmd.PersistentFlags().StringVar(&colorMode, "color", "auto", "Colorize log output: auto (terminal only, respects NO_COLOR), always or never")
 	rootCmd.AddCommand(startCmd)
 	rootCmd.AddCommand(convertCmd)
+	rootCmd.AddCommand(testCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -74,55 +108,60 @@ func startApp(configFile string) {
 	}
 
 	rt := mustLoadAndStart(absConfigPath)
+	rt.ReloadCommand = reloadCommand
+
+	if pidFile != "" {
+		if err := writePIDFile(pidFile); err != nil {
+			fatalExit(err.Error())
+		}
+		rt.PIDFile = pidFile
+	}
 
 	addr := fmt.Sprintf(":%d", rt.Cfg.Server.Port)
-	go listenApp(rt.App, addr)
+	if err := serveRuntime(rt, addr); err != nil {
+		fatalExit(fmt.Sprintf("Failed to listen on %s: %v", addr, err))
+	}
 	mslogger.LogServerStart(addr)
+	if routesTable {
+		printRoutesTable(rt.Cfg)
+	}
 	mslogger.LogSuccess(fmt.Sprintf("Interface: %s", mslogger.GetServerHost(addr, rt.Cfg.Server.Console.Path)), 0)
 
+	if openTarget != "" {
+		if url, err := buildOpenURL(rt.Cfg, openTarget); err != nil {
+			mslogger.LogWarn(err.Error())
+		} else {
+			openBrowser(url)
+		}
+	}
+
 	watchConfigFile(configFile, rt)
 }
 
 
-// watchConfigFile sets up fsnotify watcher and handles reload
+// watchConfigFile reloads the server on config changes until a shutdown signal arrives
 func watchConfigFile(configFile string, rt *Runtime) {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		fatalExit(fmt.Sprintf("Failed to start config watcher: %v", err))
-	}
-	defer watcher.Close()
-
-	if err := watcher.Add(configFile); err != nil {
-		fatalExit(fmt.Sprintf("Failed to watch config file: %v", err))
-	}
-
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	var reloadTimer *time.Timer
-	var mu sync.Mutex
-
-	for {
-		select {
-		case event := <-watcher.Events:
-			if event.Op&fsnotify.Write == fsnotify.Write {
-				mu.Lock()
-				if reloadTimer != nil {
-					reloadTimer.Stop()
-				}
-				reloadTimer = time.AfterFunc(debounceDelay, func() {
-					reloadServer(configFile, rt)
-				})
-				mu.Unlock()
-			}
-
-		case err := <-watcher.Errors:
-			mslogger.LogError(fmt.Sprintf("Config watcher error: %v", err))
-
-		case sig := <-sigChan:
-			handleSignal(sig, rt)
-			return
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- watchConfig(configFile, debounceDelay, func() {
+			reloadServer(configFile, rt)
+		}, stop)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			fatalExit(err.Error())
 		}
+		mslogger.LogWarn("Config watcher stopped, live reload is disabled")
+		handleSignal(<-sigChan, rt)
+	case sig := <-sigChan:
+		close(stop)
+		handleSignal(sig, rt)
 	}
 }
 
@@ -135,9 +174,11 @@ func handleSignal(sig os.Signal, rt *Runtime) {
 		fmt.Sprintf("Signal received (%s), shutting down gracefully...", sig),
 	)
 
-	if rt.App != nil {
-		_ = rt.App.Shutdown()
+	shutdownRuntime(rt)
+	if err := msServer.FlushState(); err != nil {
+		mslogger.LogError("Failed to save state snapshot: " + err.Error())
 	}
+	removePIDFile(rt.PIDFile)
 
 	mslogger.LogInfo("MockServer stopped. Goodbye! 👋")
 }
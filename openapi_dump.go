@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+import (
+	mslogger "mockserver/logger"
+	msServer "mockserver/server"
+)
+
+// dumpOpenAPISpec loads the config and writes the generated OpenAPI spec to w (--dump-openapi).
+// Logging is silenced so w can be piped into codegen tools; nothing is started or listened on.
+func dumpOpenAPISpec(configFile string, w io.Writer) error {
+	mslogger.LoggerConfig.Quiet = true
+	defer func() { mslogger.LoggerConfig.Quiet = false }()
+
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+
+	spec, err := msServer.OpenAPISpecJSON(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode OpenAPI spec: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(spec))
+	return err
+}
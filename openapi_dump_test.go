@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDumpOpenAPISpec verifies --dump-openapi writes only a valid spec and never starts the server.
+func TestDumpOpenAPISpec(t *testing.T) {
+	// Reserve a free port, then release it so a started server could bind it
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	configPath := filepath.Join(t.TempDir(), "mockserver.json")
+	config := fmt.Sprintf(`{
+		"server": {"port": %d, "api_prefix": "/api"},
+		"routes": [{"name": "List Users", "method": "GET", "path": "/users", "mock": {"status": 200, "body": []}}]
+	}`, port)
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0o644))
+
+	var out bytes.Buffer
+	require.NoError(t, dumpOpenAPISpec(configPath, &out))
+
+	var spec map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &spec), "output must be the spec alone")
+	assert.Equal(t, "3.0.0", spec["openapi"])
+	assert.Contains(t, spec["paths"], "/api/users")
+
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 200*time.Millisecond)
+	if err == nil {
+		conn.Close()
+	}
+	assert.Error(t, err, "nothing must be listening after a dump")
+
+	assert.Error(t, dumpOpenAPISpec(filepath.Join(t.TempDir(), "missing.json"), &out))
+}
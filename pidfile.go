@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+import (
+	mslogger "mockserver/logger"
+)
+
+// writePIDFile records the current process id at path (--pid-file) for init systems and supervisors.
+func writePIDFile(path string) error {
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write pid file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// removePIDFile deletes the pid file on shutdown. A missing file is not an error.
+func removePIDFile(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		mslogger.LogWarn(fmt.Sprintf("Failed to remove pid file '%s': %v", path, err))
+	}
+}
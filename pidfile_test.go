@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPIDFile_Lifecycle verifies --pid-file is written with the current pid and removed on shutdown.
+func TestPIDFile_Lifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mockserver.pid")
+
+	require.NoError(t, writePIDFile(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid()), strings.TrimSpace(string(data)))
+
+	handleSignal(syscall.SIGTERM, &Runtime{PIDFile: path})
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "pid file must be removed on shutdown")
+}
+
+// TestPIDFile_WriteFailure verifies an unwritable location is reported instead of ignored.
+func TestPIDFile_WriteFailure(t *testing.T) {
+	err := writePIDFile(filepath.Join(t.TempDir(), "missing", "mockserver.pid"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to write pid file")
+}
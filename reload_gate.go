@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+)
+
+// reloadGate holds the listen address while a reload swaps apps,
+// answering 503 RELOADING instead of letting connections be refused.
+type reloadGate struct {
+	server   *http.Server
+	listener net.Listener
+}
+
+// startReloadGate binds addr and serves the RELOADING response until Close is called.
+func startReloadGate(addr string) (*reloadGate, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &http.Server{Handler: http.HandlerFunc(reloadingHandler), ReadHeaderTimeout: 5 * time.Second}
+	// Each connection is closed after its response so clients reconnect to the new app
+	srv.SetKeepAlivesEnabled(false)
+
+	go func() { _ = srv.Serve(ln) }()
+	return &reloadGate{server: srv, listener: ln}, nil
+}
+
+// Addr returns the address the gate is bound to.
+func (g *reloadGate) Addr() string {
+	return g.listener.Addr().String()
+}
+
+// Close releases the address so the new app can listen on it.
+func (g *reloadGate) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = g.server.Shutdown(ctx)
+	// Shutdown skips a listener Serve has not picked up yet; release it here so the port is free on return
+	_ = g.listener.Close()
+}
+
+// reloadingHandler mirrors the server's JSON error shape.
+func reloadingHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", "1")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   false,
+		"status":    http.StatusServiceUnavailable,
+		"error":     http.StatusText(http.StatusServiceUnavailable),
+		"errorCode": "RELOADING",
+		"message":   "Server is reloading, retry shortly",
+		"timestamp": time.Now().UTC().UnixNano() / 1e6,
+	})
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReloadGate verifies requests during the reload window get a clean 503
+// and that closing the gate frees the address for the new app.
+func TestReloadGate(t *testing.T) {
+	gate, err := startReloadGate("127.0.0.1:0")
+	require.NoError(t, err)
+	addr := gate.Addr()
+
+	resp, err := http.Get("http://" + addr + "/v1/users")
+	require.NoError(t, err, "requests during the window must not be refused")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "RELOADING", body["errorCode"])
+
+	gate.Close()
+
+	// The new app must be able to bind the same address
+	next, err := startReloadGate(addr)
+	require.NoError(t, err, "address must be released after Close")
+	next.Close()
+}
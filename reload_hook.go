@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+import (
+	mslogger "mockserver/logger"
+)
+
+// Upper bound for a --reload-command run
+const reloadHookTimeout = 30 * time.Second
+
+// reloadHookCommand wraps the user command in the platform shell.
+func reloadHookCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// runReloadHook executes the --reload-command after a successful reload and logs its output.
+// Failures are only logged; they never affect the running server.
+func runReloadHook(command string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := reloadHookCommand(ctx, command)
+	cmd.WaitDelay = time.Second // don't hang on pipes kept open by orphaned children after a timeout
+
+	output, err := cmd.CombinedOutput()
+	if out := strings.TrimSpace(string(output)); out != "" {
+		mslogger.LogInfo(fmt.Sprintf("Reload command output: %s", out))
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("reload command timed out after %v", timeout)
+	}
+	if err != nil {
+		mslogger.LogError(fmt.Sprintf("Reload command failed: %v", err))
+		return err
+	}
+
+	mslogger.LogSuccess("Reload command completed")
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReloadServer_RunsReloadCommand verifies the --reload-command hook runs after a successful reload.
+func TestReloadServer_RunsReloadCommand(t *testing.T) {
+	dir := t.TempDir()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	require.NoError(t, ln.Close())
+
+	configPath := filepath.Join(dir, "mockserver.yaml")
+	config := fmt.Sprintf(`
+server:
+  port: %d
+routes:
+  - name: "Hello"
+    method: "GET"
+    path: "/hello"
+    mock:
+      status: 200
+      body: { "message": "world" }
+`, port)
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0644))
+
+	marker := filepath.Join(dir, "reloaded.marker")
+	rt := &Runtime{ReloadCommand: fmt.Sprintf("echo reloaded > %q", marker)}
+
+	reloadServer(configPath, rt)
+	defer shutdownRuntime(rt)
+	require.NotNil(t, rt.App, "reload must succeed")
+
+	// The new listener is bound by the time the reload returns
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/hello", port))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(marker)
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+// TestRunReloadHook_Failure verifies failing or slow commands are reported, not fatal.
+func TestRunReloadHook_Failure(t *testing.T) {
+	assert.Error(t, runReloadHook("exit 3", time.Second))
+	assert.Error(t, runReloadHook("sleep 5", 50*time.Millisecond))
+	assert.NoError(t, runReloadHook("true", time.Second))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/pterm/pterm"
+)
+
+import (
+	msconfig "mockserver/config"
+)
+
+// routesTableRows builds the startup routes summary (--routes-table): a header row followed by
+// one row per route with its method, full path, handler type, effective auth and tag.
+func routesTableRows(cfg *msconfig.Config) [][]string {
+	prefix := "/" + strings.Trim(cfg.Server.APIPrefix, "/")
+	if prefix == "/" {
+		prefix = ""
+	}
+
+	rows := [][]string{{"METHOD", "PATH", "TYPE", "AUTH", "TAG"}}
+	for _, route := range cfg.Routes {
+		auth := cfg.Server.Auth
+		if route.Auth != nil {
+			auth = route.Auth
+		}
+		authLabel := "-"
+		if auth != nil && auth.Enabled {
+			authLabel = strings.ToLower(auth.Type)
+		}
+		if len(route.AuthAny) > 0 {
+			var types []string
+			for _, scheme := range route.AuthAny {
+				if scheme.Enabled {
+					types = append(types, strings.ToLower(scheme.Type))
+				}
+			}
+			if len(types) > 0 {
+				authLabel = strings.Join(types, "|")
+			} else {
+				authLabel = "-"
+			}
+		}
+
+		tag := route.Tag
+		if tag == "" {
+			tag = "-"
+		}
+
+		rows = append(rows, []string{strings.ToUpper(route.Method), prefix + route.Path, routeType(route), authLabel, tag})
+	}
+	return rows
+}
+
+// routeType names the handler serving a route, in the order the server resolves them.
+func routeType(route msconfig.RouteConfig) string {
+	switch {
+	case route.Static != nil:
+		return "static"
+	case route.Stateful != nil:
+		return "stateful"
+	case route.Mock != nil:
+		return "mock"
+	case route.Fetch != nil:
+		return "fetch"
+	case route.Echo:
+		return "echo"
+	default:
+		return "cases"
+	}
+}
+
+// printRoutesTable renders the routes summary to the console.
+func printRoutesTable(cfg *msconfig.Config) {
+	_ = pterm.DefaultTable.WithHasHeader().WithData(routesTableRows(cfg)).Render()
+}
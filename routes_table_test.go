@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	msconfig "mockserver/config"
+)
+
+// TestRoutesTableRows verifies the --routes-table summary rows for each route kind.
+func TestRoutesTableRows(t *testing.T) {
+	cfg := &msconfig.Config{
+		Server: msconfig.ServerConfig{
+			APIPrefix: "/v1/",
+			Auth:      &msconfig.AuthConfig{Enabled: true, Type: "Bearer"},
+		},
+		Routes: []msconfig.RouteConfig{
+			{Method: "get", Path: "/users", Tag: "Users", Mock: &msconfig.MockConfig{}},
+			{Method: "POST", Path: "/orders", Stateful: &msconfig.StatefulConfig{}, Mock: &msconfig.MockConfig{}},
+			{Method: "GET", Path: "/upstream", Fetch: &msconfig.FetchConfig{}, Auth: &msconfig.AuthConfig{Enabled: false}},
+			{Method: "GET", Path: "/echo", Echo: true},
+			{Method: "GET", Path: "/assets", Static: &msconfig.StaticConfig{}},
+			{Method: "GET", Path: "/rules", Cases: []msconfig.CaseConfig{{When: "true"}}},
+		},
+	}
+
+	assert.Equal(t, [][]string{
+		{"METHOD", "PATH", "TYPE", "AUTH", "TAG"},
+		{"GET", "/v1/users", "mock", "bearer", "Users"},
+		{"POST", "/v1/orders", "stateful", "bearer", "-"},
+		{"GET", "/v1/upstream", "fetch", "-", "-"},
+		{"GET", "/v1/echo", "echo", "bearer", "-"},
+		{"GET", "/v1/assets", "static", "bearer", "-"},
+		{"GET", "/v1/rules", "cases", "bearer", "-"},
+	}, routesTableRows(cfg))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"net/http"
 	"sync"
 
 	"github.com/gofiber/fiber/v2"
@@ -8,7 +9,10 @@ import (
 )
 
 type Runtime struct {
-	App    *fiber.App
-	Cfg    *msconfig.Config
-	Mu     sync.Mutex
+	App           *fiber.App
+	Cfg           *msconfig.Config
+	H2C           *http.Server // set only while serving through the h2c bridge
+	ReloadCommand string       // shell command run after each successful reload (--reload-command)
+	PIDFile       string       // removed on shutdown when set (--pid-file)
+	Mu            sync.Mutex
 }
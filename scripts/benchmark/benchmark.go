@@ -4,11 +4,17 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/csv"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -17,9 +23,12 @@ import (
 )
 
 var (
-	successCount int64
-	errorCount   int64
-	requestID    uint64
+	successCount  int64
+	errorCount    int64
+	mismatchCount int64 // responses that arrived but failed the status/body-size checks
+	bytesRead     int64
+	requestID     uint64
+	recording     int32 // 0 during warmup, 1 once measured requests are counted
 )
 
 func main() {
@@ -29,12 +38,23 @@ func main() {
 	duration := flag.Duration("d", 30*time.Second, "Duration")
 	method := flag.String("m", "POST", "Method")
 	auth := flag.String("auth", "Bearer benchmark-secret-key", "Auth Header")
+	expectStatus := flag.Int("expect-status", 0, "Expected response status (0 = any status < 400)")
+	minBody := flag.Int64("min-body", 0, "Minimum response body size in bytes (0 = not checked)")
+	warmup := flag.Duration("warmup", 0, "Warmup duration whose results are discarded")
+	histogramOut := flag.String("histogram-out", "", "Write the latency histogram to a .csv or .json file")
+	histogramBucket := flag.Duration("histogram-bucket", 100*time.Microsecond, "Latency histogram bucket width")
 	flag.Parse()
 
+	// Options are checked before the run, so a bad histogram setting never throws away a finished benchmark
+	if err := validateOptions(*concurrency, *duration, *warmup, *histogramOut, *histogramBucket); err != nil {
+		pterm.Error.Println(err)
+		os.Exit(2)
+	}
+
 	pterm.DefaultHeader.WithFullWidth().WithBackgroundStyle(pterm.NewStyle(pterm.BgCyan)).WithMargin(10).Println("MOCKSERVER PERFORMANCE BENCHMARK")
-	fmt.Printf("Target  : %s [%s]\nWorkers : %d\nDuration: %v\n\n", *targetURL, *method, *concurrency, *duration)
+	fmt.Printf("Target  : %s [%s]\nWorkers : %d\nWarmup  : %v\nDuration: %v\n\n", *targetURL, *method, *concurrency, *warmup, *duration)
 
-	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	ctx, cancel := context.WithTimeout(context.Background(), *warmup+*duration)
 	defer cancel()
 
 	// HTTP Client Optimization
@@ -47,11 +67,29 @@ func main() {
 	}
 	client := &http.Client{Transport: transport, Timeout: 10 * time.Second}
 
-	results := make(chan time.Duration, 1000000)
+	// Each worker records into its own slice, so no latency sample is ever dropped
+	workerLatencies := make([][]time.Duration, *concurrency)
 	var wg sync.WaitGroup
 	wg.Add(*concurrency)
 
+	// Measurement starts once the warmup is over; requests started during warmup are discarded
+	var startMu sync.Mutex
 	start := time.Now()
+	if *warmup > 0 {
+		time.AfterFunc(*warmup, func() {
+			startMu.Lock()
+			start = time.Now()
+			startMu.Unlock()
+			atomic.StoreInt32(&recording, 1)
+		})
+	} else {
+		atomic.StoreInt32(&recording, 1)
+	}
+	measureStart := func() time.Time {
+		startMu.Lock()
+		defer startMu.Unlock()
+		return start
+	}
 
 	liveArea, _ := pterm.DefaultArea.Start()
 
@@ -61,14 +99,21 @@ func main() {
 			case <-ctx.Done():
 				return
 			case <-time.After(500 * time.Millisecond):
-				elapsed := time.Since(start).Seconds()
+				if atomic.LoadInt32(&recording) == 0 {
+					liveArea.Update(pterm.FgGray.Sprintf("Warming up (%v)...", *warmup))
+					continue
+				}
+
+				elapsed := time.Since(measureStart()).Seconds()
 				currSuccess := atomic.LoadInt64(&successCount)
 				currErrors := atomic.LoadInt64(&errorCount)
-				rps := float64(currSuccess+currErrors) / elapsed
+				currMismatches := atomic.LoadInt64(&mismatchCount)
+				rps := float64(currSuccess+currErrors+currMismatches) / elapsed
+				bps := float64(atomic.LoadInt64(&bytesRead)) / elapsed
 
 				stats, _ := pterm.DefaultTable.WithData(pterm.TableData{
-					{"Current RPS", "Success", "Errors", "Elapsed"},
-					{fmt.Sprintf("%.2f", rps), pterm.FgGreen.Sprintf("%d", currSuccess), pterm.FgRed.Sprintf("%d", currErrors), fmt.Sprintf("%.1fs", elapsed)},
+					{"Current RPS", "Throughput", "Success", "Mismatches", "Errors", "Elapsed"},
+					{fmt.Sprintf("%.2f", rps), formatBytes(bps) + "/s", pterm.FgGreen.Sprintf("%d", currSuccess), pterm.FgYellow.Sprintf("%d", currMismatches), pterm.FgRed.Sprintf("%d", currErrors), fmt.Sprintf("%.1fs", elapsed)},
 				}).Srender()
 
 				liveArea.Update(stats)
@@ -78,13 +123,14 @@ func main() {
 
 	// WORKERS
 	for i := 0; i < *concurrency; i++ {
-		go func() {
+		go func(worker int) {
 			defer wg.Done()
 			for {
 				select {
 				case <-ctx.Done():
 					return
 				default:
+					measured := atomic.LoadInt32(&recording) == 1
 					reqStart := time.Now()
 
 					var bodyBuffer *bytes.Buffer
@@ -103,66 +149,185 @@ func main() {
 
 					resp, err := client.Do(req)
 					if err != nil {
-						atomic.AddInt64(&errorCount, 1)
+						// Requests cut off by the end of the run are not errors
+						if measured && ctx.Err() == nil {
+							atomic.AddInt64(&errorCount, 1)
+						}
 						continue
 					}
 
-					if resp.StatusCode >= 400 {
-						atomic.AddInt64(&errorCount, 1)
-					} else {
-						atomic.AddInt64(&successCount, 1)
-						select {
-						case results <- time.Since(reqStart):
-						default:
+					// Drain the body to measure its size (also keeps the connection reusable)
+					n, err := io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+					if !measured {
+						continue
+					}
+
+					atomic.AddInt64(&bytesRead, n)
+					if err != nil {
+						if ctx.Err() == nil {
+							atomic.AddInt64(&errorCount, 1)
 						}
+						continue
+					}
+
+					if classify(resp.StatusCode, n, *expectStatus, *minBody) {
+						workerLatencies[worker] = append(workerLatencies[worker], time.Since(reqStart))
 					}
-					resp.Body.Close()
 				}
 			}
-		}()
+		}(i)
 	}
 
 	wg.Wait()
 	liveArea.Stop()
-	close(results)
-	totalDuration := time.Since(start)
+	totalDuration := time.Since(measureStart())
+
+	var latencies []time.Duration
+	for _, l := range workerLatencies {
+		latencies = append(latencies, l...)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	generateFinalReport(latencies, successCount, errorCount, mismatchCount, bytesRead, totalDuration)
 
-	generateFinalReport(results, successCount, errorCount, totalDuration)
+	if *histogramOut != "" {
+		if err := writeHistogram(*histogramOut, latencies, *histogramBucket); err != nil {
+			pterm.Error.Printf("Failed to write histogram: %v\n", err)
+			os.Exit(1)
+		}
+		pterm.Success.Printf("Latency histogram written to %s\n", *histogramOut)
+	}
 }
 
-func generateFinalReport(results chan time.Duration, success, errors int64, totalDur time.Duration) {
-	var latencies []time.Duration
-	for l := range results {
-		latencies = append(latencies, l)
+// classify counts a completed response as success, content mismatch or HTTP error.
+// It reports whether the response succeeded (and its latency should be recorded).
+func classify(status int, bodySize int64, expectStatus int, minBody int64) bool {
+	switch {
+	case expectStatus != 0 && status != expectStatus:
+		atomic.AddInt64(&mismatchCount, 1)
+	case expectStatus == 0 && status >= 400:
+		atomic.AddInt64(&errorCount, 1)
+	case bodySize < minBody:
+		atomic.AddInt64(&mismatchCount, 1)
+	default:
+		atomic.AddInt64(&successCount, 1)
+		return true
 	}
+	return false
+}
 
-	totalReq := success + errors
+// generateFinalReport renders the summary table; latencies must be sorted ascending.
+func generateFinalReport(latencies []time.Duration, success, errors, mismatches, totalBytes int64, totalDur time.Duration) {
+	totalReq := success + errors + mismatches
 	if totalReq == 0 {
 		pterm.Error.Println("No requests completed.")
 		return
 	}
 
-	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
-
-	p50 := latencies[int(float64(len(latencies))*0.50)]
-	p95 := latencies[int(float64(len(latencies))*0.95)]
+	// Latencies are only recorded for successful requests
+	p50, p95 := "n/a", "n/a"
+	if len(latencies) > 0 {
+		p50 = fmt.Sprintf("%v", latencies[int(float64(len(latencies))*0.50)].Round(time.Microsecond))
+		p95 = fmt.Sprintf("%v", latencies[int(float64(len(latencies))*0.95)].Round(time.Microsecond))
+	}
 	rps := float64(totalReq) / totalDur.Seconds()
+	bps := float64(totalBytes) / totalDur.Seconds()
 
 	pterm.DefaultSection.Println("FINAL BENCHMARK RESULTS")
 
 	tableData := pterm.TableData{
 		{"Metric", "Value"},
 		{"Throughput (RPS)", pterm.FgCyan.Sprintf("%.2f req/s", rps)},
+		{"Throughput (Bytes)", pterm.FgCyan.Sprintf("%s/s", formatBytes(bps))},
 		{"Total Requests", fmt.Sprintf("%d", totalReq)},
+		{"Total Received", formatBytes(float64(totalBytes))},
 		{"Success Rate", pterm.FgGreen.Sprintf("%.2f%%", float64(success)/float64(totalReq)*100)},
-		{"P50 (Median)", fmt.Sprintf("%v", p50.Round(time.Microsecond))},
-		{"P95 (Tail)", pterm.FgYellow.Sprintf("%v", p95.Round(time.Microsecond))},
+		{"P50 (Median)", p50},
+		{"P95 (Tail)", pterm.FgYellow.Sprint(p95)},
+		{"Content Mismatches", pterm.FgYellow.Sprintf("%d", mismatches)},
 		{"Total Errors", pterm.FgRed.Sprintf("%d", errors)},
 	}
 
 	pterm.DefaultTable.WithHasHeader().WithBoxed().WithData(tableData).Render()
 }
 
+// validateOptions rejects flag values the run cannot use.
+func validateOptions(concurrency int, duration, warmup time.Duration, histogramOut string, histogramBucket time.Duration) error {
+	if concurrency <= 0 {
+		return fmt.Errorf("-c must be at least 1, got %d", concurrency)
+	}
+	if duration <= 0 {
+		return fmt.Errorf("-d must be positive, got %v", duration)
+	}
+	if warmup < 0 {
+		return fmt.Errorf("-warmup cannot be negative, got %v", warmup)
+	}
+	if histogramOut != "" {
+		return validateHistogram(histogramOut, histogramBucket)
+	}
+	return nil
+}
+
+// validateHistogram checks the histogram bucket width and output format.
+func validateHistogram(path string, bucket time.Duration) error {
+	if bucket < time.Microsecond {
+		return fmt.Errorf("histogram bucket must be at least 1µs, got %v", bucket)
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".csv" && ext != ".json" {
+		return fmt.Errorf("unsupported histogram format '%s', use .csv or .json", ext)
+	}
+	return nil
+}
+
+// writeHistogram exports the latency distribution as fixed-width buckets to a .csv or .json file.
+// Each bucket reports its upper bound (µs) and the number of samples that fell into it.
+func writeHistogram(path string, latencies []time.Duration, bucket time.Duration) error {
+	if err := validateHistogram(path, bucket); err != nil {
+		return err
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+
+	type histogramBucket struct {
+		UpperBoundUs int64 `json:"upper_bound_us"`
+		Count        int   `json:"count"`
+	}
+
+	var buckets []histogramBucket
+	for _, l := range latencies {
+		upper := (int64(l/bucket) + 1) * bucket.Microseconds()
+		if n := len(buckets); n > 0 && buckets[n-1].UpperBoundUs == upper {
+			buckets[n-1].Count++
+			continue
+		}
+		buckets = append(buckets, histogramBucket{UpperBoundUs: upper, Count: 1})
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if ext == ".json" {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"bucket_us": bucket.Microseconds(),
+			"samples":   len(latencies),
+			"buckets":   buckets,
+		})
+	}
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"upper_bound_us", "count"})
+	for _, b := range buckets {
+		w.Write([]string{strconv.FormatInt(b.UpperBoundUs, 10), strconv.Itoa(b.Count)})
+	}
+	w.Flush()
+	return w.Error()
+}
+
 func avg(latencies []time.Duration) time.Duration {
 	if len(latencies) == 0 {
 		return 0
@@ -173,3 +338,14 @@ func avg(latencies []time.Duration) time.Duration {
 	}
 	return time.Duration(sum / int64(len(latencies)))
 }
+
+// formatBytes renders a byte count with a binary unit suffix (B, KB, MB, GB).
+func formatBytes(b float64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	i := 0
+	for b >= 1024 && i < len(units)-1 {
+		b /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.2f %s", b, units[i])
+}
@@ -243,9 +243,7 @@ func SafeConfigHandler(cfg *msconfig.Config) fiber.Handler {
 		json.Unmarshal(rawBytes, &safeCfg)
 
 		// Mask Global Auth
-		if safeCfg.Server.Auth != nil && safeCfg.Server.Auth.Enabled {
-			safeCfg.Server.Auth.Keys = []string{MaskedValue}
-		}
+		maskAuthSecrets(safeCfg.Server.Auth)
 
 		// Mask Console Password
 		if safeCfg.Server.Console != nil && safeCfg.Server.Console.Auth != nil {
@@ -254,8 +252,9 @@ func SafeConfigHandler(cfg *msconfig.Config) fiber.Handler {
 
 		// Mask Route-specific Auth
 		for i := range safeCfg.Routes {
-			if safeCfg.Routes[i].Auth != nil && safeCfg.Routes[i].Auth.Enabled {
-				safeCfg.Routes[i].Auth.Keys = []string{MaskedValue}
+			maskAuthSecrets(safeCfg.Routes[i].Auth)
+			for j := range safeCfg.Routes[i].AuthAny {
+				maskAuthSecrets(&safeCfg.Routes[i].AuthAny[j])
 			}
 		}
 
@@ -263,6 +262,19 @@ func SafeConfigHandler(cfg *msconfig.Config) fiber.Handler {
 	}
 }
 
+// maskAuthSecrets hides the keys and the HMAC signing secret of an auth scheme.
+func maskAuthSecrets(auth *msconfig.AuthConfig) {
+	if auth == nil {
+		return
+	}
+	if len(auth.Keys) > 0 {
+		auth.Keys = []string{MaskedValue}
+	}
+	if auth.Secret != "" {
+		auth.Secret = MaskedValue
+	}
+}
+
 // ConsoleAssetGuard middleware protects static assets from hotlinking.
 // It ensures that assets (.js, .css, .map) are only loaded within the console context.
 func ConsoleAssetGuard(consoleCfg *msconfig.ConsoleConfig) fiber.Handler {
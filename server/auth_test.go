@@ -0,0 +1,82 @@
+package server
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+import (
+	msconfig "mockserver/config"
+)
+
+// safeConfigDump returns the body served by SafeConfigHandler for cfg.
+func safeConfigDump(t *testing.T, cfg *msconfig.Config) string {
+	app := fiber.New()
+	app.Get("/config", SafeConfigHandler(cfg))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/config", nil), -1)
+	require.NoError(t, err)
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(raw)
+}
+
+// TestSafeConfigHandlerMasksHMACSecret verifies the signing secret never leaves the server.
+func TestSafeConfigHandlerMasksHMACSecret(t *testing.T) {
+	cfg := &msconfig.Config{
+		Server: msconfig.ServerConfig{
+			Auth: &msconfig.AuthConfig{Enabled: true, Type: "hmac", Name: "X-Signature", Secret: "server-signing-secret"},
+		},
+		Routes: []msconfig.RouteConfig{
+			{Name: "Webhook", Method: "POST", Path: "/hook", Auth: &msconfig.AuthConfig{Enabled: true, Type: "hmac", Name: "X-Sig", Secret: "route-signing-secret"}},
+		},
+	}
+
+	dump := safeConfigDump(t, cfg)
+	assert.NotContains(t, dump, "server-signing-secret")
+	assert.NotContains(t, dump, "route-signing-secret")
+	assert.Contains(t, dump, MaskedValue)
+
+	// The runtime config is left untouched
+	assert.Equal(t, "route-signing-secret", cfg.Routes[0].Auth.Secret)
+}
+
+// TestSafeConfigHandlerMasksAuthAnyKeys verifies every auth_any scheme is masked like the route auth.
+func TestSafeConfigHandlerMasksAuthAnyKeys(t *testing.T) {
+	cfg := &msconfig.Config{
+		Routes: []msconfig.RouteConfig{
+			{Name: "Either", Method: "GET", Path: "/either", AuthAny: []msconfig.AuthConfig{
+				{Enabled: true, Type: "apikey", In: "header", Name: "X-API-Key", Keys: []string{"any-key-1", "any-key-2"}},
+				{Enabled: true, Type: "bearer", Keys: []string{"any-token"}},
+			}},
+		},
+	}
+
+	dump := safeConfigDump(t, cfg)
+	for _, secret := range []string{"any-key-1", "any-key-2", "any-token"} {
+		assert.NotContains(t, dump, secret)
+	}
+	assert.Contains(t, dump, "X-API-Key", "non-secret settings stay visible")
+}
+
+// TestSafeConfigHandlerMasksBasicCredentials verifies "user:pass" pairs are hidden, including disabled schemes.
+func TestSafeConfigHandlerMasksBasicCredentials(t *testing.T) {
+	cfg := &msconfig.Config{
+		Routes: []msconfig.RouteConfig{
+			{Name: "Admin", Method: "GET", Path: "/admin", AuthAny: []msconfig.AuthConfig{
+				{Enabled: true, Type: "basic", Keys: []string{"admin:s3cret"}},
+				{Enabled: false, Type: "basic", Keys: []string{"legacy:old-pass"}},
+			}},
+		},
+	}
+
+	dump := safeConfigDump(t, cfg)
+	assert.NotContains(t, dump, "s3cret")
+	assert.NotContains(t, dump, "old-pass")
+	assert.NotContains(t, dump, "admin:")
+}
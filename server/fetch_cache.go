@@ -0,0 +1,158 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fetchCacheHeader tells clients whether a fetch.cache_ttl_ms route answered from memory ("HIT") or upstream ("MISS").
+const fetchCacheHeader = "X-Mock-Cache"
+
+// cacheableMethods are the only requests fetch.cache_ttl_ms serves from memory.
+var cacheableMethods = map[string]bool{
+	http.MethodGet:  true,
+	http.MethodHead: true,
+}
+
+// credentialHeaders mark a request as user-specific; such requests never share cache entries.
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// fetchCacheEntry is an upstream response as received, before error replacement or response_transform.
+type fetchCacheEntry struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+
+	// Request header values, as sent upstream, for each header named in the response's Vary
+	vary map[string]string
+}
+
+// fetchCache keeps upstream responses keyed by method, final target URL and the fetch.headers sent; nil disables caching.
+type fetchCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]fetchCacheEntry
+}
+
+func newFetchCache(ttlMs int) *fetchCache {
+	if ttlMs <= 0 {
+		return nil
+	}
+	return &fetchCache{
+		ttl:     time.Duration(ttlMs) * time.Millisecond,
+		entries: map[string]fetchCacheEntry{},
+	}
+}
+
+// fetchCacheKey includes the rendered fetch.headers so templated values (e.g. a tenant header) get their own entries.
+func fetchCacheKey(method, targetURL string, headers map[string]string) string {
+	key := method + " " + targetURL
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key += "\n" + strings.ToLower(name) + ": " + headers[name]
+	}
+	return key
+}
+
+// hasCredentials reports whether the outgoing request carries credentials via header lookup get.
+func hasCredentials(get func(name string) string) bool {
+	for _, name := range credentialHeaders {
+		if get(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// varyNames returns the canonical header names the response varies on; ok is false for "Vary: *".
+func varyNames(header http.Header) (names []string, ok bool) {
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names, true
+}
+
+// storableResponse reports whether the upstream allows a shared cache to keep the response.
+func storableResponse(header http.Header) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			switch strings.ToLower(name) {
+			case "private", "no-store", "no-cache":
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// get returns the live entry for key when the outgoing request headers match the ones the
+// response varies on; expired entries are dropped.
+func (fc *fetchCache) get(key string, request http.Header) (fetchCacheEntry, bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	entry, ok := fc.entries[key]
+	if !ok {
+		return fetchCacheEntry{}, false
+	}
+	if !time.Now().Before(entry.expires) {
+		delete(fc.entries, key)
+		return fetchCacheEntry{}, false
+	}
+	for name, value := range entry.vary {
+		if request.Get(name) != value {
+			return fetchCacheEntry{}, false
+		}
+	}
+	return entry, true
+}
+
+// put stores a 2xx response the upstream marked storable, along with the request header values
+// named in its Vary, and sweeps expired entries so stale URLs do not accumulate.
+func (fc *fetchCache) put(key string, status int, header http.Header, body []byte, request http.Header) {
+	if status < 200 || status > 299 || !storableResponse(header) {
+		return
+	}
+	names, ok := varyNames(header)
+	if !ok {
+		return
+	}
+	vary := make(map[string]string, len(names))
+	for _, name := range names {
+		vary[name] = request.Get(name)
+	}
+
+	now := time.Now()
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	for k, entry := range fc.entries {
+		if !now.Before(entry.expires) {
+			delete(fc.entries, k)
+		}
+	}
+	fc.entries[key] = fetchCacheEntry{
+		status:  status,
+		header:  header.Clone(),
+		body:    append([]byte(nil), body...),
+		expires: now.Add(fc.ttl),
+		vary:    vary,
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFetchCache verifies entries expire after the TTL and only 2xx responses are stored.
+func TestFetchCache(t *testing.T) {
+	assert.Nil(t, newFetchCache(0))
+
+	fc := newFetchCache(30)
+	header := http.Header{"Content-Type": {"application/json"}}
+	fc.put("GET http://up/a", 200, header, []byte(`{"a":1}`), nil)
+	fc.put("GET http://up/b", 503, header, []byte(`{}`), nil)
+
+	// The stored header is a copy
+	header.Set("Content-Type", "text/plain")
+
+	entry, ok := fc.get("GET http://up/a", nil)
+	require.True(t, ok)
+	assert.Equal(t, 200, entry.status)
+	assert.Equal(t, "application/json", entry.header.Get("Content-Type"))
+	assert.Equal(t, `{"a":1}`, string(entry.body))
+
+	_, ok = fc.get("GET http://up/b", nil)
+	assert.False(t, ok)
+
+	time.Sleep(40 * time.Millisecond)
+	_, ok = fc.get("GET http://up/a", nil)
+	assert.False(t, ok)
+	assert.Empty(t, fc.entries)
+}
+
+// TestFetchCacheControl verifies upstream private/no-store/no-cache responses are never stored.
+func TestFetchCacheControl(t *testing.T) {
+	fc := newFetchCache(60000)
+	for i, value := range []string{"private", "no-store", "max-age=60, No-Cache", `no-cache="Set-Cookie"`} {
+		key := "GET http://up/" + strconv.Itoa(i)
+		fc.put(key, 200, http.Header{"Cache-Control": {value}}, []byte(`{}`), nil)
+		_, ok := fc.get(key, nil)
+		assert.False(t, ok, value)
+	}
+
+	fc.put("GET http://up/public", 200, http.Header{"Cache-Control": {"public, max-age=60"}}, []byte(`{}`), nil)
+	_, ok := fc.get("GET http://up/public", nil)
+	assert.True(t, ok)
+}
+
+// TestFetchCacheKey verifies rendered fetch.headers split entries regardless of map order.
+func TestFetchCacheKey(t *testing.T) {
+	a := fetchCacheKey("GET", "http://up/a", map[string]string{"X-Tenant": "acme", "Accept": "application/json"})
+	b := fetchCacheKey("GET", "http://up/a", map[string]string{"Accept": "application/json", "X-Tenant": "acme"})
+	c := fetchCacheKey("GET", "http://up/a", map[string]string{"Accept": "application/json", "X-Tenant": "globex"})
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+	assert.Equal(t, "GET http://up/a", fetchCacheKey("GET", "http://up/a", nil))
+}
+
+// TestFetchCacheVary verifies entries only answer requests sending the same values for the
+// headers the upstream varies on, and "Vary: *" responses are never stored.
+func TestFetchCacheVary(t *testing.T) {
+	fc := newFetchCache(60000)
+	header := http.Header{"Vary": {"Accept-Encoding, accept-language"}}
+	fc.put("GET http://up/a", 200, header, []byte(`{"hello":"bonjour"}`), http.Header{"Accept-Language": {"fr"}})
+
+	_, ok := fc.get("GET http://up/a", http.Header{"Accept-Language": {"fr"}})
+	assert.True(t, ok)
+	_, ok = fc.get("GET http://up/a", http.Header{"Accept-Language": {"de"}})
+	assert.False(t, ok)
+	_, ok = fc.get("GET http://up/a", http.Header{"Accept-Language": {"fr"}, "Accept-Encoding": {"gzip"}})
+	assert.False(t, ok)
+
+	fc.put("GET http://up/b", 200, http.Header{"Vary": {"*"}}, []byte(`{}`), nil)
+	_, ok = fc.get("GET http://up/b", nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+import (
+	mslogger "mockserver/logger"
+)
+
+// idempotentMethods are the only requests fetch.retries may send more than once.
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+// fetchRetry holds the parsed fetch.retries settings; nil disables retries.
+type fetchRetry struct {
+	attempts   int
+	backoff    time.Duration
+	connection bool
+	all5xx     bool
+	statuses   map[int]bool
+}
+
+// newFetchRetry parses retry_on (validated at config load) into a fetchRetry.
+func newFetchRetry(retries, backoffMs int, retryOn []string) *fetchRetry {
+	if retries <= 0 {
+		return nil
+	}
+	r := &fetchRetry{
+		attempts: retries,
+		backoff:  time.Duration(backoffMs) * time.Millisecond,
+		statuses: map[int]bool{},
+	}
+	for _, cond := range retryOn {
+		switch cond {
+		case "connection":
+			r.connection = true
+		case "5xx":
+			r.all5xx = true
+		default:
+			if status, err := strconv.Atoi(cond); err == nil {
+				r.statuses[status] = true
+			}
+		}
+	}
+	return r
+}
+
+// shouldRetry reports whether the outcome of an attempt is a retryable failure.
+// Errors caused by the route deadline itself (ctx) are final.
+func (r *fetchRetry) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return r.connection && ctx.Err() == nil
+	}
+	if resp.StatusCode < 500 {
+		return false
+	}
+	return r.all5xx || r.statuses[resp.StatusCode]
+}
+
+// doFetch sends req, retrying idempotent requests with exponential backoff (fetch.retries).
+// A retry is skipped when its backoff would outlast the route deadline, so the last upstream
+// answer (or error) is returned instead of a timeout.
+func (p *FetchHandler) doFetch(ctx context.Context, req *http.Request) (*http.Response, error) {
+	resp, err := p.client.Do(req)
+	if p.retry == nil || !idempotentMethods[req.Method] {
+		return resp, err
+	}
+
+	backoff := p.retry.backoff
+	for attempt := 1; attempt <= p.retry.attempts && p.retry.shouldRetry(ctx, resp, err); attempt++ {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= backoff {
+			break
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		mslogger.LogInfo(fmt.Sprintf("Retrying %s %s in %s (attempt %d/%d)", req.Method, req.URL, backoff, attempt, p.retry.attempts), 0, 0, 5)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+
+		next := req.Clone(ctx)
+		if req.GetBody != nil {
+			if next.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		resp, err = p.client.Do(next)
+	}
+	return resp, err
+}
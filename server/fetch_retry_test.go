@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFetchRetry verifies retries stop after the configured attempts and never outlast the route deadline.
+func TestFetchRetry(t *testing.T) {
+	var hits atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer upstream.Close()
+
+	send := func(retry *fetchRetry, timeout time.Duration) (*http.Response, time.Duration) {
+		hits.Store(0)
+		p := &FetchHandler{client: fetchClient, retry: retry}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+		require.NoError(t, err)
+		start := time.Now()
+		resp, err := p.doFetch(ctx, req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp, time.Since(start)
+	}
+
+	// Exhausted retries return the last upstream answer
+	resp, _ := send(newFetchRetry(2, 5, []string{"5xx"}), 2*time.Second)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(3), hits.Load())
+
+	// Only the listed statuses are retried
+	resp, _ = send(newFetchRetry(2, 5, []string{"503"}), 2*time.Second)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(1), hits.Load())
+
+	// A backoff longer than the remaining deadline ends the retries early
+	resp, elapsed := send(newFetchRetry(3, 1000, []string{"5xx"}), 200*time.Millisecond)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(1), hits.Load())
+	assert.Less(t, elapsed, 200*time.Millisecond)
+
+	assert.Nil(t, newFetchRetry(0, 100, nil))
+}
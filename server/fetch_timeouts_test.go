@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+import (
+	msconfig "mockserver/config"
+	server_utils "mockserver/server/utils"
+)
+
+// TestFetchPhaseTimeouts verifies connect and response timeouts are reported separately,
+// and that a slow body after prompt headers is not cut off by the response timeout.
+func TestFetchPhaseTimeouts(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow-headers":
+			time.Sleep(300 * time.Millisecond)
+		case "/slow-body":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			time.Sleep(300 * time.Millisecond)
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer upstream.Close()
+
+	// Each connection attempt waits dialDelay before reaching the upstream
+	dialDelay := 0 * time.Millisecond
+	originalDial := upstreamDial
+	upstreamDial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		select {
+		case <-time.After(dialDelay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return originalDial(ctx, network, addr)
+	}
+	defer func() { upstreamDial = originalDial }()
+
+	call := func(path string, fetch msconfig.FetchConfig) (int, string) {
+		fetch.URL = upstream.URL + path
+		fetch.TimeoutMs = 2000
+		h, err := newFetchHandler(&fetch, msconfig.RouteConfig{Name: "Upstream", Method: "GET", Path: "/upstream"}, msconfig.ServerConfig{}, nil)
+		require.NoError(t, err)
+
+		app := fiber.New()
+		app.Get("/upstream", func(c *fiber.Ctx) error { return h.handler(c, server_utils.EContext{}) })
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/upstream", nil), -1)
+		require.NoError(t, err)
+		raw, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(raw)
+	}
+
+	status, body := call("/slow-headers", msconfig.FetchConfig{ResponseTimeoutMs: 100})
+	assert.Equal(t, 504, status)
+	assert.Contains(t, body, "FETCH_RESPONSE_TIMEOUT")
+
+	status, body = call("/slow-body", msconfig.FetchConfig{ResponseTimeoutMs: 100})
+	assert.Equal(t, 200, status, "the response timeout only covers the wait for headers")
+	assert.JSONEq(t, `{"ok":true}`, body)
+
+	dialDelay = 300 * time.Millisecond
+	status, body = call("/fast", msconfig.FetchConfig{ConnectTimeoutMs: 100, ResponseTimeoutMs: 1000})
+	assert.Equal(t, 504, status)
+	assert.Contains(t, body, "FETCH_CONNECT_TIMEOUT")
+
+	dialDelay = 20 * time.Millisecond
+	status, _ = call("/fast", msconfig.FetchConfig{ConnectTimeoutMs: 100})
+	assert.Equal(t, 200, status)
+}
+
+// TestFetchTransportsClosedOnShutdown verifies dedicated fetch clients drop their idle upstream
+// connections when the runtime that built them shuts down.
+func TestFetchTransportsClosedOnShutdown(t *testing.T) {
+	var closed atomic.Int32
+	upstream := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	upstream.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed.Add(1)
+		}
+	}
+	upstream.Start()
+	defer upstream.Close()
+
+	cfg := &msconfig.Config{Routes: []msconfig.RouteConfig{{
+		Name: "Pooled", Method: "GET", Path: "/pooled",
+		Fetch: &msconfig.FetchConfig{URL: upstream.URL, ConnectTimeoutMs: 1000},
+	}}}
+	app := fiber.New()
+	registerUserRoutes(app, cfg, "")
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/pooled", nil), -1)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(0), closed.Load(), "the upstream connection is kept alive while the runtime serves")
+
+	require.NoError(t, app.Shutdown())
+	assert.Eventually(t, func() bool { return closed.Load() == 1 }, 2*time.Second, 20*time.Millisecond)
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+import (
+	msUtils "mockserver/utils"
+)
+
+// fileRefKey is the directive inlining another JSON fixture into a mock body,
+// e.g. {"user": {"$fileRef": "./fixtures/user.json"}}
+const fileRefKey = "$fileRef"
+
+// resolveFileRefs replaces every {"$fileRef": "path"} object in a mock body with the parsed
+// content of that file. Paths are relative to the config file, or to the referencing fixture for
+// nested refs. Circular references are rejected.
+func resolveFileRefs(body interface{}, configFilePath string) (interface{}, error) {
+	return resolveFileRefsIn(body, configFilePath, nil)
+}
+
+// resolveFileRefsIn walks the value; basePath is the file relative paths resolve against and
+// chain holds the fixtures currently being inlined (for cycle detection).
+func resolveFileRefsIn(value interface{}, basePath string, chain []string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v[fileRefKey]; ok {
+			return loadFileRef(ref, len(v), basePath, chain)
+		}
+
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			resolved, err := resolveFileRefsIn(item, basePath, chain)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = resolved
+		}
+		return out, nil
+
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved, err := resolveFileRefsIn(item, basePath, chain)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	}
+	return value, nil
+}
+
+// loadFileRef reads, parses and recursively resolves a single $fileRef target.
+func loadFileRef(ref interface{}, keys int, basePath string, chain []string) (interface{}, error) {
+	refPath, ok := ref.(string)
+	if !ok || strings.TrimSpace(refPath) == "" {
+		return nil, fmt.Errorf("%s must be a non-empty file path", fileRefKey)
+	}
+	if keys > 1 {
+		return nil, fmt.Errorf("%s '%s' must be the only key of its object", fileRefKey, refPath)
+	}
+
+	path, err := filepath.Abs(msUtils.ResolveMockFilePath(basePath, refPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s '%s': %w", fileRefKey, refPath, err)
+	}
+	for _, seen := range chain {
+		if seen == path {
+			return nil, fmt.Errorf("circular %s: %s -> %s", fileRefKey, strings.Join(chain, " -> "), path)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s '%s': %w", fileRefKey, refPath, err)
+	}
+
+	var parsed interface{}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid JSON in %s '%s': %w", fileRefKey, refPath, err)
+	}
+
+	return resolveFileRefsIn(parsed, path, append(chain, path))
+}
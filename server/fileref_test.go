@@ -0,0 +1,54 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResolveFileRefs verifies $fileRef fixtures are inlined (including nested refs) and cycles are rejected.
+func TestResolveFileRefs(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "mockserver.json")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "fixtures"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fixtures", "user.json"),
+		[]byte(`{"name": "Ada", "address": {"$fileRef": "./address.json"}}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fixtures", "address.json"),
+		[]byte(`{"city": "London"}`), 0644))
+
+	body := map[string]interface{}{
+		"user":  map[string]interface{}{"$fileRef": "./fixtures/user.json"},
+		"items": []interface{}{map[string]interface{}{"$fileRef": "fixtures/address.json"}},
+		"total": 1,
+	}
+
+	resolved, err := resolveFileRefs(body, configPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"user": map[string]interface{}{
+			"name":    "Ada",
+			"address": map[string]interface{}{"city": "London"},
+		},
+		"items": []interface{}{map[string]interface{}{"city": "London"}},
+		"total": 1,
+	}, resolved)
+
+	t.Run("Circular refs are rejected", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"next": {"$fileRef": "b.json"}}`), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"next": {"$fileRef": "a.json"}}`), 0644))
+
+		_, err := resolveFileRefs(map[string]interface{}{"$fileRef": "a.json"}, configPath)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "circular $fileRef")
+	})
+
+	t.Run("Missing file is reported", func(t *testing.T) {
+		_, err := resolveFileRefs(map[string]interface{}{"$fileRef": "missing.json"}, configPath)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read $fileRef")
+	})
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+import (
+	msconfig "mockserver/config"
+	server_utils "mockserver/server/utils"
+)
+
+// globalVariables backs {{global.<name>}} templates. It is reseeded from server.globals on every
+// start and reload; values changed through the debug endpoint last until the next reload.
+var globalVariables = server_utils.NewGlobalStore()
+
+// globalsGetHandler returns all global variables.
+func globalsGetHandler(store *server_utils.GlobalStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"success": true,
+			"globals": store.Snapshot(),
+		})
+	}
+}
+
+// globalsUpdateHandler merges a JSON object of variables into the store, e.g. {"token": "abc"}.
+// Variables missing from the body keep their current value.
+func globalsUpdateHandler(store *server_utils.GlobalStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var values map[string]interface{}
+		if err := json.Unmarshal(c.Body(), &values); err != nil || values == nil {
+			return responseError(c, fiber.StatusBadRequest, "INVALID_GLOBALS",
+				"Body must be a JSON object of global variables", false)
+		}
+		for name := range values {
+			if err := msconfig.ValidateGlobalName(name); err != nil {
+				return responseError(c, fiber.StatusBadRequest, "INVALID_GLOBALS", err.Error(), false)
+			}
+		}
+
+		store.Set(values)
+		return c.JSON(fiber.Map{
+			"success": true,
+			"globals": store.Snapshot(),
+		})
+	}
+}
@@ -13,11 +13,14 @@ import (
 	"errors"
 	"regexp"
 	"strings"
+	"sync"
 
+	"net"
 	"net/http"
 	"net/url"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
 )
 
 import (
@@ -69,21 +72,17 @@ func newMockHandler(cfg *msconfig.MockConfig, routeCfg msconfig.RouteConfig, srv
 		}
 	}
 
-	// Resolve HTTP Status Code
-	status := 200
-	if routeCfg.Status != 0 {
-		status = routeCfg.Status
-	}
-	if cfg.Status != 0 {
-		status = cfg.Status
-	}
+	status := resolveMockStatus(routeCfg, srvCfg.MethodDefaultStatus)
 
-	headers := mergeHeaders(srvCfg.DefaultHeaders, routeCfg.Headers, cfg.Headers)
+	headers := mergeHeaders(srvCfg.DefaultHeaders, routeCfg.Headers, cfg.Headers, routeCfg.RemoveHeaders)
 
 	delay, err := computeDelay(routeCfg.DelayMs, cfg.DelayMs, srvCfg.DefaultDelayMs)
 	if err != nil {
 		return nil, err
 	}
+	if _, err := validateDelay(cfg.SlowDelayMs); err != nil {
+		return nil, fmt.Errorf("mock.slow_delay_ms: %w", err)
+	}
 
 	var (
 		mockBodyData interface{}
@@ -93,7 +92,11 @@ func newMockHandler(cfg *msconfig.MockConfig, routeCfg msconfig.RouteConfig, srv
 
 	// Determine Data Source: Inline 'Body' takes precedence over 'File'
 	if cfg.Body != nil {
-		mockBodyData = cfg.Body
+		// Inline external fixtures referenced with $fileRef once, at load time
+		mockBodyData, err = resolveFileRefs(cfg.Body, configFilePath)
+		if err != nil {
+			return nil, err
+		}
 	} else if cfg.File != "" {
 		mockFilePath = msUtils.ResolveMockFilePath(configFilePath, cfg.File)
 		data, err := os.ReadFile(mockFilePath)
@@ -101,8 +104,38 @@ func newMockHandler(cfg *msconfig.MockConfig, routeCfg msconfig.RouteConfig, srv
 			return nil, fmt.Errorf("failed to read mock file: %w", err)
 		}
 		mockFileData = data
-	} else {
-		return nil, fmt.Errorf("mock must define either 'body' or 'file'")
+	} else if len(cfg.Responses) == 0 && cfg.Sequence == nil {
+		return nil, fmt.Errorf("mock must define either 'body', 'file', 'responses' or 'sequence'")
+	}
+
+	var variants map[string]interface{}
+	if len(cfg.NamedVariants) > 0 {
+		variants = make(map[string]interface{}, len(cfg.NamedVariants))
+		for name, body := range cfg.NamedVariants {
+			if variants[name], err = resolveFileRefs(body, configFilePath); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var weighted []weightedResponse
+	for _, resp := range cfg.Responses {
+		body, err := resolveFileRefs(resp.Body, configFilePath)
+		if err != nil {
+			return nil, err
+		}
+		weighted = append(weighted, weightedResponse{weight: resp.Weight, status: resp.Status, body: body, headers: resp.Headers})
+	}
+
+	var sequence []msconfig.CResponse
+	if cfg.Sequence != nil {
+		sequence = make([]msconfig.CResponse, len(cfg.Sequence.Responses))
+		for i, resp := range cfg.Sequence.Responses {
+			if resp.Body, err = resolveFileRefs(resp.Body, configFilePath); err != nil {
+				return nil, err
+			}
+			sequence[i] = resp
+		}
 	}
 
 	return &MockHandler{
@@ -111,9 +144,25 @@ func newMockHandler(cfg *msconfig.MockConfig, routeCfg msconfig.RouteConfig, srv
 		status:       status,
 		headers:      headers,
 		delayMs:      delay,
+		delayPerKb:   cfg.DelayPerKb,
+		malformed:    cfg.Malformed,
+		streamDelay:  cfg.StreamItemDelayMs,
+		abortAfter:   cfg.AbortAfterBytes,
+		cookies:      cfg.Cookies,
+		slow:         newSlowSampler(cfg.SlowRequestRate, cfg.SlowDelayMs, cfg.SlowSeed),
+		variants:     variants,
+		variantQuery: cfg.VariantQuery || (srvCfg.Debug != nil && srvCfg.Debug.Enabled),
+		debugHeaders: srvCfg.Debug != nil && srvCfg.Debug.Enabled,
+		weighted:     newWeightedPicker(weighted),
+		sequence:     sequence,
+		paginated:    cfg.Paginated,
+		cycle:        cfg.Sequence != nil && cfg.Sequence.Cycle,
+		contentType:  routeCfg.ResponseContentType(),
+		untyped:      removesContentType(routeCfg, headers),
 		mockBodyData: mockBodyData,
 		mockFileData: mockFileData,
 		stateStore:   stateStore,
+		templates:    newTemplateEngine(srvCfg),
 		routecfg:     routeCfg,
 	}, nil
 }
@@ -123,11 +172,16 @@ func newMockHandler(cfg *msconfig.MockConfig, routeCfg msconfig.RouteConfig, srv
 // before returning the final JSON response.
 func (m *MockHandler) handler(c *fiber.Ctx, ctx server_utils.EContext) error {
 
-	applyDelay(m.delayMs)
+	applyDelay(c.UserContext(), m.delayMs)
+	applyDelay(c.UserContext(), m.slow.delay())
+	traceMark(c, "delay")
 
 	for k, v := range m.headers {
 		c.Set(k, v)
 	}
+	if err := setCookies(c, m.cookies, m.templates, ctx); err != nil {
+		return responseError(c, 500, "COOKIE_TEMPLATE_ERROR", err.Error(), false)
+	}
 
 	// Aggregate all parameters (Path + Query) for template substitution
 	params := make(map[string]string)
@@ -137,6 +191,7 @@ func (m *MockHandler) handler(c *fiber.Ctx, ctx server_utils.EContext) error {
 	for k, v := range c.Queries() {
 		params[k] = v
 	}
+	delete(params, variantParam)
 
 	// Parse body for Schema Validation if available
 	var body map[string]interface{}
@@ -145,24 +200,81 @@ func (m *MockHandler) handler(c *fiber.Ctx, ctx server_utils.EContext) error {
 			// return c.Status(400).JSON(fiber.Map{
 			// 	"error": "invalid body",
 			// })
+			if msg, ok := describeJSONError(c.Body(), err); ok {
+				return responseError(c, fiber.StatusBadRequest, "MALFORMED_JSON_BODY", msg, false)
+			}
 			return responseError(c, fiber.StatusBadRequest, "INVALID_BODY", err.Error(), false)
 		}
 
-		if m.routecfg.BodySchema != nil {
-			// Enforce strict JSON Schema validation
-			if err := server_utils.ValidateJSONSchema(m.routecfg.BodySchema, body, "request.body"); err != nil {
+		// Enforce strict JSON Schema validation (stateful writes were already checked before reaching the store)
+		if m.routecfg.BodySchema != nil && !isStatefulWrite(m.routecfg) {
+			if err := validateBodySchema(m.routecfg.BodySchema, body, &ctx); err != nil {
 				return responseError(c, fiber.StatusBadRequest, "SCHEMA_VALIDATION_FAILED", err.Error(), false)
 			}
 		}
+	} else if m.routecfg.BodySchema != nil && isBodyMethod(c.Method()) {
+		return responseError(c, fiber.StatusBadRequest, "EMPTY_BODY",
+			"Request body is required but was empty", false)
 	} else {
 		body = make(map[string]interface{})
 	}
 
 	var responseBody interface{}
 
-	if m.mockBodyData != nil {
+	// Named variants replace the main body when selected with ?__variant (debug mode or mock.variant_query),
+	// otherwise the next mock.sequence step or a weighted mock.responses entry provides it
+	bodyData := m.mockBodyData
+	status := m.status
+	contentType := m.contentType
+	untyped := m.untyped
+	source := "inline"
+	if name := c.Query(variantParam); name != "" && m.variantQuery && len(m.variants) > 0 {
+		variant, ok := m.variants[name]
+		if !ok {
+			return responseError(c, fiber.StatusBadRequest, "UNKNOWN_VARIANT",
+				fmt.Sprintf("Unknown variant '%s', available: %s", name, strings.Join(sortedKeys(m.variants), ", ")), false)
+		}
+		bodyData = variant
+		source = "variant"
+	} else if len(m.sequence) > 0 {
+		step := m.nextSequenceStep()
+		source = "sequence"
+		applyDelay(c.UserContext(), step.DelayMs)
+		bodyData = step.Body
+		if step.Status != 0 {
+			status = step.Status
+		}
+		contentType = headerContentType(step.Headers, contentType)
+		untyped = untyped && headerContentType(step.Headers, "") == ""
+		for k, v := range step.Headers {
+			c.Set(k, v)
+		}
+		if err := setCookies(c, step.Cookies, m.templates, ctx); err != nil {
+			return responseError(c, 500, "COOKIE_TEMPLATE_ERROR", err.Error(), false)
+		}
+	} else if m.weighted != nil {
+		picked := m.weighted.pick()
+		bodyData = picked.body
+		source = "responses"
+		if picked.status != 0 {
+			status = picked.status
+		}
+		contentType = headerContentType(picked.headers, contentType)
+		untyped = untyped && headerContentType(picked.headers, "") == ""
+		for k, v := range picked.headers {
+			c.Set(k, v)
+		}
+	}
+
+	file := ""
+	if bodyData == nil && m.filePath != "" {
+		source, file = "file", m.filePath
+	}
+	setMockSource(c, m.debugHeaders, source, file)
+
+	if bodyData != nil {
 		// Scenario A: Process Inline Mock (Dynamic Templates supported)
-		processed, err := server_utils.ProcessTemplateJSON(m.mockBodyData, ctx)
+		processed, err := m.templates.Process(bodyData, ctx)
 		if err != nil {
 			return responseError(c, 500, "TEMPLATE_ERROR", err.Error(), false)
 		}
@@ -170,21 +282,125 @@ func (m *MockHandler) handler(c *fiber.Ctx, ctx server_utils.EContext) error {
 
 	} else {
 		// Scenario B: Process Legacy File-based Mock (Filtering supported)
-		filtered, err := parseAndFilterMockData(m.mockFileData, ctx, params)
+		filterParams := params
+		if m.paginated {
+			// Paginated mocks slice the page below, once the pre-pagination total is known
+			filterParams = make(map[string]string, len(params))
+			for k, v := range params {
+				if k != "_page" && k != "_limit" {
+					filterParams[k] = v
+				}
+			}
+		}
+		filtered, err := parseAndFilterMockData(m.templates, m.mockFileData, ctx, filterParams)
 		if err != nil {
 			return responseError(c, 500, "MOCK_PARSE_ERROR", err.Error(), false)
 		}
 		responseBody = filtered
 	}
 
-	c.Status(m.status)
-	return c.JSON(responseBody)
+	// Paginated envelope: array bodies are filtered, sorted and paged from the query string
+	var page *server_utils.PageMeta
+	if m.paginated {
+		items, ok, err := pageItems(responseBody)
+		if err != nil {
+			return responseError(c, 500, "MOCK_PARSE_ERROR", err.Error(), false)
+		}
+		if ok {
+			pageData, meta, err := server_utils.FilteredMockPage(items, params)
+			if err != nil {
+				return responseError(c, fiber.StatusBadRequest, "INVALID_QUERY", err.Error(), false)
+			}
+			responseBody, page = pageData, &meta
+		}
+	}
+
+	if len(m.routecfg.Mask) > 0 {
+		responseBody = server_utils.MaskFields(responseBody, m.routecfg.Mask)
+	}
+	if page != nil {
+		responseBody = server_utils.PageEnvelope(responseBody, *page)
+	}
+	traceMark(c, "template")
+
+	// Body writers always set a type; remove_headers: ["Content-Type"] takes it off afterwards
+	if untyped {
+		defer stripContentType(c)
+	}
+
+	// Non-JSON content types (html, xml, text...) send string bodies as-is
+	if text, ok := responseBody.(string); ok && !isJSONContentType(contentType) {
+		applyDelay(c.UserContext(), sizeDelay(m.delayMs, len(text), m.delayPerKb))
+
+		c.Status(status)
+		c.Set(fiber.HeaderContentType, contentType)
+		if m.abortAfter > 0 {
+			return abortAfterBytes(c, []byte(text), m.abortAfter)
+		}
+		return c.SendString(text)
+	}
+
+	// Progressive list responses: array elements are flushed one by one
+	if m.streamDelay > 0 {
+		if items, ok := jsonArrayItems(responseBody); ok {
+			c.Status(status)
+			return streamJSONArray(c, items, m.streamDelay)
+		}
+	}
+
+	// Everything else is JSON-encoded under the route's type when that is a JSON type (e.g. application/problem+json)
+	jsonType := contentType
+	if !isJSONContentType(jsonType) {
+		jsonType = fiber.MIMEApplicationJSON
+	}
+
+	// Bandwidth simulation and negative testing (malformed, aborted) operate on the serialized body
+	if m.delayPerKb > 0 || m.malformed != "" || m.abortAfter > 0 {
+		encoded, err := json.Marshal(responseBody)
+		if err != nil {
+			return responseError(c, 500, "MOCK_ENCODE_ERROR", err.Error(), false)
+		}
+
+		// Corrupt the payload on purpose while still claiming JSON
+		if m.malformed != "" {
+			if encoded, err = server_utils.MalformJSON(encoded, m.malformed); err != nil {
+				return responseError(c, 500, "MOCK_MALFORM_ERROR", err.Error(), false)
+			}
+		}
+
+		// Delay proportional to the processed body size
+		applyDelay(c.UserContext(), sizeDelay(m.delayMs, len(encoded), m.delayPerKb))
+
+		c.Status(status)
+		c.Set(fiber.HeaderContentType, jsonType)
+		if m.abortAfter > 0 {
+			return abortAfterBytes(c, encoded, m.abortAfter)
+		}
+		return c.Send(encoded)
+	}
+
+	c.Status(status)
+	return c.JSON(responseBody, jsonType)
+}
+
+// nextSequenceStep advances the per-route call counter and returns the matching mock.sequence response.
+// Past the end it starts over when cycle is set and keeps returning the last response otherwise.
+func (m *MockHandler) nextSequenceStep() *msconfig.CResponse {
+	n := int(m.calls.Add(1) - 1)
+	if n >= len(m.sequence) {
+		if m.cycle {
+			n %= len(m.sequence)
+		} else {
+			n = len(m.sequence) - 1
+		}
+	}
+	return &m.sequence[n]
 }
 
 // [IMP_FUNC]
 // newFetchHandler prepares a proxy handler.
 // It parses the target URL and compiles path matching regexes to ensure safe proxying.
-func newFetchHandler(cfg *msconfig.FetchConfig, routeCfg msconfig.RouteConfig, srvCfg msconfig.ServerConfig) (*FetchHandler, error) {
+func newFetchHandler(cfg *msconfig.FetchConfig, routeCfg msconfig.RouteConfig, srvCfg msconfig.ServerConfig, transports *fetchTransports) (*FetchHandler, error) {
 	if routeCfg.Method != "" {
 		if err := msUtils.ValidateRouteMethod(routeCfg.Method); err != nil {
 			mslogger.LogError(err.Error(), 0, 0, 5)
@@ -213,20 +429,101 @@ func newFetchHandler(cfg *msconfig.FetchConfig, routeCfg msconfig.RouteConfig, s
 	}
 
 	return &FetchHandler{
-		routeName:        routeCfg.Name,
-		targetURL:        parsedURL,
-		method:           cfg.Method,
-		headers:          cfg.Headers,
-		fetchQueryParams: cfg.QueryParams,
-		queryParams:      queryParams,
-		passStatus:       cfg.PassStatus,
-		delayMs:          delay,
-		timeoutMs:        cfg.TimeoutMs,
-		urlRegex:         urlRegex,
-		basePath:         routeCfg.Path,
+		routeName:         routeCfg.Name,
+		targetURL:         parsedURL,
+		method:            cfg.Method,
+		headers:           cfg.Headers,
+		fetchQueryParams:  cfg.QueryParams,
+		pathParamsAsQuery: cfg.PathParamsAsQuery,
+		queryParams:       queryParams,
+		passStatus:        cfg.PassStatus,
+		delayMs:           delay,
+		timeoutMs:         cfg.TimeoutMs,
+		connectTimeoutMs:  cfg.ConnectTimeoutMs,
+		responseTimeoutMs: cfg.ResponseTimeoutMs,
+		client:            fetchClientFor(cfg.ConnectTimeoutMs, cfg.ResponseTimeoutMs, transports),
+		urlRegex:          urlRegex,
+		basePath:          routeCfg.Path,
+		templates:         newTemplateEngine(srvCfg),
+		requestTransform:  cfg.RequestTransform,
+		bodyTemplate:      cfg.BodyTemplate,
+		responseTransform: cfg.ResponseTransform,
+		retry:             newFetchRetry(cfg.Retries, cfg.RetryBackoffMs, cfg.RetryOn),
+		errorResponses:    cfg.ErrorResponses,
+		cache:             newFetchCache(cfg.CacheTTLMs),
+		mask:              routeCfg.Mask,
+		debugHeaders:      srvCfg.Debug != nil && srvCfg.Debug.Enabled,
 	}, nil
 }
 
+// fetchClient never decompresses upstream bodies transparently: the client's own Accept-Encoding
+// is forwarded, so the body and its Content-Encoding header are passed through untouched.
+var fetchClient = &http.Client{Transport: newPassthroughTransport()}
+
+func newPassthroughTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableCompression = true
+	return transport
+}
+
+// upstreamDial opens upstream connections for routes with a connect timeout (replaceable in tests).
+var upstreamDial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+
+// errConnectTimeout reports that fetch.connect_timeout_ms elapsed before the upstream accepted the connection.
+var errConnectTimeout = errors.New("upstream connect timeout")
+
+// fetchTransports collects the dedicated transports built for one server runtime, so their idle
+// upstream connections are released when that runtime shuts down (e.g. on config reload).
+type fetchTransports struct {
+	mu    sync.Mutex
+	items []*http.Transport
+}
+
+func (t *fetchTransports) add(transport *http.Transport) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.items = append(t.items, transport)
+}
+
+// closeIdle closes the idle connections of every collected transport.
+func (t *fetchTransports) closeIdle() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, transport := range t.items {
+		transport.CloseIdleConnections()
+	}
+}
+
+// fetchClientFor returns the shared client, or a dedicated pooled client when the route splits
+// connect (dial) and response (header wait) timeouts. Dedicated transports are added to transports
+// (when non-nil) so the runtime can release them.
+func fetchClientFor(connectTimeoutMs, responseTimeoutMs int, transports *fetchTransports) *http.Client {
+	if connectTimeoutMs <= 0 && responseTimeoutMs <= 0 {
+		return fetchClient
+	}
+
+	transport := newPassthroughTransport()
+	transports.add(transport)
+	if connectTimeoutMs > 0 {
+		connectTimeout := time.Duration(connectTimeoutMs) * time.Millisecond
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialCtx, cancel := context.WithTimeout(ctx, connectTimeout)
+			defer cancel()
+
+			conn, err := upstreamDial(dialCtx, network, addr)
+			if err != nil && ctx.Err() == nil && dialCtx.Err() == context.DeadlineExceeded {
+				return nil, fmt.Errorf("%w: %s not reachable within %s", errConnectTimeout, addr, connectTimeout)
+			}
+			return conn, err
+		}
+	}
+	transport.ResponseHeaderTimeout = time.Duration(responseTimeoutMs) * time.Millisecond
+	return &http.Client{Transport: transport}
+}
+
 // Handler acts as a Reverse Proxy.
 // It constructs a new downstream request, forwarding allowed headers and body,
 // while enforcing timeouts and handling artificial delays.
@@ -239,9 +536,12 @@ func (p *FetchHandler) handler(c *fiber.Ctx, ctx server_utils.EContext) error {
 		timeout = time.Duration(p.timeoutMs) * time.Millisecond
 	}
 
-	// Create a context with timeout to prevent hanging connections
-	timeCtx, cancel := context.WithTimeout(c.Context(), timeout)
+	// Create a context with timeout to prevent hanging connections; it also ends with the
+	// route's timeout_ms deadline (user context) and with server shutdown
+	timeCtx, cancel := context.WithTimeout(c.UserContext(), timeout)
 	defer cancel()
+	stopOnShutdown := context.AfterFunc(c.Context(), cancel)
+	defer stopOnShutdown()
 
 	if p.delayMs > 0 {
 		select {
@@ -264,13 +564,77 @@ func (p *FetchHandler) handler(c *fiber.Ctx, ctx server_utils.EContext) error {
 		clientQueryParams[k] = v
 	}
 
-	targetURL := buildTargetURL(p.targetURL, pathParams, clientQueryParams, p.queryParams, p.fetchQueryParams)
+	targetURL := buildTargetURL(p.targetURL, pathParams, clientQueryParams, p.queryParams, p.fetchQueryParams, p.pathParamsAsQuery)
+
+	// fetch.headers are rendered up front: they take part in the cache key
+	headers := make(map[string]string, len(p.headers))
+	for k, v := range p.headers {
+		processed, err := p.templates.Process(v, ctx)
+		if err != nil {
+			return responseError(c, fiber.StatusInternalServerError, "FETCH_HEADER_TEMPLATE_ERROR", err.Error(), false)
+		}
+		headers[k] = fmt.Sprintf("%v", processed)
+	}
+
+	// Header Forwarding Strategy:
+	// 1. Apply headers defined in FetchConfig (template tokens resolved against the request)
+	// 2. Forward client headers (unless overridden by config)
+	outHeader := http.Header{}
+	for k, v := range headers {
+		outHeader.Set(k, v)
+	}
+	c.Request().Header.VisitAll(func(key, val []byte) {
+		k := string(key)
+		if _, ok := p.headers[k]; !ok {
+			outHeader.Set(k, string(val))
+		}
+	})
+	// Masking needs a readable body, so the upstream must not compress it
+	if len(p.mask) > 0 {
+		outHeader.Del(fiber.HeaderAcceptEncoding)
+	}
+
+	// fetch.cache_ttl_ms: repeated GET/HEAD requests for the same target skip the upstream.
+	// Requests carrying credentials always reach it so one caller's response is never served to another,
+	// and entries only answer requests that send the same values for the headers the upstream varies on.
+	cacheKey := ""
+	if p.cache != nil && cacheableMethods[method] {
+		if hasCredentials(outHeader.Get) {
+			c.Set(fetchCacheHeader, "BYPASS")
+		} else {
+			cacheKey = fetchCacheKey(method, targetURL, headers)
+			if entry, ok := p.cache.get(cacheKey, outHeader); ok {
+				c.Set(fetchCacheHeader, "HIT")
+				setMockSource(c, p.debugHeaders, "cache", "")
+				return p.respond(c, ctx, entry.status, entry.header, entry.body)
+			}
+			c.Set(fetchCacheHeader, "MISS")
+		}
+	}
+	setMockSource(c, p.debugHeaders, "upstream", "")
+
 	mslogger.LogInfo(fmt.Sprintf("Proxying request: %s %s", method, targetURL), 0, 0, 5)
 
-	// Prepare Request Body
+	// Prepare Request Body: body_template replaces the client body whenever it is configured
 	var body io.Reader
-	if method == fiber.MethodPost || method == fiber.MethodPut || method == fiber.MethodPatch {
-		body = bytes.NewReader(c.Body())
+	templatedJSON := false
+	if p.bodyTemplate != nil {
+		payload, isJSON, err := renderRequestBody(p.bodyTemplate, p.templates, ctx)
+		if err != nil {
+			return responseError(c, fiber.StatusInternalServerError, "FETCH_BODY_TEMPLATE_ERROR", err.Error(), false)
+		}
+		templatedJSON = isJSON
+		body = bytes.NewReader(payload)
+	} else if method == fiber.MethodPost || method == fiber.MethodPut || method == fiber.MethodPatch {
+		payload := c.Body()
+		if len(p.requestTransform) > 0 {
+			transformed, err := transformRequestBody(payload, p.requestTransform, p.templates, ctx)
+			if err != nil {
+				return responseError(c, fiber.StatusInternalServerError, "FETCH_TRANSFORM_ERROR", err.Error(), false)
+			}
+			payload = transformed
+		}
+		body = bytes.NewReader(payload)
 	}
 
 	req, err := http.NewRequestWithContext(timeCtx, method, targetURL, body)
@@ -279,24 +643,34 @@ func (p *FetchHandler) handler(c *fiber.Ctx, ctx server_utils.EContext) error {
 		return responseError(c, fiber.StatusInternalServerError, "FETCH_BUILD_REQUEST_ERROR", err.Error(), false)
 	}
 
-	// Header Forwarding Strategy:
-	// 1. Apply headers defined in FetchConfig
-	// 2. Forward client headers (unless overridden by config)
-	for k, v := range p.headers {
-		req.Header.Set(k, v)
-	}
-	c.Request().Header.VisitAll(func(key, val []byte) {
-		k := string(key)
-		if _, ok := p.headers[k]; !ok {
-			req.Header.Set(k, string(val))
+	req.Header = outHeader.Clone()
+	// A JSON body_template describes its own content type unless fetch.headers sets one
+	if templatedJSON {
+		configured := false
+		for k := range p.headers {
+			configured = configured || strings.EqualFold(k, fiber.HeaderContentType)
 		}
-	})
+		if !configured {
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		}
+	}
 
-	// Execute Request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	// Execute Request (with fetch.retries for idempotent methods)
+	resp, err := p.doFetch(timeCtx, req)
 	if err != nil {
 
+		if errors.Is(err, errConnectTimeout) {
+			return responseError(c, fiber.StatusGatewayTimeout, "FETCH_CONNECT_TIMEOUT",
+				fmt.Sprintf("Upstream connection not established within %d ms", p.connectTimeoutMs), false)
+		}
+
+		// The transport's header timeout is a net.Error timeout raised while the overall deadline is still open
+		var netErr net.Error
+		if p.responseTimeoutMs > 0 && timeCtx.Err() == nil && errors.As(err, &netErr) && netErr.Timeout() {
+			return responseError(c, fiber.StatusGatewayTimeout, "FETCH_RESPONSE_TIMEOUT",
+				fmt.Sprintf("Upstream sent no response within %d ms", p.responseTimeoutMs), false)
+		}
+
 		if errors.Is(err, context.DeadlineExceeded) {
 			return responseError(c, fiber.StatusGatewayTimeout, "FETCH_TIMEOUT_ERROR",
 				fmt.Sprintf("Request exceeded timeout of %d ms", p.timeoutMs), false)
@@ -327,23 +701,174 @@ func (p *FetchHandler) handler(c *fiber.Ctx, ctx server_utils.EContext) error {
 		return responseError(c, fiber.StatusInternalServerError, "FETCH_BODY_READ_ERROR", err.Error(), false)
 	}
 
-	// Pass upstream errors to client
-	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-		return responseError(c, resp.StatusCode, "FETCH_UPSTREAM_CLIENT_ERROR", "An unknown error occurred while sending the request to the specified URL.", false)
+	if cacheKey != "" {
+		p.cache.put(cacheKey, resp.StatusCode, resp.Header, bodyBytes, outHeader)
+	}
+	return p.respond(c, ctx, resp.StatusCode, resp.Header, bodyBytes)
+}
+
+// respond turns an upstream response (fresh or from fetch.cache_ttl_ms) into the client response,
+// applying error_responses, the 4xx error mapping and response_transform.
+func (p *FetchHandler) respond(c *fiber.Ctx, ctx server_utils.EContext, status int, header http.Header, bodyBytes []byte) error {
+	// Standardized replacement for this upstream error status
+	if replacement, ok := p.errorResponses[status]; ok {
+		processed, err := p.templates.Process(replacement, ctx)
+		if err != nil {
+			return responseError(c, fiber.StatusInternalServerError, "TEMPLATE_ERROR", err.Error(), false)
+		}
+		if len(p.mask) > 0 {
+			processed = server_utils.MaskFields(processed, p.mask)
+		}
+		return c.Status(status).JSON(processed)
+	}
+
+	// Pass upstream errors to client (pass_status forwards the upstream status and body as-is)
+	if !p.passStatus && status >= 400 && status < 500 {
+		return responseError(c, status, "FETCH_UPSTREAM_CLIENT_ERROR", "An unknown error occurred while sending the request to the specified URL.", false)
+	}
+
+	// Reshape successful JSON responses; bodies that do not decode as JSON pass through untouched
+	if p.responseTransform != nil && status < 400 {
+		var decoded interface{}
+		if server_utils.DecodeJSON(bodyBytes, &decoded) == nil {
+			transformed, err := server_utils.TransformResponse(decoded, p.responseTransform)
+			if err != nil {
+				return responseError(c, fiber.StatusBadGateway, "FETCH_RESPONSE_TRANSFORM_ERROR", err.Error(), false)
+			}
+			if bodyBytes, err = json.Marshal(transformed); err != nil {
+				return responseError(c, fiber.StatusInternalServerError, "FETCH_RESPONSE_TRANSFORM_ERROR", err.Error(), false)
+			}
+		}
+	}
+
+	// mask hides fields of JSON bodies; other bodies pass through untouched
+	if len(p.mask) > 0 {
+		var decoded interface{}
+		if server_utils.DecodeJSON(bodyBytes, &decoded) == nil {
+			masked, err := json.Marshal(server_utils.MaskFields(decoded, p.mask))
+			if err != nil {
+				return responseError(c, fiber.StatusInternalServerError, "FETCH_MASK_ERROR", err.Error(), false)
+			}
+			bodyBytes = masked
+		}
 	}
 
-	for k, vals := range resp.Header {
+	for k, vals := range header {
 		for _, v := range vals {
 			c.Set(k, v)
 		}
 	}
 
+	if p.passStatus {
+		c.Status(status)
+	}
 	return c.Send(bodyBytes)
 }
 
+// newEchoHandler prepares a handler that reflects the incoming request back to the client.
+func newEchoHandler(routeCfg msconfig.RouteConfig, srvCfg msconfig.ServerConfig) (*EchoHandler, error) {
+	status := 200
+	if routeCfg.Status != 0 {
+		status = routeCfg.Status
+	}
+
+	delay, err := computeDelay(routeCfg.DelayMs, 0, srvCfg.DefaultDelayMs)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := mergeHeaders(srvCfg.DefaultHeaders, routeCfg.Headers, nil, routeCfg.RemoveHeaders)
+	return &EchoHandler{
+		routeName: routeCfg.Name,
+		status:    status,
+		headers:   headers,
+		untyped:   removesContentType(routeCfg, headers),
+		delayMs:   delay,
+	}, nil
+}
+
+// Handler returns the request as seen by the server (similar to httpbin's /anything).
+// Values come from the EContext, so header and query keys are lowercased.
+func (e *EchoHandler) handler(c *fiber.Ctx, ctx server_utils.EContext) error {
+	applyDelay(c.UserContext(), e.delayMs)
+
+	for k, v := range e.headers {
+		c.Set(k, v)
+	}
+	if e.untyped {
+		defer stripContentType(c)
+	}
+
+	c.Status(e.status)
+	return c.JSON(fiber.Map{
+		"method":  c.Method(),
+		"path":    c.Path(),
+		"params":  ctx.Path,
+		"query":   ctx.Query,
+		"headers": ctx.Headers,
+		"body":    ctx.Body,
+	})
+}
+
+// newStaticHandler mounts the configured directory using Fiber's filesystem middleware.
+// Missing files fall through to the 404 fallback handler.
+func newStaticHandler(routeCfg msconfig.RouteConfig, configFilePath string) fiber.Handler {
+	dir := msUtils.ResolveMockFilePath(configFilePath, routeCfg.Static.Dir)
+
+	return withRouteMeta(msServerHandlers.RouteTypeStatic, routeCfg.Name, filesystem.New(filesystem.Config{
+		Root:   http.Dir(dir),
+		Browse: routeCfg.Static.Browse,
+	}))
+}
+
+// customStateResponse returns the configured response for a state error and its status
+// (the response status, or the error's default status when unset).
+func customStateResponse(responses *msconfig.StatefulResponses, err error) (*msconfig.CResponse, int) {
+	if responses == nil {
+		return nil, 0
+	}
+
+	var resp *msconfig.CResponse
+	status := 0
+	switch err {
+	case server_utils.StateErrNotFound:
+		resp, status = responses.NotFound, fiber.StatusNotFound
+	case server_utils.StateErrConflict:
+		resp, status = responses.Conflict, fiber.StatusConflict
+	case server_utils.StateErrBadInput:
+		resp, status = responses.BadInput, fiber.StatusBadRequest
+	}
+	if resp == nil {
+		return nil, 0
+	}
+	if resp.Status != 0 {
+		status = resp.Status
+	}
+	return resp, status
+}
+
 // handleStateError maps internal storage errors to standardized HTTP API responses.
 // It provides helpful hints for 404 (Not Found) and 409 (Conflict) scenarios.
-func handleStateError(c *fiber.Ctx, err error, route msconfig.RouteConfig, ctx server_utils.EContext) error {
+func handleStateError(c *fiber.Ctx, err error, route msconfig.RouteConfig, ctx server_utils.EContext, templates *server_utils.TemplateEngine) error {
+	// Route-defined responses (stateful.responses) replace the built-in bodies
+	if resp, status := customStateResponse(route.Stateful.Responses, err); resp != nil {
+		applyDelay(c.UserContext(), resp.DelayMs)
+		for k, v := range resp.Headers {
+			c.Set(k, v)
+		}
+		if err := setCookies(c, resp.Cookies, templates, ctx); err != nil {
+			return responseError(c, 500, "COOKIE_TEMPLATE_ERROR", err.Error(), false)
+		}
+		processed, err := templates.Process(resp.Body, ctx)
+		if err != nil {
+			return responseError(c, 500, "TEMPLATE_PROCESS_ERROR", err.Error(), false)
+		}
+		if len(route.Mask) > 0 {
+			processed = server_utils.MaskFields(processed, route.Mask)
+		}
+		return c.Status(status).JSON(processed)
+	}
+
 	if err == server_utils.StateErrNotFound {
 		return c.Status(404).JSON(fiber.Map{
 			"error": fiber.Map{
@@ -374,6 +899,24 @@ func handleStateError(c *fiber.Ctx, err error, route msconfig.RouteConfig, ctx s
 		})
 	}
 
+	if err == server_utils.StateErrLimit {
+		return c.Status(fiber.StatusInsufficientStorage).JSON(fiber.Map{
+			"error": fiber.Map{
+				"code":       "STATE_LIMIT_REACHED",
+				"message":    fmt.Sprintf("Collection is full (max_items: %d)", route.Stateful.MaxItems),
+				"collection": route.Stateful.Collection,
+				"hint": fmt.Sprintf(
+					"Delete items via DELETE %s/{id} or raise max_items",
+					strings.Split(route.Path, "/{")[0],
+				),
+			},
+		})
+	}
+
+	if errors.Is(err, server_utils.StateErrInvalidQuery) {
+		return responseError(c, fiber.StatusBadRequest, "INVALID_QUERY", err.Error(), false)
+	}
+
 	return responseError(c, 500, "STATE_ERROR", err.Error(), false)
 }
 
@@ -388,24 +931,28 @@ func handleStateError(c *fiber.Ctx, err error, route msconfig.RouteConfig, ctx s
 //     before any response logic is triggered.
 //  3. Conditional Cases: Evaluates 'When/Then' priority scenarios. The first matching case terminates the
 //     pipeline and returns the associated response.
-//  4. Base Handler (Fallback): If no cases match, executes the pre-initialized Mock or Fetch handler.
+//  4. Base Handler (Fallback): If no cases match, executes the pre-initialized Mock, Fetch or Echo handler.
 //  5. Default Fallback: If no handler matched and a 'Default' response is defined, it serves as the final
 //     result (Fetch routes are excluded from this fallback).
+//  6. Status Headers: Server-level 'status_headers' matching the final status code are appended.
 //
 // Parameters:
 //   - route: The RouteConfig object containing the specific route definition.
 //   - srvCfg: Global server configuration for default delays, auth, and headers.
 //   - configFilePath: Base directory path for resolving file-based mocks.
 //   - stateStore: The thread-safe in-memory store for managing stateful collections.
+//   - transports: Collects dedicated fetch transports so the runtime can close them on shutdown.
 //
 // Returns:
 //   - fiber.Handler: A compiled Go-Fiber handler ready for router registration.
 //   - error: Returns an error if regex compilation or handler initialization fails during startup.
-func createRouteHandler(route msconfig.RouteConfig, srvCfg msconfig.ServerConfig, configFilePath string, stateStore *server_utils.StateStore) (fiber.Handler, error) {
+func createRouteHandler(route msconfig.RouteConfig, srvCfg msconfig.ServerConfig, configFilePath string, stateStore *server_utils.StateStore, transports *fetchTransports) (fiber.Handler, error) {
 
 	var baseHandler BaseHandlerFunc
 	var err error
 
+	templates := newTemplateEngine(srvCfg)
+
 	// Initialize the appropriate Base Handler (Mock or Fetch)
 	if route.Mock != nil {
 		var mh *MockHandler
@@ -420,7 +967,7 @@ func createRouteHandler(route msconfig.RouteConfig, srvCfg msconfig.ServerConfig
 		)
 	} else if route.Fetch != nil {
 		var fh *FetchHandler
-		fh, err = newFetchHandler(route.Fetch, route, srvCfg)
+		fh, err = newFetchHandler(route.Fetch, route, srvCfg, transports)
 		if err != nil {
 			return nil, err
 		}
@@ -429,73 +976,209 @@ func createRouteHandler(route msconfig.RouteConfig, srvCfg msconfig.ServerConfig
 			fh.routeName,
 			fh.handler,
 		)
+	} else if route.Echo {
+		var eh *EchoHandler
+		eh, err = newEchoHandler(route, srvCfg)
+		if err != nil {
+			return nil, err
+		}
+		baseHandler = withRouteMetaContext(
+			msServerHandlers.RouteTypeEcho,
+			eh.routeName,
+			eh.handler,
+		)
 	}
 
-	return func(c *fiber.Ctx) error {
+	// String bodies of cases, variants and the default are sent as-is under a non-JSON content type
+	routeContentType := route.ResponseContentType()
+	debugHeaders := srvCfg.Debug != nil && srvCfg.Debug.Enabled
+
+	// Stateful writes are checked before the store changes; patch bodies are partial
+	var writeSchema *msconfig.JSONSchema
+	if route.BodySchema != nil && isStatefulWrite(route) {
+		writeSchema = route.BodySchema
+		if route.Stateful.Action == "patch" {
+			writeSchema = partialSchema(route.BodySchema)
+		}
+	}
+
+	// Query params that select the route or carry credentials are not list filters
+	var listIgnored map[string]bool
+	if route.Stateful != nil && route.Stateful.Action == "list" {
+		listIgnored = authQueryParams(route, srvCfg.Auth)
+		for key := range route.MatchQuery {
+			listIgnored[key] = true
+		}
+	}
+
+	handle := func(c *fiber.Ctx) error {
+		// Pathologically nested JSON is refused before anything parses it
+		if srvCfg.MaxBodyDepth > 0 && isJSONDocument(c.Body()) {
+			if depth := server_utils.JSONDepth(c.Body(), srvCfg.MaxBodyDepth); depth > srvCfg.MaxBodyDepth {
+				return responseError(c, fiber.StatusBadRequest, "BODY_TOO_DEEP",
+					fmt.Sprintf("Request body nesting exceeds the maximum depth of %d", srvCfg.MaxBodyDepth), false)
+			}
+		}
+
 		// Build EContext
 		ctx := server_utils.EContext{
+			Method:  c.Method(),
+			Proto:   requestProto(c),
+			Now:     requestClock(c),
+			Context: c.UserContext(),
 			Headers: buildHeaders(c),
 			Query:   buildQuery(c),
 			Path:    c.AllParams(),
 			Body:    map[string]interface{}{},
 		}
 		if len(c.Body()) > 0 {
-			json.Unmarshal(c.Body(), &ctx.Body)
+			ctx.RawBody = strings.ToValidUTF8(string(c.Body()), "\uFFFD")
+			server_utils.DecodeJSON(c.Body(), &ctx.Body)
 		}
+		traceMark(c, "context")
 
 		// Execute Stateful Logic (if configured)
 		// This handles CRUD operations on the state store before any response logic.
 		if route.Stateful != nil {
-			if err := server_utils.ApplyStateful(stateStore, route.Stateful, &ctx); err != nil {
-				return handleStateError(c, err, route, ctx)
+			// Writes are checked against the (route or shared collection) schema before reaching the store
+			if writeSchema != nil {
+				if err := validateBodySchema(writeSchema, ctx.Body, &ctx); err != nil {
+					return responseError(c, fiber.StatusBadRequest, "SCHEMA_VALIDATION_FAILED", err.Error(), false)
+				}
+			}
+
+			// A request that already ran out of time (route timeout_ms) must not change the store
+			if err := c.UserContext().Err(); err != nil {
+				return err
 			}
+			stateCtx := &ctx
+			if len(listIgnored) > 0 {
+				listCtx := ctx
+				listCtx.Query = withoutKeys(ctx.Query, listIgnored)
+				stateCtx = &listCtx
+			}
+			err := server_utils.ApplyStateful(stateStore, route.Stateful, stateCtx)
+			ctx.State = stateCtx.State
+			if err != nil {
+				return handleStateError(c, err, route, ctx, templates)
+			}
+			traceMark(c, "stateful")
 		}
 
 		// Evaluate Conditional Cases (Priority Logic)
 		// If a "Case" matches, it returns immediately, bypassing the Base Handler.
 		if len(route.Cases) > 0 {
-			for _, cs := range route.Cases {
+			for i, cs := range route.Cases {
 				match, err := server_utils.EvaluateCondition(cs.When, ctx)
 				if err != nil {
-					return responseError(c, 500, "CASE_EVAL_ERROR", err.Error(), false)
+					detail := caseEvalErrorDetail(route, i, err)
+					mslogger.LogError(detail)
+
+					// Route name and expression are configuration details: only exposed in debug mode
+					message := "Failed to evaluate a route condition"
+					if srvCfg.Debug != nil && srvCfg.Debug.Enabled {
+						message = detail
+					}
+					return responseError(c, 500, "CASE_EVAL_ERROR", message, false)
 				}
 				if match {
-					applyDelay(cs.Then.DelayMs)
+					traceMark(c, "cases")
+					setMockSource(c, debugHeaders, "case", "")
+					applyDelay(c.UserContext(), cs.Then.DelayMs)
 					for k, v := range cs.Then.Headers {
 						c.Set(k, v)
 					}
-					processed, err := server_utils.ProcessTemplateJSON(cs.Then.Body, ctx)
+					if err := setCookies(c, cs.Then.Cookies, templates, ctx); err != nil {
+						return responseError(c, 500, "COOKIE_TEMPLATE_ERROR", err.Error(), false)
+					}
+					processed, err := templates.Process(cs.Then.Body, ctx)
 					if err != nil {
 						return responseError(c, 500, "TEMPLATE_PROCESS_ERROR", err.Error(), false)
 					}
-					c.Status(cs.Then.Status)
-					return c.JSON(processed)
+					if len(route.Mask) > 0 {
+						processed = server_utils.MaskFields(processed, route.Mask)
+					}
+					traceMark(c, "template")
+					err = sendBody(c, cs.Then.Status, processed, headerContentType(cs.Then.Headers, routeContentType))
+					traceMark(c, "send")
+					return err
 				}
 			}
+			traceMark(c, "cases")
+		}
+
+		// Header Variants: the request header value selects a canned response
+		if route.Variants != nil {
+			if variant, ok := route.Variants.Values[ctx.Headers[strings.ToLower(route.Variants.Header)]]; ok {
+				setMockSource(c, debugHeaders, "header_variant", "")
+				applyDelay(c.UserContext(), variant.DelayMs)
+				for k, v := range variant.Headers {
+					c.Set(k, v)
+				}
+				if err := setCookies(c, variant.Cookies, templates, ctx); err != nil {
+					return responseError(c, 500, "COOKIE_TEMPLATE_ERROR", err.Error(), false)
+				}
+				processed, err := templates.Process(variant.Body, ctx)
+				if err != nil {
+					return responseError(c, 500, "VARIANT_TEMPLATE_ERROR", err.Error(), false)
+				}
+				if len(route.Mask) > 0 {
+					processed = server_utils.MaskFields(processed, route.Mask)
+				}
+				traceMark(c, "template")
+				err = sendBody(c, variant.Status, processed, headerContentType(variant.Headers, routeContentType))
+				traceMark(c, "send")
+				return err
+			}
 		}
 
 		// Execute Base Handler (Fallback)
 		if baseHandler != nil {
-			return baseHandler(c, ctx)
+			err := baseHandler(c, ctx)
+			traceMark(c, "send")
+			return err
 		}
 
 		//  Default Handler (Fallback)
 		if route.Default != nil && route.Fetch == nil {
-			applyDelay(route.Default.DelayMs)
+			setMockSource(c, debugHeaders, "default", "")
+			applyDelay(c.UserContext(), route.Default.DelayMs)
 
 			for k, v := range route.Default.Headers {
 				c.Set(k, v)
 			}
+			if err := setCookies(c, route.Default.Cookies, templates, ctx); err != nil {
+				return responseError(c, 500, "COOKIE_TEMPLATE_ERROR", err.Error(), false)
+			}
 
-			processed, err := server_utils.ProcessTemplateJSON(route.Default.Body, ctx)
+			processed, err := templates.Process(route.Default.Body, ctx)
 			if err != nil {
 				return responseError(c, 500, "DEFAULT_TEMPLATE_ERROR", err.Error(), false)
 			}
+			if len(route.Mask) > 0 {
+				processed = server_utils.MaskFields(processed, route.Mask)
+			}
+			traceMark(c, "template")
 
-			c.Status(route.Default.Status)
-			return c.JSON(processed)
+			err = sendBody(c, route.Default.Status, processed, headerContentType(route.Default.Headers, routeContentType))
+			traceMark(c, "send")
+			return err
 		}
 
 		return responseError(c, fiber.StatusNotFound, "HANDLER_NOT_MATCHED", "No handler matched", false)
+	}
+
+	// Fetch routes also keep their own upstream timeout (fetch.timeout_ms)
+	if route.TimeoutMs > 0 {
+		handle = withTimeout(handle, route.TimeoutMs)
+	}
+
+	return func(c *fiber.Ctx) error {
+		trace := startTrace(c, srvCfg)
+		err := handle(c)
+		applyDebugStatusOverride(c, srvCfg.Debug)
+		applyStatusHeaders(c, srvCfg.StatusHeaders)
+		trace.finish(c)
+		return err
 	}, nil
 }
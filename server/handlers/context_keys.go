@@ -3,16 +3,22 @@ package server_handlers
 const (
 	RouteTypeMock      = "mock"
 	RouteTypeFetch     = "fetch"
+	RouteTypeEcho      = "echo"
+	RouteTypeStatic    = "static"
 	RouteTypeInternal  = "internal"
 	RouteTypeUnmatched = "unmatched"
 )
 
 const (
 	CtxRequestID      = "__req_id"
-	CtxRouteType      = "__route_type" // "mock" | "fetch"
+	CtxRouteType      = "__route_type" // "mock" | "fetch" | "echo"
 	CtxRoutePath      = "__route_path"
 	CtxRouteName      = "__route_name"
 	CtxUpstreamURL    = "__up_url"
 	CtxUpstreamStatus = "__up_status"
 	CtxUpstreamTimeMs = "__up_time_ms"
+	CtxSkipLog        = "__skip_log"   // set by routes with log_requests: false
+	CtxSkipRoute      = "__skip_route" // set by match_query guards when the request query does not match
+	CtxProto          = "__proto"      // client protocol, including requests bridged through server.h2c
+	CtxClock          = "__clock"      // func() time.Time of the serving runtime (server.frozen_time)
 )
@@ -1,6 +1,7 @@
 package server_handlers
 
 import (
+	"net/url"
 	"strings"
 	"time"
 
@@ -20,12 +21,18 @@ type RequestLog struct {
 	Time       time.Time `json:"time"`
 	DurationMs int64     `json:"duration_ms"`
 
+	// Number of consecutive identical requests folded into this entry (see Debug.CoalesceLogs);
+	// Time is then the latest request and FirstTime the earliest
+	Count     int        `json:"count"`
+	FirstTime *time.Time `json:"first_time,omitempty"`
+
 	Request struct {
-		Method string            `json:"method"`
-		Path   string            `json:"path"`
-		Query  map[string]string `json:"query,omitempty"`
-		IP     string            `json:"ip"`
-		UA     string            `json:"user_agent,omitempty"`
+		Method  string            `json:"method"`
+		Path    string            `json:"path"`
+		Query   map[string]string `json:"query,omitempty"`
+		Headers map[string]string `json:"headers,omitempty"`
+		IP      string            `json:"ip"`
+		UA      string            `json:"user_agent,omitempty"`
 	} `json:"request"`
 
 	Response struct {
@@ -42,6 +49,13 @@ type RequestLog struct {
 		Status     int    `json:"status"`
 		DurationMs int64  `json:"duration_ms"`
 	} `json:"upstream,omitempty"`
+
+	coalesce bool
+}
+
+// fingerprint identifies requests that can be coalesced together.
+func (l *RequestLog) fingerprint() string {
+	return l.Request.Method + " " + l.Request.Path + " " + strconv.Itoa(l.Response.Status)
 }
 
 var (
@@ -53,6 +67,8 @@ var (
 
 var IgnoredPaths = map[string]bool{
 	"/openapi.json": true,
+	"/openapi.yaml": true,
+	"/openapi":      true,
 	"/favicon.ico":  true,
 }
 
@@ -63,6 +79,20 @@ func StartLogAggregator() {
 		for {
 			select {
 			case entry := <-logChannel:
+				// Coalesced entries keep the first request's time in FirstTime and report the latest one
+				if n := len(requestLogs); entry.coalesce && n > 0 &&
+					requestLogs[n-1].fingerprint() == entry.fingerprint() {
+					last := &requestLogs[n-1]
+					if last.FirstTime == nil {
+						first := last.Time
+						last.FirstTime = &first
+					}
+					last.Time = entry.Time
+					last.DurationMs = entry.DurationMs
+					last.Count++
+					continue
+				}
+
 				if len(requestLogs) >= maxLogRecords {
 					requestLogs = requestLogs[1:]
 				}
@@ -83,22 +113,100 @@ func StartLogAggregator() {
 	}()
 }
 
+const RedactedValue = "****"
+
 // Utils
-func extractSafeHeaders(c *fiber.Ctx) map[string]string {
+
+// extractSafeHeaders snapshots the request headers (lowercased keys) for logging.
+// Values of headers listed in redact are replaced with RedactedValue.
+func extractSafeHeaders(c *fiber.Ctx, redact map[string]struct{}) map[string]string {
 	out := map[string]string{}
 
-	if ua := c.Get("User-Agent"); ua != "" {
-		out["user-agent"] = ua
+	for k, vals := range c.GetReqHeaders() {
+		if len(vals) == 0 {
+			continue
+		}
+		// Keys and values are copied: fasthttp reuses the underlying buffers after the request
+		key := strings.Clone(strings.ToLower(k))
+		if _, ok := redact[key]; ok {
+			out[key] = RedactedValue
+			continue
+		}
+		out[key] = string([]byte(vals[0]))
+	}
+	return out
+}
+
+// alwaysRedactedHeaders are masked in request logs whatever redact_headers says.
+var alwaysRedactedHeaders = []string{"Set-Cookie"}
+
+// authParamNames collects the header and query parameter names that carry credentials
+// for the server, route and auth_any schemes, so request logs never show them.
+func authParamNames(cfg *msconfig.Config) (headers, queries []string) {
+	add := func(auth *msconfig.AuthConfig) {
+		if auth == nil || auth.Name == "" {
+			return
+		}
+		switch strings.ToLower(auth.In) {
+		case "header":
+			headers = append(headers, auth.Name)
+		case "query":
+			queries = append(queries, auth.Name)
+		}
 	}
-	if ct := c.Get("Content-Type"); ct != "" {
-		out["content-type"] = ct
+
+	add(cfg.Server.Auth)
+	for i := range cfg.Routes {
+		add(cfg.Routes[i].Auth)
+		for j := range cfg.Routes[i].AuthAny {
+			add(&cfg.Routes[i].AuthAny[j])
+		}
 	}
-	if al := c.Get("Accept-Language"); al != "" {
-		out["accept-language"] = al
+	return headers, queries
+}
+
+// redactQueries copies the query map out of the request buffers, masking the values of parameters listed in redact.
+func redactQueries(queries map[string]string, redact map[string]struct{}) map[string]string {
+	out := make(map[string]string, len(queries))
+	for k, v := range queries {
+		if _, ok := redact[strings.ToLower(k)]; ok {
+			v = RedactedValue
+		}
+		out[strings.Clone(k)] = strings.Clone(v)
 	}
 	return out
 }
 
+// redactURL masks redacted query parameter values in a request URI, keeping the parameter order.
+func redactURL(uri string, redact map[string]struct{}) string {
+	path, query, found := strings.Cut(uri, "?")
+	if !found || len(redact) == 0 {
+		return uri
+	}
+
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		rawKey, _, _ := strings.Cut(param, "=")
+		key := rawKey
+		if name, err := url.QueryUnescape(rawKey); err == nil {
+			key = name
+		}
+		if _, ok := redact[strings.ToLower(key)]; ok {
+			params[i] = rawKey + "=" + RedactedValue
+		}
+	}
+	return path + "?" + strings.Join(params, "&")
+}
+
+// buildRedactSet normalizes the configured header names into a lookup set.
+func buildRedactSet(headers []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(headers))
+	for _, h := range headers {
+		set[strings.ToLower(strings.TrimSpace(h))] = struct{}{}
+	}
+	return set
+}
+
 func getClientIP(c *fiber.Ctx) string {
 	if ip := c.Get("X-Forwarded-For"); ip != "" {
 		return strings.Split(ip, ",")[0]
@@ -118,6 +226,11 @@ var requestCounter uint64
 
 // Middleware
 func RequestLoggerMiddleware(debugPath string, cfg *msconfig.Config) fiber.Handler {
+	authHeaders, authQueries := authParamNames(cfg)
+	redactHeaders := append(append(append([]string{}, cfg.Server.Debug.RedactHeaders...), alwaysRedactedHeaders...), authHeaders...)
+	redact := buildRedactSet(redactHeaders)
+	redactQuery := buildRedactSet(authQueries)
+
 	return func(c *fiber.Ctx) error {
 
 		if strings.HasPrefix(c.Path(), debugPath) || IgnoredPaths[c.Path()] || strings.HasPrefix(c.Path(), cfg.Server.Console.Path) {
@@ -133,23 +246,31 @@ func RequestLoggerMiddleware(debugPath string, cfg *msconfig.Config) fiber.Handl
 
 		// SAFE SNAPSHOT (BEFORE Next)
 		method := string([]byte(c.Method()))
-		originalURL := string([]byte(c.OriginalURL()))
+		originalURL := redactURL(string([]byte(c.OriginalURL())), redactQuery)
 		// queries := safeQueries(c.Queries())
-		queries := c.Queries()
-		ip := getClientIP(c)
+		queries := redactQueries(c.Queries(), redactQuery)
+		ip := strings.Clone(getClientIP(c))
 		ua := string([]byte(c.Get("User-Agent")))
+		headers := extractSafeHeaders(c, redact)
 
 		err := c.Next()
 
+		if skip, _ := c.Locals(CtxSkipLog).(bool); skip {
+			return err
+		}
+
 		entry := RequestLog{
 			ID:         reqID,
 			Time:       start,
 			DurationMs: time.Since(start).Milliseconds(),
+			Count:      1,
+			coalesce:   cfg.Server.Debug.CoalesceLogs,
 		}
 
 		entry.Request.Method = method
 		entry.Request.Path = originalURL
 		entry.Request.Query = queries
+		entry.Request.Headers = headers
 		entry.Request.IP = ip
 		entry.Request.UA = ua
 		entry.Response.Status = c.Response().StatusCode()
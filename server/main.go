@@ -7,6 +7,7 @@ import (
 
 	// "os"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -31,6 +32,11 @@ var idRegex = regexp.MustCompile(`{([a-zA-Z0-9_]+)}`)
 // It is initialized once at startup.
 var globalStateStore = server_utils.NewStateStore()
 
+// FlushState writes a pending state snapshot right away (no-op without server.persistence).
+func FlushState() error {
+	return globalStateStore.Flush()
+}
+
 func (e *ApiError) Error() string {
 	return e.Message
 }
@@ -49,9 +55,33 @@ func StartServer(cfg *msconfig.Config, configFilePath string, embedFS fs.FS, fav
 	// Initialize background log aggregation
 	msServerHandlers.StartLogAggregator()
 
+	// Config values win over runtime updates on every (re)load
+	globalVariables.Replace(cfg.Server.Globals)
+
+	// Restore persisted collections once; later reloads keep the live store
+	if p := cfg.Server.Persistence; p != nil {
+		if err := globalStateStore.EnablePersistence(p.Path, time.Duration(p.DebounceMs)*time.Millisecond); err != nil {
+			msUtils.StopWithError("Failed to restore persisted state", err)
+		}
+	}
+
+	perf := cfg.Server.Performance
+	if perf == nil {
+		perf = &msconfig.PerformanceConfig{}
+	}
+
 	app := fiber.New(fiber.Config{
 		DisableStartupMessage: true,
 
+		// Server tuning (zero values keep Fiber's defaults)
+		ReadTimeout:  time.Duration(perf.ReadTimeoutMs) * time.Millisecond,
+		WriteTimeout: time.Duration(perf.WriteTimeoutMs) * time.Millisecond,
+		IdleTimeout:  time.Duration(perf.IdleTimeoutMs) * time.Millisecond,
+		Concurrency:  perf.Concurrency,
+
+		// Keep request numbers as json.Number so large integer ids stay exact
+		JSONDecoder: server_utils.DecodeJSON,
+
 		// Custom Fiber Error Handler
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
@@ -77,7 +107,7 @@ func StartServer(cfg *msconfig.Config, configFilePath string, embedFS fs.FS, fav
 				Err:       http.StatusText(code),
 				ErrorCode: errorCode,
 				Message:   message,
-				Timestamp: time.Now().UTC().UnixNano() / 1e6,
+				Timestamp: requestClock(c)().UTC().UnixNano() / 1e6,
 			}
 			return c.Status(code).JSON(apiErr)
 		},
@@ -90,10 +120,9 @@ func StartServer(cfg *msconfig.Config, configFilePath string, embedFS fs.FS, fav
 	SetupConsoleRoutes(app, cfg, embedFS)
 
 	// OpenAPI / Swagger UI
-	app.Get("/openapi.json", func(c *fiber.Ctx) error {
-		openapi := generateOpenAPISpec(cfg)
-		return c.JSON(openapi)
-	})
+	app.Get("/openapi.json", openAPIJSONHandler(cfg))
+	app.Get("/openapi.yaml", openAPIYAMLHandler(cfg))
+	app.Get("/openapi", openAPINegotiatedHandler(cfg))
 	app.Get(cfg.Server.SwaggerUIPath, swaggerUIHandler)
 
 	// Debug Routes
@@ -111,6 +140,17 @@ func StartServer(cfg *msconfig.Config, configFilePath string, embedFS fs.FS, fav
 
 // setupMiddleware attaches global middleware to the Fiber app.
 func setupMiddleware(app *fiber.App, cfg *msconfig.Config, faviconFS fs.FS) {
+	// Request protocol (HTTP/2 requests arrive through the h2c bridge as HTTP/1.1)
+	app.Use(protoMiddleware(cfg.Server.H2C))
+
+	// time.* conditions and error timestamps follow this runtime's server.frozen_time
+	app.Use(clockMiddleware(cfg.Server))
+
+	// Duplicate slash handling ("//v1//users")
+	if mode := cfg.Server.NormalizePaths; mode != "" && mode != "off" {
+		app.Use(PathNormalizerMiddleware(mode))
+	}
+
 	// Favicon
 	// if _, err := os.Stat("./favicon.ico"); err == nil {
 		app.Use(favicon.New(favicon.Config{
@@ -128,12 +168,7 @@ func setupMiddleware(app *fiber.App, cfg *msconfig.Config, faviconFS fs.FS) {
 
 	// CORS
 	if cfg.Server.CORS.Enabled {
-		app.Use(cors.New(cors.Config{
-			AllowOrigins:     strings.Join(cfg.Server.CORS.AllowOrigins, ","),
-			AllowMethods:     strings.Join(cfg.Server.CORS.AllowMethods, ","),
-			AllowHeaders:     strings.Join(cfg.Server.CORS.AllowHeaders, ","),
-			AllowCredentials: cfg.Server.CORS.AllowCredentials,
-		}))
+		app.Use(cors.New(newCORSConfig(cfg.Server.CORS)))
 	} else {
 		app.Use(cors.New())
 	}
@@ -144,8 +179,9 @@ func setupMiddleware(app *fiber.App, cfg *msconfig.Config, faviconFS fs.FS) {
 		err := c.Next()
 		duration := time.Since(start)
 
-		// Skip logging for internal dashboard paths to keep logs clean
-		if msServerHandlers.IgnoredPaths[c.Path()] ||
+		// Skip logging for internal dashboard paths and opted-out routes to keep logs clean
+		if skip, _ := c.Locals(msServerHandlers.CtxSkipLog).(bool); skip ||
+			msServerHandlers.IgnoredPaths[c.Path()] ||
 			strings.HasPrefix(c.Path(), cfg.Server.Console.Path) ||
 			strings.HasPrefix(c.Path(), cfg.Server.Debug.Path) {
 			return nil
@@ -163,20 +199,64 @@ func registerUserRoutes(app *fiber.App, cfg *msconfig.Config, configFilePath str
 	maxLogRoutes := 10
 	routeLogCount := 0
 
-	for _, route := range cfg.Routes {
-		handler, err := createRouteHandler(route, cfg.Server, configFilePath, globalStateStore)
-		if err != nil {
-			msUtils.StopWithError(fmt.Sprintf("Failed to create route: %s", route.Name), err)
-			continue
-		}
+	// Idle upstream connections of dedicated fetch clients are released with this runtime
+	transports := &fetchTransports{}
+	app.Hooks().OnShutdown(func() error {
+		transports.closeIdle()
+		return nil
+	})
 
+	for _, route := range orderRoutes(cfg.Routes) {
 		// Convert OpenAPI style path "{id}" to Fiber style ":id"
 		fiberPath := idRegex.ReplaceAllString(route.Path, `:$1`)
 		routePath := prefix + fiberPath
 		method := strings.ToUpper(route.Method)
 
-		// Register the specific method
-		registerRoute(app, method, routePath, authMiddleware(cfg.Server.Auth, route.Auth), handler)
+		handlers := []fiber.Handler{authMiddleware(cfg.Server.Auth, route.Auth)}
+		if len(route.AuthAny) > 0 {
+			handlers[0] = authAnyMiddleware(route.AuthAny)
+		}
+		if hasRequiredParam(route.RequestHeaders) {
+			handlers = append(handlers, requiredHeadersMiddleware(route.RequestHeaders))
+		}
+		if route.StrictQuery {
+			handlers = append(handlers, strictQueryMiddleware(route, cfg.Server.Auth))
+		}
+		if route.Quota != nil {
+			handlers = append(handlers, quotaMiddleware(globalQuotaStore, route))
+		}
+		if !route.ShouldLogRequests() {
+			handlers = append([]fiber.Handler{skipRequestLog}, handlers...)
+		}
+
+		if route.Static != nil {
+			// Static directories are mounted as prefix middleware so nested files resolve; only GET and
+			// HEAD enter the mount, other methods skip its auth and quota and reach the remaining routes
+			args := []interface{}{routePath}
+			for _, h := range append(handlers, newStaticHandler(route, configFilePath)) {
+				args = append(args, readOnly(h))
+			}
+			app.Use(args...)
+		} else {
+			handler, err := createRouteHandler(route, cfg.Server, configFilePath, globalStateStore, transports)
+			if err != nil {
+				msUtils.StopWithError(fmt.Sprintf("Failed to create route: %s", route.Name), err)
+				continue
+			}
+
+			handlers = append(handlers, handler)
+
+			// Query-guarded routes skip their handlers and fall through when the query does not match
+			if len(route.MatchQuery) > 0 {
+				for i, h := range handlers {
+					handlers[i] = skippable(h)
+				}
+				handlers = append([]fiber.Handler{queryGuardMiddleware(route.MatchQuery)}, handlers...)
+			}
+
+			// Register the specific method
+			registerRoute(app, method, routePath, handlers...)
+		}
 
 		// Logging
 		routeLogCount++
@@ -190,32 +270,85 @@ func registerUserRoutes(app *fiber.App, cfg *msconfig.Config, configFilePath str
 	}
 }
 
+// orderRoutes returns the routes in registration order. Routes sharing a method and path are
+// grouped at the position of the first one, most specific match_query first (more required
+// values win, ties keep config order) and unguarded routes last, so they act as the fallback.
+func orderRoutes(routes []msconfig.RouteConfig) []msconfig.RouteConfig {
+	groups := map[string][]msconfig.RouteConfig{}
+	var keys []string
+
+	for _, route := range routes {
+		key := strings.ToUpper(route.Method) + " " + route.Path
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], route)
+	}
+
+	ordered := make([]msconfig.RouteConfig, 0, len(routes))
+	for _, key := range keys {
+		group := groups[key]
+		sort.SliceStable(group, func(i, j int) bool {
+			return len(group[i].MatchQuery) > len(group[j].MatchQuery)
+		})
+		ordered = append(ordered, group...)
+	}
+	return ordered
+}
+
 // registerRoute is a helper to dynamically register handlers based on string method names.
-func registerRoute(app *fiber.App, method, path string, mw, handler fiber.Handler) {
+func registerRoute(app *fiber.App, method, path string, handlers ...fiber.Handler) {
 	switch strings.ToUpper(method) {
 	case fiber.MethodGet:
-		app.Get(path, mw, handler)
+		app.Get(path, handlers...)
 	case fiber.MethodPost:
-		app.Post(path, mw, handler)
+		app.Post(path, handlers...)
 	case fiber.MethodPut:
-		app.Put(path, mw, handler)
+		app.Put(path, handlers...)
 	case fiber.MethodPatch:
-		app.Patch(path, mw, handler)
+		app.Patch(path, handlers...)
 	case fiber.MethodDelete:
-		app.Delete(path, mw, handler)
+		app.Delete(path, handlers...)
 	}
 }
 
+// skipRequestLog flags the request so the logging middlewares ignore it.
+func skipRequestLog(c *fiber.Ctx) error {
+	c.Locals(msServerHandlers.CtxSkipLog, true)
+	return c.Next()
+}
+
 // Debug route'ları ayırmak için (Opsiyonel temizlik)
 func setupDebugRoutes(app *fiber.App, cfg *msconfig.Config) {
 	debugRequestPath := cfg.Server.Debug.Path + "/requests"
 	debugHealthPath := cfg.Server.Debug.Path + "/health"
+	debugStateExportPath := cfg.Server.Debug.Path + "/state/export"
+	debugStateEventsPath := cfg.Server.Debug.Path + "/state/events"
+	debugQuotaResetPath := cfg.Server.Debug.Path + "/quota/reset"
+	debugGlobalsPath := cfg.Server.Debug.Path + "/globals"
 
 	app.Get(debugRequestPath, withRouteMeta(msServerHandlers.RouteTypeInternal, "debug_requests", msServerHandlers.DebugRequestsHandler))
 
 	routeCount, mockCount, fetchCount := getRoutesStat(cfg)
 	app.Get(debugHealthPath, withRouteMeta(msServerHandlers.RouteTypeInternal, "debug_health",
 		msServerHandlers.HealthHandler(routeCount, mockCount, fetchCount, appinfo.Version)))
+
+	// State export and events expose stored data, so they sit behind the global auth when configured.
+	app.Get(debugStateExportPath,
+		withRouteMeta(msServerHandlers.RouteTypeInternal, "debug_state_export", authMiddleware(cfg.Server.Auth, nil)),
+		stateExportHandler(globalStateStore))
+	app.Get(debugStateEventsPath,
+		withRouteMeta(msServerHandlers.RouteTypeInternal, "debug_state_events", authMiddleware(cfg.Server.Auth, nil)),
+		stateEventsHandler(globalStateStore))
+	app.Post(debugQuotaResetPath,
+		withRouteMeta(msServerHandlers.RouteTypeInternal, "debug_quota_reset", authMiddleware(cfg.Server.Auth, nil)),
+		quotaResetHandler(globalQuotaStore))
+	app.Get(debugGlobalsPath,
+		withRouteMeta(msServerHandlers.RouteTypeInternal, "debug_globals", authMiddleware(cfg.Server.Auth, nil)),
+		globalsGetHandler(globalVariables))
+	app.Put(debugGlobalsPath,
+		withRouteMeta(msServerHandlers.RouteTypeInternal, "debug_globals_update", authMiddleware(cfg.Server.Auth, nil)),
+		globalsUpdateHandler(globalVariables))
 }
 
 func normalizePrefix(prefix string) string {
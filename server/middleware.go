@@ -1,21 +1,35 @@
 package server
 
 import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
 )
 
 import (
 	msconfig "mockserver/config"
+	msServerHandlers "mockserver/server/handlers"
 )
 
 // PathNormalizerMiddleware sanitizes the request URL by removing duplicate slashes.
 // This ensures that routes like "//console//dashboard" are treated as "/console/dashboard",
 // preventing routing mismatches and improving SEO/canonical URL handling.
-func PathNormalizerMiddleware(consolePath string) fiber.Handler {
+//
+// mode "redirect" answers with a 301 to the collapsed path (query string kept);
+// "rewrite" routes the request as if the collapsed path was sent, without a round trip.
+func PathNormalizerMiddleware(mode string) fiber.Handler {
 
 	slashRegex := regexp.MustCompile(`/{2,}`)
 	return func(c *fiber.Ctx) error {
@@ -27,6 +41,13 @@ func PathNormalizerMiddleware(consolePath string) fiber.Handler {
 		}
 
 		if path != originalPath {
+			if mode == "rewrite" {
+				c.Path(path)
+				return c.Next()
+			}
+			if query := string(c.Request().URI().QueryString()); query != "" {
+				path += "?" + query
+			}
 			return c.Redirect(path, fiber.StatusMovedPermanently)
 		}
 
@@ -58,9 +79,105 @@ func RegisterFallback() fiber.Handler {
 	}
 }
 
+// queryGuardMiddleware lets a request into the route only when every match_query value is present
+// in the query string. Otherwise the route's remaining handlers are skipped (see skippable) and
+// routing continues with the next route matching the method and path, or the 404 fallback.
+func queryGuardMiddleware(required map[string]string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		matched := true
+		for key, want := range required {
+			if c.Query(key) != want {
+				matched = false
+				break
+			}
+		}
+
+		c.Locals(msServerHandlers.CtxSkipRoute, !matched)
+		return c.Next()
+	}
+}
+
+// skippable wraps a handler of a query-guarded route so it passes the request on when the guard rejected it.
+func skippable(next fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if skip, _ := c.Locals(msServerHandlers.CtxSkipRoute).(bool); skip {
+			return c.Next()
+		}
+		return next(c)
+	}
+}
+
+// readOnly runs next for GET and HEAD requests only; any other method continues down the stack.
+func readOnly(next fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if method := c.Method(); method != fiber.MethodGet && method != fiber.MethodHead {
+			return c.Next()
+		}
+		return next(c)
+	}
+}
+
+// newCORSConfig builds the Fiber CORS settings. Plain origins are passed through as-is; when
+// wildcard or regex patterns are configured, every origin is matched by AllowOriginsFunc and the
+// matched origin is reflected back in Access-Control-Allow-Origin.
+//
+// With allow_credentials, browsers reject a literal "*", so origins are always matched by
+// AllowOriginsFunc and reflected (with Vary: Origin). Only listed origins and patterns are reflected;
+// config validation rejects "*" together with credentials.
+func newCORSConfig(corsCfg *msconfig.CORSConfig) cors.Config {
+	result := cors.Config{
+		AllowOrigins:     strings.Join(corsCfg.AllowOrigins, ","),
+		AllowMethods:     strings.Join(corsCfg.AllowMethods, ","),
+		AllowHeaders:     strings.Join(corsCfg.AllowHeaders, ","),
+		AllowCredentials: corsCfg.AllowCredentials,
+	}
+
+	exact, patterns, err := corsCfg.OriginPatterns()
+	if err != nil {
+		return result // invalid patterns are rejected when the config is loaded
+	}
+	wildcard := false
+	for _, origin := range exact {
+		if origin == "*" {
+			wildcard = true
+		}
+	}
+
+	if !corsCfg.AllowCredentials {
+		if len(patterns) == 0 {
+			return result
+		}
+		if wildcard {
+			result.AllowOrigins = "*"
+			return result
+		}
+	}
+
+	result.AllowOrigins = ""
+	result.AllowOriginsFunc = func(origin string) bool {
+		return corsOriginAllowed(origin, exact, patterns)
+	}
+	return result
+}
+
+// corsOriginAllowed matches an origin against the plain (case-insensitive) and pattern allowlists.
+func corsOriginAllowed(origin string, exact []string, patterns []*regexp.Regexp) bool {
+	for _, allowed := range exact {
+		if strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	for _, re := range patterns {
+		if re.MatchString(strings.ToLower(origin)) {
+			return true
+		}
+	}
+	return false
+}
+
 // authMiddleware enforces access control based on the configuration.
 // It prioritizes Route-Level authentication over Global authentication.
-// Supports: API Key (Header/Query) and Bearer Token schemes.
+// Supports: API Key (Header/Query), Bearer Token, HMAC signature and Basic schemes.
 func authMiddleware(globalAuth, routeAuth *msconfig.AuthConfig) fiber.Handler {
 
 	// Determine effective configuration (Route > Global)
@@ -74,58 +191,235 @@ func authMiddleware(globalAuth, routeAuth *msconfig.AuthConfig) fiber.Handler {
 	}
 
 	return func(c *fiber.Ctx) error {
+		if failure := checkAuth(c, authConf); failure != nil {
+			setAuthChallenge(c, authConf, failure)
+			return responseError(c, failure.status, failure.code, failure.message, false)
+		}
+		return c.Next()
+	}
+}
 
-		authType := strings.ToLower(authConf.Type)
-		authIn := strings.ToLower(authConf.In)
-		authName := authConf.Name
+// authAnyMiddleware accepts the request when any enabled scheme validates, tried in order.
+// It takes precedence over route and global auth; 401 is returned only if every scheme fails.
+func authAnyMiddleware(schemes []msconfig.AuthConfig) fiber.Handler {
+	var enabled []*msconfig.AuthConfig
+	for i := range schemes {
+		if schemes[i].Enabled {
+			enabled = append(enabled, &schemes[i])
+		}
+	}
+
+	if len(enabled) == 0 {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
 
-		// Configuration Sanity Check
-		if authType == "" {
-			return responseError(c, fiber.StatusInternalServerError, "AUTH_MISCONFIGURED", "Authentication type is missing", false)
+	return func(c *fiber.Ctx) error {
+		reasons := make([]string, 0, len(enabled))
+		failures := make([]*authFailure, 0, len(enabled))
+		for _, authConf := range enabled {
+			failure := checkAuth(c, authConf)
+			if failure == nil {
+				return c.Next()
+			}
+			reasons = append(reasons, fmt.Sprintf("%s: %s", strings.ToLower(authConf.Type), failure.message))
+			failures = append(failures, failure)
 		}
+		// Challenges are only sent once every scheme has rejected the request,
+		// so a later scheme that accepts it never leaks a WWW-Authenticate header.
+		for i, failure := range failures {
+			setAuthChallenge(c, enabled[i], failure)
+		}
+		return responseError(c, fiber.StatusUnauthorized, "AUTH_FAILED",
+			"No authentication scheme accepted the request ("+strings.Join(reasons, "; ")+")", false)
+	}
+}
+
+// ProtoHeader carries the client protocol (e.g. "HTTP/2.0") from the server.h2c bridge,
+// whose adaptor hands every request to Fiber as HTTP/1.1.
+const ProtoHeader = "X-Mockserver-Proto"
 
-		var credential string
-		switch strings.ToLower(authIn) {
-		case "header":
-			credential = c.Get(authName)
-		case "query":
-			credential = c.Query(authName)
+// protoMiddleware records the request protocol for conditions. The bridge header is only
+// trusted under server.h2c (the bridge overwrites any client value) and is always removed,
+// so it never reaches echo bodies, logs or upstreams.
+func protoMiddleware(h2c bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		proto := string(c.Request().Header.Protocol())
+		if bridged := c.Get(ProtoHeader); h2c && bridged != "" {
+			proto = strings.Clone(bridged)
 		}
+		c.Request().Header.Del(ProtoHeader)
+		c.Locals(msServerHandlers.CtxProto, proto)
+		return c.Next()
+	}
+}
+
+// requestProto returns the protocol recorded by protoMiddleware, or the raw request protocol.
+func requestProto(c *fiber.Ctx) string {
+	if proto, ok := c.Locals(msServerHandlers.CtxProto).(string); ok {
+		return proto
+	}
+	return string(c.Request().Header.Protocol())
+}
+
+// clockMiddleware records the runtime's clock, so a reload with a different server.frozen_time
+// never changes the time seen by requests still served by the previous runtime.
+func clockMiddleware(srvCfg msconfig.ServerConfig) fiber.Handler {
+	clock := time.Now
+	if frozen, ok := srvCfg.FrozenNow(); ok {
+		clock = func() time.Time { return frozen }
+	}
+	return func(c *fiber.Ctx) error {
+		c.Locals(msServerHandlers.CtxClock, clock)
+		return c.Next()
+	}
+}
+
+// requestClock returns the clock recorded by clockMiddleware, or the real clock.
+func requestClock(c *fiber.Ctx) func() time.Time {
+	if clock, ok := c.Locals(msServerHandlers.CtxClock).(func() time.Time); ok {
+		return clock
+	}
+	return time.Now
+}
 
-		if credential == "" && authType == "bearer" {
-			credential = c.Get("Authorization")
+// authFailure describes why a credential was rejected by a single auth scheme.
+type authFailure struct {
+	status  int
+	code    string
+	message string
+}
+
+// basicAuthChallenge asks clients to retry with Basic credentials.
+const basicAuthChallenge = `Basic realm="mockserver"`
+
+// setAuthChallenge adds the WWW-Authenticate header to rejected Basic auth requests.
+func setAuthChallenge(c *fiber.Ctx, authConf *msconfig.AuthConfig, failure *authFailure) {
+	if failure.status == fiber.StatusUnauthorized && strings.EqualFold(authConf.Type, "basic") {
+		c.Set(fiber.HeaderWWWAuthenticate, basicAuthChallenge)
+	}
+}
+
+// checkAuth validates the request against one auth scheme, returning nil on success.
+func checkAuth(c *fiber.Ctx, authConf *msconfig.AuthConfig) *authFailure {
+	authType := strings.ToLower(authConf.Type)
+	authIn := strings.ToLower(authConf.In)
+	authName := authConf.Name
+
+	// Configuration Sanity Check
+	if authType == "" {
+		return &authFailure{fiber.StatusInternalServerError, "AUTH_MISCONFIGURED", "Authentication type is missing"}
+	}
+
+	var credential string
+	switch authIn {
+	case "header":
+		credential = c.Get(authName)
+	case "query":
+		credential = c.Query(authName)
+	}
+
+	if credential == "" && authType == "bearer" {
+		credential = c.Get("Authorization")
+	}
+
+	// Basic credentials always travel in the Authorization header
+	if authType == "basic" {
+		credential = c.Get(fiber.HeaderAuthorization)
+	}
+
+	if credential == "" {
+		return &authFailure{fiber.StatusUnauthorized, "MISSING_CREDENTIAL", "Missing authentication credential"}
+	}
+
+	// Validate Credential Scheme
+	switch authType {
+	case "apikey":
+		if !_contains(authConf.Keys, credential) {
+			return &authFailure{fiber.StatusUnauthorized, "INVALID_API_KEY", "Invalid API key"}
 		}
+	case "bearer":
+
+		token := credential
 
-		if credential == "" {
-			return responseError(c, fiber.StatusUnauthorized, "MISSING_CREDENTIAL", "Missing authentication credential", false)
+		if len(credential) > 7 && strings.EqualFold(credential[0:7], "Bearer ") {
+			token = credential[7:]
 		}
 
-		// Validate Credential Scheme
-		switch strings.ToLower(authConf.Type) {
-		case "apikey":
-			if !_contains(authConf.Keys, credential) {
-				return responseError(c, fiber.StatusUnauthorized, "INVALID_API_KEY", "Invalid API key", false)
-			}
-		case "bearer":
+		// Bearer token extraction and validation
+		token = strings.TrimSpace(token)
 
-			token := credential
+		if !_contains(authConf.Keys, token) {
+			return &authFailure{fiber.StatusUnauthorized, "INVALID_BEARER_TOKEN", "Invalid bearer token"}
+		}
+	case "hmac":
+		if !validHMACSignature(c.Body(), credential, authConf) {
+			return &authFailure{fiber.StatusUnauthorized, "INVALID_SIGNATURE", "Invalid request signature"}
+		}
+	case "basic":
+		if !validBasicCredentials(credential, authConf.Keys) {
+			return &authFailure{fiber.StatusUnauthorized, "INVALID_BASIC_CREDENTIALS", "Invalid username or password"}
+		}
+	default:
+		return &authFailure{fiber.StatusInternalServerError, "UNSUPPORTED_AUTH_TYPE", "Unsupported authentication type"}
+	}
 
-			if len(credential) > 7 && strings.EqualFold(credential[0:7], "Bearer ") {
-				token = credential[7:]
-			}
+	return nil
+}
 
-			// Bearer token extraction and validation
-			token = strings.TrimSpace(token)
+// hmacHashes maps auth.algorithm to its hash constructor.
+var hmacHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
 
-			if !_contains(authConf.Keys, token) {
-				return responseError(c, fiber.StatusUnauthorized, "INVALID_BEARER_TOKEN", "Invalid bearer token", false)
-			}
-		default:
-			return responseError(c, fiber.StatusInternalServerError, "UNSUPPORTED_AUTH_TYPE", "Unsupported authentication type", false)
-		}
+// validHMACSignature recomputes the HMAC of the raw body and compares it in constant time.
+// The signature is hex encoded and may carry a webhook-style "<algorithm>=" prefix (e.g. "sha256=ab12...").
+func validHMACSignature(body []byte, signature string, authConf *msconfig.AuthConfig) bool {
+	algorithm := authConf.HMACAlgorithm()
+	newHash, ok := hmacHashes[algorithm]
+	if !ok {
+		return false
+	}
 
-		return c.Next()
+	signature = strings.TrimSpace(signature)
+	if len(signature) > len(algorithm)+1 && strings.EqualFold(signature[:len(algorithm)+1], algorithm+"=") {
+		signature = signature[len(algorithm)+1:]
+	}
+	given, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(newHash, []byte(authConf.Secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), given)
+}
+
+// validBasicCredentials decodes "Basic <base64(user:pass)>" and compares it in constant time
+// against the configured "user:pass" keys.
+func validBasicCredentials(header string, keys []string) bool {
+	if len(header) < 6 || !strings.EqualFold(header[:6], "Basic ") {
+		return false
 	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(header[6:]))
+	if err != nil {
+		return false
+	}
+	user, pass, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return false
+	}
+
+	for _, key := range keys {
+		validUser, validPass, _ := strings.Cut(key, ":")
+		userMatch := subtle.ConstantTimeCompare([]byte(user), []byte(validUser)) == 1
+		passMatch := subtle.ConstantTimeCompare([]byte(pass), []byte(validPass)) == 1
+		if userMatch && passMatch {
+			return true
+		}
+	}
+	return false
 }
 
 // containsString is a helper to check for string existence in a slice.
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	server_utils "mockserver/server/utils"
+)
+
+// TestParseAndFilterMockDataKeepsLargeNumbers verifies fixture ids beyond float64 precision are served and filtered exactly.
+func TestParseAndFilterMockDataKeepsLargeNumbers(t *testing.T) {
+	templates := server_utils.NewTemplateEngine(server_utils.TemplateOptions{})
+	data := []byte(`[{"id": 12345678901234567891, "name": "Ada"}, {"id": 12345678901234567890, "name": "Alan"}]`)
+
+	result, err := parseAndFilterMockData(templates, data, server_utils.EContext{}, map[string]string{"id": "12345678901234567891"})
+	require.NoError(t, err)
+	raw, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"id": 12345678901234567891, "name": "Ada"}]`, string(raw))
+
+	single, err := parseAndFilterMockData(templates, []byte(`{"id": 12345678901234567891}`), server_utils.EContext{}, nil)
+	require.NoError(t, err)
+	raw, err = json.Marshal(single)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":12345678901234567891}`, string(raw))
+}
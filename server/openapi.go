@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/gofiber/fiber/v2"
+	"gopkg.in/yaml.v3"
 )
 
 import (
@@ -103,9 +105,13 @@ func buildRequestBody(route msconfig.RouteConfig) map[string]interface{} {
 	return reqBody
 }
 
-func buildResponses(route msconfig.RouteConfig) map[string]interface{} {
+func buildResponses(route msconfig.RouteConfig, methodDefaults map[string]int) map[string]interface{} {
 	responses := map[string]interface{}{}
 
+	// Mock bodies are documented under the status the mock handler actually answers with
+	baseStatus := resolveMockStatus(route, methodDefaults)
+	mockStatus := fmt.Sprintf("%d", baseStatus)
+
 	// CASE responses
 	for _, cs := range route.Cases {
 		statusCode := fmt.Sprintf("%d", cs.Then.Status)
@@ -119,6 +125,87 @@ func buildResponses(route msconfig.RouteConfig) map[string]interface{} {
 		}
 	}
 
+	// Header variant responses, in value order so the spec is stable across runs
+	if route.Variants != nil {
+		values := make([]string, 0, len(route.Variants.Values))
+		for value := range route.Variants.Values {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+
+		// Variants sharing a status are documented together as named examples
+		byStatus := map[int][]string{}
+		var statuses []int
+		for _, value := range values {
+			status := route.Variants.Values[value].Status
+			if _, seen := byStatus[status]; !seen {
+				statuses = append(statuses, status)
+			}
+			byStatus[status] = append(byStatus[status], value)
+		}
+
+		for _, status := range statuses {
+			group := byStatus[status]
+			media := map[string]interface{}{}
+			if len(group) == 1 {
+				media["example"] = route.Variants.Values[group[0]].Body
+			} else {
+				examples := make(map[string]interface{}, len(group))
+				for _, value := range group {
+					examples[value] = map[string]interface{}{
+						"summary": fmt.Sprintf("%s: %s", route.Variants.Header, value),
+						"value":   route.Variants.Values[value].Body,
+					}
+				}
+				media["examples"] = examples
+			}
+			responses[fmt.Sprintf("%d", status)] = map[string]interface{}{
+				"description": fmt.Sprintf("Variant response for %s: %s", route.Variants.Header, strings.Join(group, ", ")),
+				"content": map[string]interface{}{
+					"application/json": media,
+				},
+			}
+		}
+	}
+
+	// Weighted random responses
+	if route.Mock != nil {
+		for _, resp := range route.Mock.Responses {
+			status := resp.Status
+			if status == 0 {
+				status = baseStatus
+			}
+			if _, exists := responses[fmt.Sprintf("%d", status)]; exists || resp.Weight <= 0 {
+				continue
+			}
+			responses[fmt.Sprintf("%d", status)] = map[string]interface{}{
+				"description": fmt.Sprintf("Random response (weight %v)", resp.Weight),
+				"content": map[string]interface{}{
+					route.ResponseContentType(): map[string]interface{}{"example": resp.Body},
+				},
+			}
+		}
+	}
+
+	// Sequential responses
+	if route.Mock != nil && route.Mock.Sequence != nil {
+		for i, resp := range route.Mock.Sequence.Responses {
+			status := resp.Status
+			if status == 0 {
+				status = baseStatus
+			}
+			if _, exists := responses[fmt.Sprintf("%d", status)]; exists {
+				continue
+			}
+			responses[fmt.Sprintf("%d", status)] = map[string]interface{}{
+				"description": fmt.Sprintf("Sequence response #%d", i+1),
+				"content": map[string]interface{}{
+					route.ResponseContentType(): map[string]interface{}{"example": resp.Body},
+				},
+			}
+		}
+	}
+
 	// Default response
 	if route.Default != nil {
 		statusCode := fmt.Sprintf("%d", route.Default.Status)
@@ -151,6 +238,10 @@ func buildResponses(route msconfig.RouteConfig) map[string]interface{} {
 			responses["200"] = jsonResponseExample("Item updated", map[string]interface{}{})
 			responses["404"] = errorResponse("Not found", "Ensure the item exists before updating")
 
+		case "patch":
+			responses["200"] = jsonResponseExample("Item patched", map[string]interface{}{})
+			responses["404"] = errorResponse("Not found", "Ensure the item exists before patching")
+
 		case "delete":
 			responses["200"] = jsonResponseExample("Item deleted", map[string]interface{}{
 				"success": true,
@@ -162,17 +253,17 @@ func buildResponses(route msconfig.RouteConfig) map[string]interface{} {
 
 	if route.Mock != nil && route.Mock.File != "" {
 		if example, err := loadMockFile(route.Mock.File); err == nil {
-			responses["200"] = map[string]interface{}{
+			responses[mockStatus] = map[string]interface{}{
 				"description": "Successful response",
 				"content": map[string]interface{}{
-					"application/json": map[string]interface{}{"example": example},
+					route.ResponseContentType(): map[string]interface{}{"example": example},
 				},
 			}
 		} else if route.Mock.Body != nil {
-			responses["200"] = map[string]interface{}{
+			responses[mockStatus] = map[string]interface{}{
 				"description": "Successful response",
 				"content": map[string]interface{}{
-					"application/json": map[string]interface{}{"example": route.Mock.Body},
+					route.ResponseContentType(): map[string]interface{}{"example": route.Mock.Body},
 				},
 			}
 		} else {
@@ -180,10 +271,51 @@ func buildResponses(route msconfig.RouteConfig) map[string]interface{} {
 				"description": fmt.Sprintf("Failed to load mock file: %v", err),
 			}
 		}
+	} else if route.Mock != nil && route.Mock.Body != nil && route.Stateful == nil {
+		responses[mockStatus] = map[string]interface{}{
+			"description": "Successful response",
+			"content": map[string]interface{}{
+				route.ResponseContentType(): map[string]interface{}{"example": route.Mock.Body},
+			},
+		}
 	} else if route.Fetch != nil && route.Fetch.URL != "" {
 		responses["200"] = map[string]interface{}{
 			"description": "Successful response from upstream service",
 		}
+	} else if route.Static != nil {
+		responses["200"] = map[string]interface{}{
+			"description": fmt.Sprintf("Static file served from '%s'", route.Static.Dir),
+		}
+		responses["404"] = map[string]interface{}{"description": "File not found"}
+	} else if route.Echo {
+		responses["200"] = jsonResponseExample("Echo of the incoming request", map[string]interface{}{
+			"method": strings.ToUpper(route.Method), "path": route.Path,
+			"params": map[string]interface{}{}, "query": map[string]interface{}{},
+			"headers": map[string]interface{}{}, "body": map[string]interface{}{},
+		})
+	}
+
+	// Explicit response example wins over whatever was generated for the success status
+	if route.ResponseExample != nil {
+		status := mockStatus
+		if route.Stateful != nil {
+			status = "200"
+			if route.Stateful.Action == "create" {
+				status = "201"
+			}
+		}
+		description := "Successful response"
+		if existing, ok := responses[status].(map[string]interface{}); ok {
+			if desc, ok := existing["description"].(string); ok && desc != "" {
+				description = desc
+			}
+		}
+		responses[status] = map[string]interface{}{
+			"description": description,
+			"content": map[string]interface{}{
+				route.ResponseContentType(): map[string]interface{}{"example": route.ResponseExample},
+			},
+		}
 	}
 
 	return responses
@@ -215,7 +347,7 @@ func errorResponse(msg, hint string) map[string]interface{} {
 }
 
 // applyAuthToOperation applies authentication metadata to an OpenAPI operation.
-// Supports API key, bearer token, and basic auth.
+// Supports API key, bearer token, HMAC signature, and basic auth.
 func applyAuthToOperation(op map[string]interface{}, params *[]map[string]interface{}, auth *msconfig.AuthConfig) {
 	if auth == nil || !auth.Enabled {
 		return
@@ -236,6 +368,17 @@ func applyAuthToOperation(op map[string]interface{}, params *[]map[string]interf
 		}
 	case "bearer":
 		secName = "BearerAuth"
+	case "hmac":
+		// Signatures are per request, so they are documented as a header rather than a security scheme
+		if auth.Name != "" {
+			*params = append(*params, map[string]interface{}{
+				"name":        auth.Name,
+				"in":          "header",
+				"required":    true,
+				"schema":      map[string]interface{}{"type": "string"},
+				"description": fmt.Sprintf("Hex HMAC-%s of the raw request body", strings.ToUpper(auth.HMACAlgorithm())),
+			})
+		}
 	case "basic":
 		secName = "BasicAuth"
 	}
@@ -319,7 +462,7 @@ func generateOpenAPISpec(cfg *msconfig.Config) map[string]interface{} {
 			operation["requestBody"] = buildRequestBody(route)
 		}
 
-		operation["responses"] = buildResponses(route)
+		operation["responses"] = buildResponses(route, cfg.Server.MethodDefaultStatus)
 
 		// Add to paths
 		if paths[fullPath] == nil {
@@ -346,6 +489,57 @@ func generateOpenAPISpec(cfg *msconfig.Config) map[string]interface{} {
 	return spec
 }
 
+// OpenAPISpecJSON renders the spec served at /openapi.json as indented JSON,
+// without starting a server (used by `start --dump-openapi`).
+func OpenAPISpecJSON(cfg *msconfig.Config) ([]byte, error) {
+	return json.MarshalIndent(generateOpenAPISpec(cfg), "", "  ")
+}
+
+// openAPISpecYAML renders the same spec as /openapi.json in YAML. The spec goes through JSON first
+// so field names and values match the JSON document exactly (config structs carry different yaml tags).
+func openAPISpecYAML(cfg *msconfig.Config) ([]byte, error) {
+	encoded, err := json.Marshal(generateOpenAPISpec(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(encoded, &generic); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(generic)
+}
+
+// openAPIJSONHandler serves the spec as JSON (/openapi.json).
+func openAPIJSONHandler(cfg *msconfig.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(generateOpenAPISpec(cfg))
+	}
+}
+
+// openAPIYAMLHandler serves the spec as YAML (/openapi.yaml).
+func openAPIYAMLHandler(cfg *msconfig.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		data, err := openAPISpecYAML(cfg)
+		if err != nil {
+			return responseError(c, fiber.StatusInternalServerError, "OPENAPI_ENCODE_ERROR", err.Error(), false)
+		}
+		c.Set(fiber.HeaderContentType, "application/yaml")
+		return c.Send(data)
+	}
+}
+
+// openAPINegotiatedHandler serves /openapi as YAML when the Accept header asks for it, JSON otherwise.
+func openAPINegotiatedHandler(cfg *msconfig.Config) fiber.Handler {
+	jsonHandler, yamlHandler := openAPIJSONHandler(cfg), openAPIYAMLHandler(cfg)
+	return func(c *fiber.Ctx) error {
+		if strings.Contains(strings.ToLower(c.Get(fiber.HeaderAccept)), "yaml") {
+			return yamlHandler(c)
+		}
+		return jsonHandler(c)
+	}
+}
+
 // [IMP_FUNC]
 // swaggerUIHandler serves the Swagger UI for the API.
 // Loads OpenAPI spec from /openapi.json endpoint.
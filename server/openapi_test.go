@@ -0,0 +1,70 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	msconfig "mockserver/config"
+)
+
+// TestBuildResponsesVariantOrder verifies header variants sharing a status are all documented as
+// named examples, the same way on every run.
+func TestBuildResponsesVariantOrder(t *testing.T) {
+	route := msconfig.RouteConfig{
+		Name: "Tenant", Method: "GET", Path: "/tenant",
+		Variants: &msconfig.VariantsConfig{
+			Header: "X-Tenant",
+			Values: map[string]msconfig.CResponse{
+				"acme":     {Status: 200, Body: map[string]interface{}{"theme": "red"}},
+				"globex":   {Status: 200, Body: map[string]interface{}{"theme": "blue"}},
+				"initech":  {Status: 200, Body: map[string]interface{}{"theme": "green"}},
+				"umbrella": {Status: 403, Body: map[string]interface{}{"error": "suspended"}},
+			},
+		},
+	}
+
+	for i := 0; i < 20; i++ {
+		responses := buildResponses(route, nil)
+		ok := responses["200"].(map[string]interface{})
+		assert.Equal(t, "Variant response for X-Tenant: acme, globex, initech", ok["description"])
+
+		media := ok["content"].(map[string]interface{})["application/json"].(map[string]interface{})
+		assert.NotContains(t, media, "example")
+		assert.Equal(t, map[string]interface{}{
+			"acme":    map[string]interface{}{"summary": "X-Tenant: acme", "value": map[string]interface{}{"theme": "red"}},
+			"globex":  map[string]interface{}{"summary": "X-Tenant: globex", "value": map[string]interface{}{"theme": "blue"}},
+			"initech": map[string]interface{}{"summary": "X-Tenant: initech", "value": map[string]interface{}{"theme": "green"}},
+		}, media["examples"])
+
+		// A status with a single variant keeps the plain example
+		forbidden := responses["403"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"example": map[string]interface{}{"error": "suspended"}},
+			forbidden["content"].(map[string]interface{})["application/json"])
+	}
+}
+
+// TestBuildResponsesMockStatus verifies mocks are documented under the status the handler answers
+// with: mock status, else route status, else server.method_default_status.
+func TestBuildResponsesMockStatus(t *testing.T) {
+	defaults := map[string]int{"POST": 201}
+	body := map[string]interface{}{"ok": true}
+
+	route := msconfig.RouteConfig{Name: "Create", Method: "POST", Path: "/orders", Mock: &msconfig.MockConfig{Body: body}}
+	assert.Equal(t, []string{"201"}, sortedKeys(buildResponses(route, defaults)))
+	assert.Equal(t, []string{"200"}, sortedKeys(buildResponses(route, nil)))
+
+	route.Status = 202
+	assert.Equal(t, []string{"202"}, sortedKeys(buildResponses(route, defaults)))
+
+	route.Mock.Status = 200
+	assert.Equal(t, []string{"200"}, sortedKeys(buildResponses(route, defaults)))
+
+	// Weighted responses without a status use the same resolution
+	weighted := msconfig.RouteConfig{Name: "Flaky", Method: "POST", Path: "/flaky", Mock: &msconfig.MockConfig{
+		Responses: []msconfig.WeightedResponse{{Weight: 1, Body: body}, {Weight: 1, Status: 503, Body: body}},
+	}}
+	assert.Equal(t, []string{"201", "503"}, sortedKeys(buildResponses(weighted, defaults)))
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+import (
+	msconfig "mockserver/config"
+)
+
+// globalQuotaStore holds per-route quota counters. Like the state store it survives hot reloads.
+var globalQuotaStore = newQuotaStore()
+
+// quotaCounter tracks the requests consumed in the current window.
+type quotaCounter struct {
+	names       map[string]bool // names of the routes using the counter, so it can be reset by any of them
+	used        int
+	windowStart time.Time
+}
+
+// quotaStore keeps quota counters keyed by route method, path and match_query (see quotaKey).
+type quotaStore struct {
+	mu       sync.Mutex
+	counters map[string]*quotaCounter
+}
+
+func newQuotaStore() *quotaStore {
+	return &quotaStore{counters: make(map[string]*quotaCounter)}
+}
+
+// take consumes one request from the route quota. When the quota is exhausted it reports false
+// and, for windowed quotas, how long until the window starts over.
+func (q *quotaStore) take(key, name string, quota *msconfig.QuotaConfig, now time.Time) (int, time.Duration, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	counter, ok := q.counters[key]
+	window := time.Duration(quota.WindowSeconds) * time.Second
+	if !ok {
+		counter = &quotaCounter{names: map[string]bool{}, windowStart: now}
+		q.counters[key] = counter
+	} else if window > 0 && now.Sub(counter.windowStart) >= window {
+		counter.used = 0
+		counter.windowStart = now
+	}
+	if name != "" {
+		counter.names[name] = true
+	}
+
+	if counter.used >= quota.Max {
+		var retryIn time.Duration
+		if window > 0 {
+			retryIn = counter.windowStart.Add(window).Sub(now)
+		}
+		return 0, retryIn, false
+	}
+	counter.used++
+	return quota.Max - counter.used, 0, true
+}
+
+// reset clears the counters matching route, either a route name or a "METHOD /path" key, or all
+// counters when route is empty. It returns the number cleared.
+func (q *quotaStore) reset(route string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if route == "" {
+		n := len(q.counters)
+		q.counters = make(map[string]*quotaCounter)
+		return n
+	}
+	n := 0
+	for key, counter := range q.counters {
+		if key == route || counter.names[route] {
+			delete(q.counters, key)
+			n++
+		}
+	}
+	return n
+}
+
+// quotaKey identifies a route's counter by method, path and match_query (sorted, e.g.
+// "GET /reports?view=all"): route names are not required to be unique, so two routes sharing a
+// name must not share a quota, and routes told apart by match_query count separately.
+func quotaKey(route msconfig.RouteConfig) string {
+	key := strings.ToUpper(route.Method) + " " + route.Path
+	if len(route.MatchQuery) > 0 {
+		query := url.Values{}
+		for k, v := range route.MatchQuery {
+			query.Set(k, v)
+		}
+		key += "?" + query.Encode()
+	}
+	return key
+}
+
+// quotaMiddleware enforces route.quota, answering 429 QUOTA_EXCEEDED once the quota is consumed.
+// X-Quota-Limit / X-Quota-Remaining report the counter on every response.
+func quotaMiddleware(store *quotaStore, route msconfig.RouteConfig) fiber.Handler {
+	key := quotaKey(route)
+	label := route.Name
+	if label == "" {
+		label = key
+	}
+	quota := route.Quota
+
+	return func(c *fiber.Ctx) error {
+		remaining, retryIn, ok := store.take(key, route.Name, quota, time.Now())
+		c.Set("X-Quota-Limit", strconv.Itoa(quota.Max))
+		c.Set("X-Quota-Remaining", strconv.Itoa(remaining))
+
+		if !ok {
+			if retryIn > 0 {
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int((retryIn+time.Second-1)/time.Second)))
+			}
+			return responseError(c, fiber.StatusTooManyRequests, "QUOTA_EXCEEDED",
+				fmt.Sprintf("Quota of %d requests exhausted for route '%s'", quota.Max, label), false)
+		}
+		return c.Next()
+	}
+}
+
+// quotaResetHandler clears quota counters: ?route=<name> (or "METHOD /path") resets matching routes,
+// no parameter resets all.
+func quotaResetHandler(store *quotaStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		route := c.Query("route")
+		return c.JSON(fiber.Map{
+			"success": true,
+			"route":   route,
+			"reset":   store.reset(route),
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	msconfig "mockserver/config"
+)
+
+// TestQuotaStoreWindow verifies windowed quotas start over once the window has passed.
+func TestQuotaStoreWindow(t *testing.T) {
+	store := newQuotaStore()
+	quota := &msconfig.QuotaConfig{Max: 2, WindowSeconds: 60}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	remaining, _, ok := store.take("GET /route", "Route", quota, start)
+	assert.True(t, ok)
+	assert.Equal(t, 1, remaining)
+
+	_, _, ok = store.take("GET /route", "Route", quota, start.Add(10*time.Second))
+	assert.True(t, ok)
+
+	_, retryIn, ok := store.take("GET /route", "Route", quota, start.Add(20*time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, 40*time.Second, retryIn)
+
+	remaining, _, ok = store.take("GET /route", "Route", quota, start.Add(60*time.Second))
+	assert.True(t, ok, "a new window starts with a fresh counter")
+	assert.Equal(t, 1, remaining)
+
+	assert.Equal(t, 1, store.reset(""))
+	assert.Equal(t, 0, store.reset("Route"))
+}
+
+// TestQuotaKeyPerRoute verifies routes sharing a name keep separate counters and reset together by name.
+func TestQuotaKeyPerRoute(t *testing.T) {
+	list := msconfig.RouteConfig{Name: "Reports", Method: "get", Path: "/reports"}
+	create := msconfig.RouteConfig{Name: "Reports", Method: "POST", Path: "/reports"}
+	assert.Equal(t, "GET /reports", quotaKey(list))
+	assert.NotEqual(t, quotaKey(list), quotaKey(create))
+
+	store := newQuotaStore()
+	quota := &msconfig.QuotaConfig{Max: 1}
+	now := time.Now()
+
+	_, _, ok := store.take(quotaKey(list), list.Name, quota, now)
+	assert.True(t, ok)
+	_, _, ok = store.take(quotaKey(create), create.Name, quota, now)
+	assert.True(t, ok, "a same-named route has its own quota")
+
+	assert.Equal(t, 1, store.reset("POST /reports"))
+	assert.Equal(t, 1, store.reset("Reports"))
+}
+
+// TestQuotaKeyMatchQuery verifies routes told apart by match_query keep separate counters, and a
+// shared counter can be reset by the name of any route using it.
+func TestQuotaKeyMatchQuery(t *testing.T) {
+	all := msconfig.RouteConfig{Name: "All Reports", Method: "GET", Path: "/reports", MatchQuery: map[string]string{"view": "all", "format": "csv"}}
+	mine := msconfig.RouteConfig{Name: "My Reports", Method: "GET", Path: "/reports", MatchQuery: map[string]string{"view": "mine"}}
+	assert.Equal(t, "GET /reports?format=csv&view=all", quotaKey(all))
+	assert.NotEqual(t, quotaKey(all), quotaKey(mine))
+
+	store := newQuotaStore()
+	quota := &msconfig.QuotaConfig{Max: 5}
+	now := time.Now()
+
+	store.take("GET /shared", "First", quota, now)
+	store.take("GET /shared", "Second", quota, now)
+	assert.Equal(t, 1, store.reset("First"), "the first route's name is kept")
+}
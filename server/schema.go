@@ -1,7 +1,9 @@
 package server
 
+import "net/http"
 import "net/url"
 import "regexp"
+import "sync/atomic"
 
 import (
 	msconfig "mockserver/config"
@@ -14,24 +16,62 @@ type MockHandler struct {
 	status       int
 	headers      map[string]string
 	delayMs      int
+	delayPerKb   int
+	malformed    string
+	streamDelay  int
+	abortAfter   int
+	cookies      []msconfig.CookieConfig
+	slow         *slowSampler
+	variants     map[string]interface{}
+	variantQuery bool
+	debugHeaders bool
+	weighted     *weightedPicker
+	sequence     []msconfig.CResponse
+	cycle        bool
+	calls        atomic.Uint64
+	paginated    bool
+	contentType  string
+	untyped      bool // remove_headers drops Content-Type and no route or mock header sets one
 	mockFileData []byte
 	mockBodyData interface{}
 	stateStore   *server_utils.StateStore
+	templates    *server_utils.TemplateEngine
 	routecfg     msconfig.RouteConfig
 }
 
 type FetchHandler struct {
+	routeName         string
+	targetURL         *url.URL
+	method            string
+	headers           map[string]string
+	queryParams       map[string]struct{}
+	fetchQueryParams  map[string]string
+	pathParamsAsQuery map[string]string
+	passStatus        bool
+	delayMs           int
+	timeoutMs         int
+	connectTimeoutMs  int
+	responseTimeoutMs int
+	client            *http.Client
+	urlRegex          *regexp.Regexp
+	basePath          string
+	templates         *server_utils.TemplateEngine
+	requestTransform  map[string]interface{}
+	bodyTemplate      interface{}
+	responseTransform *msconfig.ResponseTransformConfig
+	retry             *fetchRetry
+	errorResponses    map[int]interface{}
+	cache             *fetchCache
+	mask              []msconfig.MaskRule
+	debugHeaders      bool
+}
+
+type EchoHandler struct {
 	routeName string
-	targetURL *url.URL
-	method    string
+	status    int
 	headers   map[string]string
-	queryParams      map[string]struct{}
-	fetchQueryParams map[string]string
-	passStatus       bool
-	delayMs          int
-	timeoutMs        int
-	urlRegex         *regexp.Regexp
-	basePath         string
+	untyped   bool
+	delayMs   int
 }
 
 // ApiError represents a structured API error response.
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// slowSampler picks the fraction of requests that get an extra delay (mock.slow_request_rate).
+// Unlike a fixed delay, most requests stay fast; a seeded sampler slows the same requests on every run.
+type slowSampler struct {
+	mu      sync.Mutex
+	rng     *rand.Rand
+	rate    float64
+	delayMs int
+}
+
+// newSlowSampler returns nil when no request would ever be slowed.
+// A zero seed draws from the current time.
+func newSlowSampler(rate float64, delayMs int, seed int64) *slowSampler {
+	if rate <= 0 || delayMs <= 0 {
+		return nil
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &slowSampler{
+		rng:     rand.New(rand.NewSource(seed)),
+		rate:    rate,
+		delayMs: delayMs,
+	}
+}
+
+// delay returns the extra delay (ms) for the next request: slow_delay_ms or 0.
+func (s *slowSampler) delay() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rng.Float64() < s.rate {
+		return s.delayMs
+	}
+	return 0
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSlowSampler verifies a seeded sampler slows roughly the configured fraction of requests, reproducibly.
+func TestSlowSampler(t *testing.T) {
+	const requests = 10000
+
+	count := func(s *slowSampler) ([]int, int) {
+		seq := make([]int, requests)
+		slowed := 0
+		for i := range seq {
+			seq[i] = s.delay()
+			if seq[i] > 0 {
+				assert.Equal(t, 250, seq[i])
+				slowed++
+			}
+		}
+		return seq, slowed
+	}
+
+	for _, rate := range []float64{0.05, 0.5} {
+		first, slowed := count(newSlowSampler(rate, 250, 42))
+		assert.InDelta(t, rate, float64(slowed)/requests, 0.02, "rate %v", rate)
+
+		second, _ := count(newSlowSampler(rate, 250, 42))
+		assert.Equal(t, first, second, "same seed must slow the same requests")
+	}
+
+	_, slowed := count(newSlowSampler(1, 250, 7))
+	assert.Equal(t, requests, slowed)
+
+	assert.Nil(t, newSlowSampler(0, 250, 1))
+	assert.Nil(t, newSlowSampler(0.5, 0, 1))
+	assert.Equal(t, 0, (*slowSampler)(nil).delay())
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+import (
+	server_utils "mockserver/server/utils"
+)
+
+const (
+	stateEventsBuffer    = 64
+	stateEventsHeartbeat = 15 * time.Second
+)
+
+// stateEventsHandler streams stateful collection changes as Server-Sent Events.
+// Each change is sent as "event: <action>" with the JSON StateChange as data;
+// ?collection= limits the stream to one collection. The stream ends when the
+// client disconnects or the server shuts down.
+func stateEventsHandler(store *server_utils.StateStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		collection := c.Query("collection")
+
+		// Subscribe before the handler returns so no change made after this request is missed
+		events, cancel := store.Subscribe(stateEventsBuffer)
+		shutdown := c.Context().Done()
+
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Set(fiber.HeaderConnection, "keep-alive")
+
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer cancel()
+
+			heartbeat := time.NewTicker(stateEventsHeartbeat)
+			defer heartbeat.Stop()
+
+			w.WriteString(": connected\n\n")
+			if err := w.Flush(); err != nil {
+				return
+			}
+
+			for {
+				select {
+				case change := <-events:
+					if collection != "" && change.Collection != collection {
+						continue
+					}
+					data, err := json.Marshal(change)
+					if err != nil {
+						continue
+					}
+					fmt.Fprintf(w, "event: %s\ndata: %s\n\n", change.Action, data)
+				case <-heartbeat.C:
+					// Comment lines keep proxies from timing out and detect dropped clients
+					w.WriteString(": ping\n\n")
+				case <-shutdown:
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return // client went away
+				}
+			}
+		})
+		return nil
+	}
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+import (
+	server_utils "mockserver/server/utils"
+)
+
+// unsafeFilenameChars strips anything that could break the Content-Disposition header.
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// stateExportHandler serves a stateful collection as a JSON array download,
+// ready to be dropped in as a mock.file or seed fixture.
+func stateExportHandler(store *server_utils.StateStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		collection := c.Query("collection")
+		if collection == "" {
+			return responseError(c, fiber.StatusBadRequest, "MISSING_COLLECTION", "Query parameter 'collection' is required", false)
+		}
+
+		items, ok := store.Snapshot(collection)
+		if !ok {
+			return responseError(c, fiber.StatusNotFound, "COLLECTION_NOT_FOUND", fmt.Sprintf("Collection '%s' not found", collection), false)
+		}
+
+		filename := unsafeFilenameChars.ReplaceAllString(collection, "_") + ".json"
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+		return c.JSON(items)
+	}
+}
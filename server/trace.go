@@ -0,0 +1,93 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+import (
+	msconfig "mockserver/config"
+)
+
+const (
+	// Request header opting into a timing trace (only honored when debug is enabled)
+	traceRequestHeader = "X-Debug-Trace"
+
+	// Response header carrying the trace, Server-Timing style: "context;dur=0.021, cases;dur=0.004, ..."
+	traceResponseHeader = "X-Trace"
+
+	// Standard header carrying the same stages when server.server_timing is enabled
+	serverTimingHeader = "Server-Timing"
+
+	ctxTrace = "__trace"
+)
+
+// traceStage is the time spent in one pipeline stage.
+type traceStage struct {
+	name string
+	dur  time.Duration
+}
+
+// requestTrace records per-stage timings for a single request through createRouteHandler.
+type requestTrace struct {
+	start  time.Time
+	last   time.Time
+	stages []traceStage
+
+	debugHeader  bool // X-Trace requested by the client
+	serverTiming bool // Server-Timing enabled for every response
+}
+
+// startTrace begins a trace when server.server_timing is enabled, or when debug mode is on
+// and the client sent X-Debug-Trace. Returns nil otherwise; all trace helpers are no-ops for untraced requests.
+func startTrace(c *fiber.Ctx, srvCfg msconfig.ServerConfig) *requestTrace {
+	debugHeader := srvCfg.Debug != nil && srvCfg.Debug.Enabled && c.Get(traceRequestHeader) != ""
+	if !debugHeader && !srvCfg.ServerTiming {
+		return nil
+	}
+
+	now := time.Now()
+	trace := &requestTrace{start: now, last: now, debugHeader: debugHeader, serverTiming: srvCfg.ServerTiming}
+	c.Locals(ctxTrace, trace)
+	return trace
+}
+
+// traceMark closes the current stage of the request's trace (if any) under the given name.
+func traceMark(c *fiber.Ctx, stage string) {
+	trace, _ := c.Locals(ctxTrace).(*requestTrace)
+	if trace == nil {
+		return
+	}
+
+	now := time.Now()
+	trace.stages = append(trace.stages, traceStage{name: stage, dur: now.Sub(trace.last)})
+	trace.last = now
+}
+
+// finish writes the recorded stages and the total duration to the X-Trace and/or Server-Timing response headers.
+func (t *requestTrace) finish(c *fiber.Ctx) {
+	if t == nil {
+		return
+	}
+
+	parts := make([]string, 0, len(t.stages)+1)
+	for _, stage := range t.stages {
+		parts = append(parts, stage.name+";dur="+formatTraceMs(stage.dur))
+	}
+	parts = append(parts, "total;dur="+formatTraceMs(time.Since(t.start)))
+	value := strings.Join(parts, ", ")
+	if t.debugHeader {
+		c.Set(traceResponseHeader, value)
+	}
+	if t.serverTiming {
+		c.Set(serverTimingHeader, value)
+	}
+}
+
+// formatTraceMs renders a duration in milliseconds without losing sub-microsecond precision.
+func formatTraceMs(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+}
@@ -1,14 +1,22 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
 )
 
 import (
@@ -17,35 +25,364 @@ import (
 	server_utils "mockserver/server/utils"
 )
 
+// maxDelayMs is the safety limit for artificial delays (10 seconds).
+const maxDelayMs = 10000
+
 // validateDelay ensures the artificial delay does not exceed the safety limit (10 seconds).
 // This prevents configuration errors from causing long-hanging connections.
 func validateDelay(delay int) (int, error) {
-	if delay > 10000 {
-		return 0, fmt.Errorf("delay cannot exceed 10000 ms (10 seconds), got %d", delay)
+	if delay > maxDelayMs {
+		return 0, fmt.Errorf("delay cannot exceed %d ms (10 seconds), got %d", maxDelayMs, delay)
 	}
 	return delay, nil
 }
 
+// jsonArrayItems returns the elements of an array response body (inline or file-based mocks).
+func jsonArrayItems(body interface{}) ([]interface{}, bool) {
+	switch v := body.(type) {
+	case []interface{}:
+		return v, true
+	case []map[string]interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+// streamJSONArray writes items as a single valid JSON array ("[", items separated by ",", "]"),
+// flushing each element and waiting delayMs between elements. Items are encoded up front so
+// encoding errors still produce a regular error response.
+func streamJSONArray(c *fiber.Ctx, items []interface{}, delayMs int) error {
+	encoded := make([][]byte, len(items))
+	for i, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return responseError(c, 500, "MOCK_ENCODE_ERROR", err.Error(), false)
+		}
+		encoded[i] = data
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		w.WriteString("[")
+		for i, data := range encoded {
+			if i > 0 {
+				time.Sleep(time.Duration(delayMs) * time.Millisecond)
+				w.WriteString(",")
+			}
+			w.Write(data)
+			if err := w.Flush(); err != nil {
+				return // client went away
+			}
+		}
+		w.WriteString("]")
+		w.Flush()
+	})
+	return nil
+}
+
+// abortAfterBytes simulates an interrupted response: the headers announce the full body
+// (Content-Length), but only the first n bytes are written before the connection is closed.
+// Bodies no longer than n are sent normally since there is nothing to cut.
+func abortAfterBytes(c *fiber.Ctx, body []byte, n int) error {
+	if n >= len(body) {
+		return c.Send(body)
+	}
+
+	fctx := c.Context()
+	fctx.Response.Header.SetContentLength(len(body))
+	fctx.HijackSetNoResponse(true)
+
+	// Runs after the middleware chain, so headers added on the way out are included
+	fctx.Hijack(func(conn net.Conn) {
+		conn.Write(fctx.Response.Header.Header())
+		conn.Write(body[:n])
+		// fasthttp closes hijacked connections when this returns
+	})
+	return nil
+}
+
+// transformRequestBody merges the processed transform fields into a JSON object body;
+// a null transform value removes the field. Bodies that are not a JSON object pass through unchanged.
+func transformRequestBody(raw []byte, transform map[string]interface{}, templates *server_utils.TemplateEngine, ctx server_utils.EContext) ([]byte, error) {
+	var body map[string]interface{}
+	if err := server_utils.DecodeJSON(raw, &body); err != nil || body == nil {
+		return raw, nil
+	}
+
+	for key, tmpl := range transform {
+		if tmpl == nil {
+			delete(body, key)
+			continue
+		}
+		val, err := templates.Process(tmpl, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("request_transform.%s: %w", key, err)
+		}
+		body[key] = val
+	}
+	return json.Marshal(body)
+}
+
+// renderRequestBody processes fetch.body_template into the upstream request body.
+// String results are sent as-is; other values are encoded as JSON (isJSON reports which).
+func renderRequestBody(tmpl interface{}, templates *server_utils.TemplateEngine, ctx server_utils.EContext) ([]byte, bool, error) {
+	processed, err := templates.Process(tmpl, ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("body_template: %w", err)
+	}
+	if text, ok := processed.(string); ok {
+		return []byte(text), false, nil
+	}
+	encoded, err := json.Marshal(processed)
+	if err != nil {
+		return nil, false, fmt.Errorf("body_template: %w", err)
+	}
+	return encoded, true, nil
+}
+
+// setCookies processes each cookie value as a template and adds the cookie to the response.
+func setCookies(c *fiber.Ctx, cookies []msconfig.CookieConfig, templates *server_utils.TemplateEngine, ctx server_utils.EContext) error {
+	for _, ck := range cookies {
+		value, err := templates.Process(ck.Value, ctx)
+		if err != nil {
+			return fmt.Errorf("cookie '%s': %w", ck.Name, err)
+		}
+
+		path := ck.Path
+		if path == "" {
+			path = "/"
+		}
+		c.Cookie(&fiber.Cookie{
+			Name:     ck.Name,
+			Value:    fmt.Sprint(value),
+			Path:     path,
+			Domain:   ck.Domain,
+			MaxAge:   ck.MaxAge,
+			HTTPOnly: ck.HTTPOnly,
+			Secure:   ck.Secure,
+			SameSite: ck.SameSite,
+		})
+	}
+	return nil
+}
+
+// sizeDelay computes the bandwidth delay (ms) for a payload of the given size at msPerKb.
+// The combined base + size delay is capped at maxDelayMs.
+func sizeDelay(baseDelay, sizeBytes, msPerKb int) int {
+	if msPerKb <= 0 {
+		return 0
+	}
+	extra := sizeBytes * msPerKb / 1024
+	if _, err := validateDelay(baseDelay + extra); err != nil {
+		extra = maxDelayMs - baseDelay
+	}
+	if extra < 0 {
+		return 0
+	}
+	return extra
+}
+
+// resolveMockStatus returns the status a mock answers with: the method-aware default
+// (server.method_default_status, else 200), overridden by the route status, then the mock status.
+func resolveMockStatus(routeCfg msconfig.RouteConfig, methodDefaults map[string]int) int {
+	status := 200
+	if s, ok := methodDefaults[strings.ToUpper(routeCfg.Method)]; ok {
+		status = s
+	}
+	if routeCfg.Status != 0 {
+		status = routeCfg.Status
+	}
+	if routeCfg.Mock != nil && routeCfg.Mock.Status != 0 {
+		status = routeCfg.Mock.Status
+	}
+	return status
+}
+
 // mergeHeaders combines HTTP headers from multiple sources with a specific precedence order:
 // Default Config < Route Config < Custom Overrides.
-// Keys in later maps overwrite keys in earlier maps.
-func mergeHeaders(defaults, routeHeaders, customHeaders map[string]string) map[string]string {
+// Keys in later maps overwrite keys in earlier maps regardless of case.
+// Defaults named in remove (route remove_headers) are dropped before merging.
+func mergeHeaders(defaults, routeHeaders, customHeaders map[string]string, remove []string) map[string]string {
 	headers := make(map[string]string)
 	for k, v := range defaults {
-		headers[k] = v
-	}
-	for k, v := range routeHeaders {
-		headers[k] = v
+		if !containsFold(remove, k) {
+			headers[k] = v
+		}
 	}
-	for k, v := range customHeaders {
-		headers[k] = v
+	for _, overrides := range []map[string]string{routeHeaders, customHeaders} {
+		for k, v := range overrides {
+			for existing := range headers {
+				if strings.EqualFold(existing, k) {
+					delete(headers, existing)
+				}
+			}
+			headers[k] = v
+		}
 	}
 	return headers
 }
 
-func applyDelay(ms int) {
-	if ms > 0 {
-		time.Sleep(time.Duration(ms) * time.Millisecond)
+// removesContentType reports whether the route's remove_headers leaves out Content-Type and the
+// merged headers (and produces) do not set one again.
+func removesContentType(routeCfg msconfig.RouteConfig, headers map[string]string) bool {
+	return containsFold(routeCfg.RemoveHeaders, fiber.HeaderContentType) &&
+		routeCfg.Produces == "" && headerContentType(headers, "") == ""
+}
+
+// stripContentType drops the Content-Type set while writing the body; fasthttp would otherwise
+// fill in its text/plain default.
+func stripContentType(c *fiber.Ctx) {
+	c.Response().Header.Del(fiber.HeaderContentType)
+	c.Response().Header.SetNoDefaultContentType(true)
+}
+
+// containsFold reports whether list contains name, ignoring case.
+func containsFold(list []string, name string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyStatusHeaders sets the server-level headers registered for the final response status.
+// Headers already present on the response (route, case or default headers) are left untouched.
+func applyStatusHeaders(c *fiber.Ctx, statusHeaders map[int]map[string]string) {
+	headers, ok := statusHeaders[c.Response().StatusCode()]
+	if !ok {
+		return
+	}
+	for k, v := range headers {
+		if len(c.Response().Header.Peek(k)) == 0 {
+			c.Set(k, v)
+		}
+	}
+}
+
+// debugStatusParam overrides the response status in debug mode (e.g. ?__status=503)
+const debugStatusParam = "__status"
+
+// applyDebugStatusOverride replaces the response status with ?__status when debug mode is enabled,
+// keeping the body. Invalid values and non-debug servers are ignored.
+func applyDebugStatusOverride(c *fiber.Ctx, debug *msconfig.DebugConfig) {
+	if debug == nil || !debug.Enabled {
+		return
+	}
+	raw := c.Query(debugStatusParam)
+	if raw == "" {
+		return
+	}
+	if status, err := strconv.Atoi(raw); err == nil && status >= 100 && status <= 599 {
+		c.Status(status)
+	}
+}
+
+// variantParam selects one of mock.named_variants (e.g. ?__variant=empty)
+const variantParam = "__variant"
+
+// Debug mode headers naming what served a response: X-Mock-Source is "inline", "file", "variant",
+// "sequence" or "responses" for mocks, "case", "header_variant" or "default" for route-level
+// responses and "cache" or "upstream" for fetch routes; X-Mock-File is the resolved fixture path of file mocks.
+const (
+	mockSourceHeader = "X-Mock-Source"
+	mockFileHeader   = "X-Mock-File"
+)
+
+// setMockSource writes the debug source headers; file is only sent when a fixture served the response.
+func setMockSource(c *fiber.Ctx, enabled bool, source, file string) {
+	if !enabled {
+		return
+	}
+	c.Set(mockSourceHeader, source)
+	if file != "" {
+		c.Set(mockFileHeader, file)
+	}
+}
+
+// sortedKeys returns the map keys in a stable order for error messages.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// caseEvalErrorDetail describes a failing case condition with the route name, case index and 'when' expression.
+func caseEvalErrorDetail(route msconfig.RouteConfig, index int, err error) string {
+	return fmt.Sprintf("route '%s' cases[%d] when \"%s\": %v", route.Name, index, route.Cases[index].When, err)
+}
+
+// isJSONContentType reports whether a content type is JSON (application/json, application/problem+json...).
+func isJSONContentType(contentType string) bool {
+	return strings.Contains(strings.ToLower(contentType), "json")
+}
+
+// isJSONDocument reports whether a request body is a JSON object or array (leading whitespace allowed).
+func isJSONDocument(body []byte) bool {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// headerContentType returns the Content-Type of a response header set (any name case), or fallback.
+func headerContentType(headers map[string]string, fallback string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, fiber.HeaderContentType) {
+			return v
+		}
+	}
+	return fallback
+}
+
+// sendBody writes a processed response body: strings are sent as-is under non-JSON content types
+// (html, xml, csv...), everything else is JSON-encoded.
+func sendBody(c *fiber.Ctx, status int, body interface{}, contentType string) error {
+	c.Status(status)
+	if text, ok := body.(string); ok && !isJSONContentType(contentType) {
+		c.Set(fiber.HeaderContentType, contentType)
+		return c.SendString(text)
+	}
+	return c.JSON(body)
+}
+
+// applyDelay sleeps for ms milliseconds, returning early if ctx is cancelled.
+func applyDelay(ctx context.Context, ms int) {
+	if ms <= 0 {
+		return
+	}
+	select {
+	case <-time.After(time.Duration(ms) * time.Millisecond):
+	case <-ctx.Done():
+	}
+}
+
+// withTimeout bounds a handler by timeoutMs through the request's user context.
+// Delays, upstream calls, state writes and template rendering abort once the deadline passes; any response produced
+// after it (status, headers, cookies and body) is replaced by a 504.
+func withTimeout(next fiber.Handler, timeoutMs int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		timeCtx, cancel := context.WithTimeout(c.UserContext(), time.Duration(timeoutMs)*time.Millisecond)
+		defer cancel()
+		c.SetUserContext(timeCtx)
+
+		// Headers set by earlier middleware (e.g. CORS) must survive the replacement
+		var before fasthttp.ResponseHeader
+		c.Response().Header.CopyTo(&before)
+
+		err := next(c)
+		if errors.Is(timeCtx.Err(), context.DeadlineExceeded) {
+			before.CopyTo(&c.Response().Header)
+			c.Response().ResetBody()
+			return responseError(c, fiber.StatusGatewayTimeout, "ROUTE_TIMEOUT_ERROR",
+				fmt.Sprintf("Request exceeded timeout of %d ms", timeoutMs), false)
+		}
+		return err
 	}
 }
 
@@ -70,29 +407,165 @@ func buildQuery(c *fiber.Ctx) map[string]string {
 	return q
 }
 
-// shouldParseBody determines if the HTTP method typically supports a request body.
-func shouldParseBody(c *fiber.Ctx) bool {
-	switch c.Method() {
+// isBodyMethod reports whether the HTTP method typically carries a request body.
+func isBodyMethod(method string) bool {
+	switch method {
 	case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch:
-		return len(c.Body()) > 0
+		return true
 	default:
 		return false
 	}
 }
 
+// shouldParseBody determines if the HTTP method typically supports a request body.
+func shouldParseBody(c *fiber.Ctx) bool {
+	return isBodyMethod(c.Method()) && len(c.Body()) > 0
+}
+
+// isStatefulWrite reports whether the route writes request bodies to the state store; their
+// body_schema is checked once, before the write, instead of in the mock handler.
+func isStatefulWrite(route msconfig.RouteConfig) bool {
+	if route.Stateful == nil {
+		return false
+	}
+	switch route.Stateful.Action {
+	case "create", "update", "patch":
+		return true
+	}
+	return false
+}
+
+// partialSchema returns a copy of schema without required fields at any object level, for
+// patch bodies that only carry the fields being changed. Array items are kept as they are,
+// since a patched array replaces the stored one.
+func partialSchema(schema *msconfig.JSONSchema) *msconfig.JSONSchema {
+	if schema == nil {
+		return nil
+	}
+	partial := *schema
+	partial.Required = nil
+	if len(schema.Properties) > 0 {
+		partial.Properties = make(map[string]*msconfig.JSONSchema, len(schema.Properties))
+		for name, prop := range schema.Properties {
+			partial.Properties[name] = partialSchema(prop)
+		}
+	}
+	return &partial
+}
+
+// validateBodySchema checks a request body against body_schema. Merge patches use null to delete
+// fields, so nulls are not validated against the schema.
+func validateBodySchema(schema *msconfig.JSONSchema, body map[string]interface{}, ctx *server_utils.EContext) error {
+	var input interface{} = body
+	if server_utils.IsMergePatch(ctx) {
+		input = stripNulls(body)
+	}
+	return server_utils.ValidateJSONSchema(schema, input, "request.body")
+}
+
+// withoutKeys returns a copy of the map without the given keys.
+func withoutKeys(m map[string]string, keys map[string]bool) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		if !keys[k] {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// stripNulls returns a copy of the object without null-valued fields (recursively).
+func stripNulls(obj map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		switch t := v.(type) {
+		case nil:
+			continue
+		case map[string]interface{}:
+			out[k] = stripNulls(t)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// describeJSONError converts JSON decoding errors into a client-friendly message that points
+// at the offending position (line, column and byte offset) within the raw body.
+// Returns false if the error is not a JSON syntax/type error.
+func describeJSONError(raw []byte, err error) (string, bool) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, col := offsetToLineCol(raw, syntaxErr.Offset)
+		return fmt.Sprintf("malformed JSON at line %d, column %d (offset %d): %s",
+			line, col, syntaxErr.Offset, syntaxErr.Error()), true
+
+	case errors.As(err, &typeErr):
+		line, col := offsetToLineCol(raw, typeErr.Offset)
+		field := typeErr.Field
+		if field == "" {
+			field = "root"
+		}
+		return fmt.Sprintf("invalid JSON value for '%s' at line %d, column %d (offset %d): expected %s, got %s",
+			field, line, col, typeErr.Offset, typeErr.Type, typeErr.Value), true
+	}
+
+	return "", false
+}
+
+// offsetToLineCol translates a byte offset into 1-based line and column numbers.
+func offsetToLineCol(raw []byte, offset int64) (int, int) {
+	if offset > int64(len(raw)) {
+		offset = int64(len(raw))
+	}
+	line, col := 1, 1
+	for _, b := range raw[:offset] {
+		if b == '\n' {
+			line++
+			col = 1
+			continue
+		}
+		col++
+	}
+	return line, col
+}
+
+// newTemplateEngine builds the template engine configured by the server settings.
+func newTemplateEngine(srvCfg msconfig.ServerConfig) *server_utils.TemplateEngine {
+	opts := server_utils.TemplateOptions{
+		KeepMissingRefs: srvCfg.TemplateMissingRefs == "keep",
+		Lenient:         srvCfg.TemplateErrorMode == "lenient",
+		Globals:         globalVariables,
+		MaxNodes:        srvCfg.MaxTemplateNodes,
+	}
+	if frozen, ok := srvCfg.FrozenNow(); ok {
+		opts.FrozenTime = frozen
+	}
+	if len(srvCfg.TemplateDelimiters) == 2 {
+		opts.OpenDelim = srvCfg.TemplateDelimiters[0]
+		opts.CloseDelim = srvCfg.TemplateDelimiters[1]
+	}
+	return server_utils.NewTemplateEngine(opts)
+}
+
 // parseAndFilterMockData processes raw JSON templates and applies filtering logic.
 // 1. Unmarshals raw bytes into a generic interface.
 // 2. Executes template substitution (e.g., {{fake.Name}}).
-// 3. Normalizes single objects into a slice of objects.
-// 4. Applies query parameter filtering to the result set.
-func parseAndFilterMockData(data []byte, ctx server_utils.EContext, params map[string]string) ([]map[string]interface{}, error) {
+// 3. Returns a top-level object as-is when no filter parameters are present.
+// 4. Otherwise normalizes single objects into a slice of objects.
+// 5. Applies query parameter filtering to the result set.
+func parseAndFilterMockData(templates *server_utils.TemplateEngine, data []byte, ctx server_utils.EContext, params map[string]string) (interface{}, error) {
 
+	// Numbers stay json.Number so large ids in fixtures are served exactly as written
 	var rawData interface{}
-	if err := json.Unmarshal(data, &rawData); err != nil {
+	if err := server_utils.DecodeJSON(data, &rawData); err != nil {
 		return nil, fmt.Errorf("invalid JSON format: %w", err)
 	}
 
-	processed, err := server_utils.ProcessTemplateJSON(rawData, ctx)
+	processed, err := templates.Process(rawData, ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to process template JSON: %w", err)
 	}
@@ -103,6 +576,10 @@ func parseAndFilterMockData(data []byte, ctx server_utils.EContext, params map[s
 	case []interface{}:
 		arr = v
 	case map[string]interface{}:
+		// A single-object fixture is a plain response unless it is being filtered
+		if !server_utils.HasFilterParams(params) {
+			return v, nil
+		}
 		// Wrap single object in array
 		arr = []interface{}{v}
 	default:
@@ -126,10 +603,30 @@ func parseAndFilterMockData(data []byte, ctx server_utils.EContext, params map[s
 	return filtered, nil
 }
 
+// pageItems returns the objects of an array body for mock.paginated; ok is false for non-array bodies,
+// which are sent without an envelope.
+func pageItems(body interface{}) ([]map[string]interface{}, bool, error) {
+	switch v := body.(type) {
+	case []map[string]interface{}:
+		return v, true, nil
+	case []interface{}:
+		items := make([]map[string]interface{}, 0, len(v))
+		for _, item := range v {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, false, fmt.Errorf("paginated mock array items must be objects")
+			}
+			items = append(items, m)
+		}
+		return items, true, nil
+	}
+	return nil, false, nil
+}
+
 // buildTargetURL constructs the final upstream URL for proxy requests.
-// It handles path parameter substitution (e.g., {id} -> 123) and merges
-// client query parameters with configured overrides.
-func buildTargetURL(base *url.URL, pathParams, clientQuery map[string]string, acceptedQueryParams map[string]struct{}, fetchQueryParams map[string]string) string {
+// It handles path parameter substitution (e.g., {id} -> 123), moves mapped path params
+// into the query (e.g., id -> ?userId=123) and merges client query parameters with configured overrides.
+func buildTargetURL(base *url.URL, pathParams, clientQuery map[string]string, acceptedQueryParams map[string]struct{}, fetchQueryParams, pathParamsAsQuery map[string]string) string {
 	target := *base
 
 	// Path Parameter Substitution
@@ -147,6 +644,12 @@ func buildTargetURL(base *url.URL, pathParams, clientQuery map[string]string, ac
 		}
 	}
 
+	for param, queryKey := range pathParamsAsQuery {
+		if v, ok := pathParams[param]; ok {
+			q.Set(queryKey, v)
+		}
+	}
+
 	for k, v := range fetchQueryParams {
 		q.Set(k, v)
 	}
@@ -164,7 +667,7 @@ func responseError(c *fiber.Ctx, status int, errCode, message string, returnObje
 		Err:       http.StatusText(status),
 		ErrorCode: errCode,
 		Message:   message,
-		Timestamp: time.Now().UTC().UnixNano() / 1e6,
+		Timestamp: requestClock(c)().UTC().UnixNano() / 1e6,
 	}
 
 	err := c.Status(status).JSON(apiErr)
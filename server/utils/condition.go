@@ -0,0 +1,256 @@
+package server_utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// condToken is a lexical unit of a case condition.
+type condToken struct {
+	kind string // "(", ")", "and", "or", "not", "cmp"
+	text string
+}
+
+// condNode is a node of a parsed condition: a logical operator or a single comparison.
+type condNode struct {
+	op       string // "and", "or", "not", "cmp"
+	children []*condNode
+	cmp      string
+}
+
+// parseCondition builds the parse tree of a condition.
+// Precedence from lowest to highest: OR, AND, NOT, parenthesized groups / comparisons.
+func parseCondition(expr string) (*condNode, error) {
+	tokens, err := tokenizeCondition(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+
+	p := &condParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%s' in condition", p.tokens[p.pos].text)
+	}
+	return node, nil
+}
+
+// tokenizeCondition splits a condition into logical operators, parentheses and comparisons.
+// Quoted strings, list literals and call parentheses such as type(...) stay inside their comparison.
+func tokenizeCondition(expr string) ([]condToken, error) {
+	var tokens []condToken
+	var cmp strings.Builder
+	callDepth := 0
+
+	flush := func() {
+		if text := strings.TrimSpace(cmp.String()); text != "" {
+			tokens = append(tokens, condToken{kind: "cmp", text: text})
+		}
+		cmp.Reset()
+	}
+	emit := func(kind, text string) {
+		flush()
+		tokens = append(tokens, condToken{kind: kind, text: text})
+	}
+
+	for i := 0; i < len(expr); {
+		ch := expr[i]
+		switch {
+		case ch == '\'' || ch == '"':
+			end := strings.IndexByte(expr[i+1:], ch)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in condition")
+			}
+			cmp.WriteString(expr[i : i+end+2])
+			i += end + 2
+
+		case ch == '(':
+			// A parenthesis right after an identifier is a call, e.g. type(request.body.x)
+			if callDepth > 0 || endsWithIdent(cmp.String()) {
+				callDepth++
+				cmp.WriteByte(ch)
+			} else {
+				emit("(", "(")
+			}
+			i++
+
+		case ch == ')':
+			if callDepth > 0 {
+				callDepth--
+				cmp.WriteByte(ch)
+			} else {
+				emit(")", ")")
+			}
+			i++
+
+		case callDepth == 0 && strings.HasPrefix(expr[i:], "&&"):
+			emit("and", "&&")
+			i += 2
+
+		case callDepth == 0 && strings.HasPrefix(expr[i:], "||"):
+			emit("or", "||")
+			i += 2
+
+		case callDepth == 0 && ch == '!' && !strings.HasPrefix(expr[i:], "!="):
+			emit("not", "!")
+			i++
+
+		case isCondWordChar(ch) && (i == 0 || !isCondWordChar(expr[i-1])):
+			end := i
+			for end < len(expr) && isCondWordChar(expr[end]) {
+				end++
+			}
+			word := expr[i:end]
+			if kind := strings.ToLower(word); callDepth == 0 && (kind == "and" || kind == "or" || kind == "not") {
+				emit(kind, word)
+			} else {
+				cmp.WriteString(word)
+			}
+			i = end
+
+		default:
+			cmp.WriteByte(ch)
+			i++
+		}
+	}
+
+	if callDepth > 0 {
+		return nil, fmt.Errorf("missing closing parenthesis")
+	}
+	flush()
+	return tokens, nil
+}
+
+// isCondWordChar reports whether ch belongs to a word (keyword or reference such as request.headers.x-id).
+func isCondWordChar(ch byte) bool {
+	return isExprIdentChar(ch) || ch == '-'
+}
+
+func endsWithIdent(s string) bool {
+	return s != "" && isExprIdentChar(s[len(s)-1])
+}
+
+// condParser is a recursive-descent parser producing a condNode tree.
+type condParser struct {
+	tokens []condToken
+	pos    int
+}
+
+func (p *condParser) peek(kind string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == kind
+}
+
+// parseOr handles OR / || (lowest precedence).
+func (p *condParser) parseOr() (*condNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &condNode{op: "or", children: []*condNode{left, right}}
+	}
+	return left, nil
+}
+
+// parseAnd handles AND / &&.
+func (p *condParser) parseAnd() (*condNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("and") {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &condNode{op: "and", children: []*condNode{left, right}}
+	}
+	return left, nil
+}
+
+// parseNot handles the unary NOT / ! prefix (repeatable, e.g. NOT NOT x).
+func (p *condParser) parseNot() (*condNode, error) {
+	if p.peek("not") {
+		p.pos++
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &condNode{op: "not", children: []*condNode{operand}}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary handles parenthesized groups and single comparisons.
+func (p *condParser) parsePrimary() (*condNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of condition")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	switch tok.kind {
+	case "cmp":
+		return &condNode{op: "cmp", cmp: tok.text}, nil
+
+	case "(":
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(")") {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return node, nil
+
+	default:
+		return nil, fmt.Errorf("unexpected '%s' in condition", tok.text)
+	}
+}
+
+// eval evaluates the tree against the request context. AND / OR short-circuit,
+// so comparisons that are not needed are never resolved.
+func (n *condNode) eval(ctx EContext) (bool, error) {
+	switch n.op {
+	case "and":
+		for _, child := range n.children {
+			ok, err := child.eval(ctx)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case "or":
+		for _, child := range n.children {
+			ok, err := child.eval(ctx)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+
+	case "not":
+		ok, err := n.children[0].eval(ctx)
+		return !ok && err == nil, err
+
+	default:
+		ok, err := evalSingleCondition(n.cmp, ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed evaluating '%s': %w", n.cmp, err)
+		}
+		return ok, nil
+	}
+}
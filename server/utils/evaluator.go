@@ -1,53 +1,50 @@
 package server_utils
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// inOperatorRegex matches membership checks such as
+// "request.body.role in ['admin','editor']" or "request.body.role in request.headers.x-roles".
+var inOperatorRegex = regexp.MustCompile(`^([^\s'"]+)\s+in\s+(.+)$`)
+
+// containsOperatorRegex matches substring / array membership checks such as
+// "request.headers.accept contains 'json'" or "request.body.tags contains 'sale'".
+var containsOperatorRegex = regexp.MustCompile(`^([^\s'"]+)\s+contains\s+(.+)$`)
+
 // EvaluateCondition parses and executes boolean expressions against the request context.
-// Supports logical operators (AND, OR) and grouping.
+// Supports logical operators (AND / &&, OR / ||), unary NOT / ! and parenthesized grouping,
+// e.g. "NOT (request.body.age < 18) AND request.query.type == 'adult'".
 func EvaluateCondition(expr string, ctx EContext) (bool, error) {
 	expr = strings.TrimSpace(expr)
 	if expr == "" {
 		return false, errors.New("empty condition")
 	}
 
-	// Normalize logical operators
-	expr = strings.ReplaceAll(expr, "&&", " AND ")
-	expr = strings.ReplaceAll(expr, "||", " OR ")
-	expr = strings.ReplaceAll(expr, " and ", " AND ")
-	expr = strings.ReplaceAll(expr, " or ", " OR ")
-
-	// Split OR
-	orParts := splitLogical(expr, "OR")
-	for _, orPart := range orParts {
-		andOK := true
-		for _, andPart := range splitLogical(orPart, "AND") {
-			ok, err := evalSingleCondition(strings.TrimSpace(andPart), ctx)
-			if err != nil {
-				return false, fmt.Errorf("failed evaluating '%s': %w", andPart, err)
-			}
-			if !ok {
-				andOK = false
-				break
-			}
-		}
-		if andOK {
-			return true, nil
-		}
+	tree, err := parseCondition(expr)
+	if err != nil {
+		return false, fmt.Errorf("invalid condition '%s': %w", expr, err)
 	}
-	return false, nil
+	return tree.eval(ctx)
 }
 
-func splitLogical(expr, op string) []string {
-	return strings.Split(expr, " "+op+" ")
-}
-
-// evalSingleCondition parses a binary comparison (e.g., "a > b") or a type check.
+// evalSingleCondition parses a binary comparison (e.g., "a > b"), a membership check ("a in b"),
+// a containment check ("a contains b") or a type check.
 func evalSingleCondition(cond string, ctx EContext) (bool, error) {
+	if m := inOperatorRegex.FindStringSubmatch(cond); m != nil {
+		return evalMembership(m[1], strings.TrimSpace(m[2]), ctx)
+	}
+	if m := containsOperatorRegex.FindStringSubmatch(cond); m != nil {
+		return evalContains(m[1], strings.TrimSpace(m[2]), ctx)
+	}
+
 	ops := []string{"==", "!=", "<=", ">=", "<", ">"}
 
 	var op string
@@ -98,6 +95,96 @@ func evalSingleCondition(cond string, ctx EContext) (bool, error) {
 	return evalCompareValues(leftVal, rightVal, op)
 }
 
+// evalMembership checks whether the left reference is contained in the right-hand list.
+// The list is either a literal array (['a', 'b'] / [1, 2]) or a request reference resolving to
+// a JSON array or a comma-separated string (e.g., a header "admin, editor").
+func evalMembership(left, right string, ctx EContext) (bool, error) {
+	leftVal, err := evalResolveValue(left, ctx)
+	if err != nil {
+		return false, fmt.Errorf("left value error: %w", err)
+	}
+
+	list, err := evalResolveList(right, ctx)
+	if err != nil {
+		return false, fmt.Errorf("right value error: %w", err)
+	}
+
+	return evalCompareValues(leftVal, list, "in")
+}
+
+// evalContains checks whether the left reference contains the right-hand value: a substring for
+// strings, an element for arrays. The right side is a literal or a request reference.
+func evalContains(left, right string, ctx EContext) (bool, error) {
+	leftVal, err := evalResolveValue(left, ctx)
+	if err != nil {
+		return false, fmt.Errorf("left value error: %w", err)
+	}
+
+	rightVal, err := evalParseLiteral(right)
+	if err != nil {
+		if rightVal, err = evalResolveValue(right, ctx); err != nil {
+			return false, fmt.Errorf("right value error: %w", err)
+		}
+	}
+
+	return evalCompareValues(leftVal, rightVal, "contains")
+}
+
+// evalResolveList turns the right-hand side of an 'in' expression into a slice of values.
+func evalResolveList(expr string, ctx EContext) ([]interface{}, error) {
+	if strings.HasPrefix(expr, "[") {
+		val, err := evalParseLiteral(expr)
+		if err != nil {
+			return nil, err
+		}
+		return val.([]interface{}), nil
+	}
+
+	val, err := evalResolveValue(expr, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := val.(type) {
+	case []interface{}:
+		return v, nil
+	case string:
+		var list []interface{}
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				list = append(list, part)
+			}
+		}
+		return list, nil
+	default:
+		return []interface{}{v}, nil
+	}
+}
+
+// splitListLiteral splits the inner part of an array literal on commas outside of quotes.
+func splitListLiteral(inner string) []string {
+	var parts []string
+	var current strings.Builder
+	inQuote := false
+
+	for _, r := range inner {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+			current.WriteRune(r)
+		case r == ',' && !inQuote:
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if strings.TrimSpace(current.String()) != "" {
+		parts = append(parts, current.String())
+	}
+	return parts
+}
+
 func evalTypeCheck(value interface{}, expectedType string, operator string) (bool, error) {
 	var actualType string
 
@@ -109,7 +196,7 @@ func evalTypeCheck(value interface{}, expectedType string, operator string) (boo
 		} else {
 			actualType = "string"
 		}
-	case float64, int:
+	case float64, int, json.Number:
 		actualType = "number"
 	case bool:
 		actualType = "boolean"
@@ -141,6 +228,19 @@ func evalParseLiteral(val string) (interface{}, error) {
 		return val[1 : len(val)-1], nil
 	}
 
+	// array (e.g. ['US', 'CA'] or [1, 2])
+	if strings.HasPrefix(val, "[") && strings.HasSuffix(val, "]") {
+		list := []interface{}{}
+		for _, raw := range splitListLiteral(val[1 : len(val)-1]) {
+			item, err := evalParseLiteral(raw)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	}
+
 	// boolean
 	if val == "true" {
 		return true, nil
@@ -149,17 +249,30 @@ func evalParseLiteral(val string) (interface{}, error) {
 		return false, nil
 	}
 
-	// number
-	if n, err := strconv.ParseFloat(val, 64); err == nil {
-		return n, nil
+	// number (kept as json.Number so large integers compare exactly)
+	if _, err := strconv.ParseFloat(val, 64); err == nil {
+		return json.Number(val), nil
 	}
 
 	return nil, fmt.Errorf("invalid literal value: '%s'", val)
 }
 
 // evalResolveValue extracts data from the EContext using dot notation (e.g., request.body.id).
-// Supports scopes: body, query, headers, path.
+// Supports scopes: body, query, headers, path, request.raw_body and request.proto. The "time." namespace resolves against ctx.Now.
+// Body references walk nested objects (request.body.user.address.city) and array indexes (request.body.items.0.sku);
+// query, header and path keys are flat, so any dots after the scope belong to the key.
 func evalResolveValue(path string, ctx EContext) (interface{}, error) {
+	if strings.HasPrefix(path, "time.") {
+		return evalResolveTime(strings.TrimPrefix(path, "time."), ctx.now())
+	}
+
+	if path == "request.raw_body" {
+		return ctx.RawBody, nil
+	}
+	if path == "request.proto" {
+		return ctx.Proto, nil
+	}
+
 	if !strings.HasPrefix(path, "request.") {
 		return nil, fmt.Errorf("invalid reference (must start with 'request.'): '%s'", path)
 	}
@@ -170,105 +283,130 @@ func evalResolveValue(path string, ctx EContext) (interface{}, error) {
 	}
 
 	scope := parts[1]
-	key := parts[2]
-
-	var val interface{}
-	var ok bool
+	key := strings.Join(parts[2:], ".")
 
 	switch scope {
 	case "body":
-		for k, v := range ctx.Body {
-			if strings.EqualFold(k, key) { // case-insensitive match
-				val = v
-				ok = true
-				break
-			}
+		// Body keys match exactly first, then case-insensitively
+		if val, ok := lookupJSONPath(ctx.Body, parts[2:], true); ok {
+			return val, nil
 		}
-		if !ok {
-			return nil, fmt.Errorf("body key '%s' not found", key)
-		}
-		return val, nil
+		return nil, fmt.Errorf("body key '%s' not found", key)
 
 	case "query":
-		for k, v := range ctx.Query {
-			if strings.EqualFold(k, key) {
-				val = v
-				ok = true
-				break
-			}
+		if val, ok := evalLookupFold(ctx.Query, key); ok {
+			return val, nil
 		}
-		if !ok {
-			return nil, fmt.Errorf("query key '%s' not found", key)
-		}
-		return val, nil
+		return nil, fmt.Errorf("query key '%s' not found", key)
 
 	case "headers":
-		for k, v := range ctx.Headers {
-			if strings.EqualFold(k, key) {
-				val = v
-				ok = true
-				break
-			}
+		if val, ok := evalLookupFold(ctx.Headers, key); ok {
+			return val, nil
 		}
-		if !ok {
-			return nil, fmt.Errorf("header key '%s' not found", key)
-		}
-		return val, nil
+		return nil, fmt.Errorf("header key '%s' not found", key)
 
 	case "path":
-		for k, v := range ctx.Path {
-			if strings.EqualFold(k, key) {
-				val = v
-				ok = true
-				break
-			}
+		if val, ok := evalLookupFold(ctx.Path, key); ok {
+			return val, nil
 		}
-		if !ok {
-			return nil, fmt.Errorf("path key '%s' not found", key)
-		}
-		return val, nil
+		return nil, fmt.Errorf("path key '%s' not found", key)
 
 	default:
 		return nil, fmt.Errorf("unknown request scope: '%s'", scope)
 	}
 }
 
+// evalLookupFold finds a flat request value (query, header or path param) by case-insensitive key.
+func evalLookupFold(values map[string]string, key string) (string, bool) {
+	for k, v := range values {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// evalResolveTime exposes the current time to conditions, e.g.
+// "time.hour >= 9 AND time.hour < 17" or "time.weekday == 'saturday'".
+func evalResolveTime(field string, now time.Time) (interface{}, error) {
+	switch field {
+	case "hour":
+		return float64(now.Hour()), nil
+	case "minute":
+		return float64(now.Minute()), nil
+	case "weekday":
+		return strings.ToLower(now.Weekday().String()), nil
+	case "weekday_num":
+		return float64(now.Weekday()), nil
+	case "day":
+		return float64(now.Day()), nil
+	case "month":
+		return float64(now.Month()), nil
+	case "year":
+		return float64(now.Year()), nil
+	case "unix":
+		return float64(now.Unix()), nil
+	default:
+		return nil, fmt.Errorf("unknown time field: '%s'", field)
+	}
+}
+
 // evalCompareValues performs the actual comparison logic with automatic type coercion.
 func evalCompareValues(a interface{}, b interface{}, op string) (bool, error) {
 	if a == nil || b == nil {
 		return false, fmt.Errorf("cannot compare nil values: a=%v, b=%v", a, b)
 	}
 
-	// Helper: Coerces any numeric-like value (int, string-number) to float64
-	convertToFloat := func(val interface{}) (float64, bool) {
-		switch t := val.(type) {
-		case float64:
-			return t, true
-		case int:
-			return float64(t), true
+	switch op {
+	case "in":
+		list, ok := b.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("'in' requires a list on the right, got %T", b)
+		}
+		for _, item := range list {
+			// Type mismatches simply do not match
+			if ok, err := evalCompareValues(a, item, "=="); err == nil && ok {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case "contains":
+		switch av := a.(type) {
 		case string:
-			if f, err := strconv.ParseFloat(t, 64); err == nil {
-				return f, true
+			return strings.Contains(av, exprToString(b)), nil
+		case []interface{}:
+			return evalCompareValues(b, av, "in")
+		default:
+			return false, fmt.Errorf("'contains' requires a string or array on the left, got %T", a)
+		}
+	}
+
+	// Helper: Coerces any numeric-like value (int, float64, json.Number, string-number) to an exact rational
+	convertToNumber := func(val interface{}) (*big.Rat, bool) {
+		if s, ok := val.(string); ok {
+			if _, err := strconv.ParseFloat(s, 64); err != nil {
+				return nil, false
 			}
 		}
-		return 0, false
+		return toRat(val)
 	}
 
 	switch av := a.(type) {
-	// Numeric Comparison (includes int & float64)
-	case float64, int:
-		af, _ := convertToFloat(av)
-		bf, bok := convertToFloat(b)
-		if !bok {
+	// Numeric Comparison (includes int, float64 & json.Number)
+	case float64, int, json.Number:
+		an, aok := convertToNumber(av)
+		bn, bok := convertToNumber(b)
+		if !aok || !bok {
 			return false, fmt.Errorf("type mismatch: left numeric, right %T", b)
 		}
-		return compareFloats(af, bf, op)
+		return compareNumbers(an, bn, op)
 
 	case string:
 
-		if af, aok := convertToFloat(av); aok {
-			if bf, bok := convertToFloat(b); bok {
-				return compareFloats(af, bf, op)
+		if an, aok := convertToNumber(av); aok {
+			if bn, bok := convertToNumber(b); bok {
+				return compareNumbers(an, bn, op)
 
 			}
 		}
@@ -307,21 +445,22 @@ func evalCompareValues(a interface{}, b interface{}, op string) (bool, error) {
 	// return false, fmt.Errorf("unsupported comparison types: %T %T with operator '%s'", a, b, op) [OLD]
 }
 
-// Helper to reduce duplicated switch cases
-func compareFloats(a, b float64, op string) (bool, error) {
+// Helper to reduce duplicated switch cases; compares exactly so large integer ids do not collapse.
+func compareNumbers(a, b *big.Rat, op string) (bool, error) {
+	cmp := a.Cmp(b)
 	switch op {
 	case ">":
-		return a > b, nil
+		return cmp > 0, nil
 	case ">=":
-		return a >= b, nil
+		return cmp >= 0, nil
 	case "<":
-		return a < b, nil
+		return cmp < 0, nil
 	case "<=":
-		return a <= b, nil
+		return cmp <= 0, nil
 	case "==":
-		return a == b, nil
+		return cmp == 0, nil
 	case "!=":
-		return a != b, nil
+		return cmp != 0, nil
 	}
 	return false, fmt.Errorf("unsupported comparison types: %T %T with operator '%s'", a, b, op)
 }
@@ -2,6 +2,7 @@ package server_utils
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -112,6 +113,49 @@ func TestEvaluateCondition_Logic(t *testing.T) {
 	}
 }
 
+// TestEvaluateCondition_Grouping verifies NOT / ! and parenthesized groups, which
+// override the default AND-before-OR precedence.
+func TestEvaluateCondition_Grouping(t *testing.T) {
+	ctx := helperContext()
+
+	tests := []struct {
+		name      string
+		expr      string
+		want      bool
+		expectErr bool
+	}{
+		{"NOT Group", "NOT (request.body.age < 18) AND request.query.search == 'laptop'", true, false},
+		{"Bang Prefix", "!(request.body.role == 'guest')", true, false},
+		{"Lowercase not", "not request.body.active == true", false, false},
+		{"Double Negation", "NOT NOT request.body.active == true", true, false},
+		{"Double Bang", "!!(request.body.age > 18)", true, false},
+		{"Group Overrides Precedence", "request.body.role == 'guest' AND (request.body.age > 18 OR request.body.active == true)", false, false},
+		{"Without Group", "request.body.role == 'guest' AND request.body.age > 18 OR request.body.active == true", true, false},
+		{"Nested Groups", "((request.body.age > 18 AND NOT (request.body.role == 'guest')) OR request.query.page == '99') && request.path.id == '101'", true, false},
+		{"Negated Nested Group", "NOT ((request.body.age > 18) || (request.body.price > 100))", false, false},
+		{"Type Call Inside Group", "(type(request.body.age) == 'number' AND NOT type(request.body.role) == 'number')", true, false},
+		{"Operators Inside Quotes", "request.query.search != 'rock and (roll)'", true, false},
+		{"Short Circuit Skips Missing Key", "request.body.active == true OR (request.body.missing == 1)", true, false},
+
+		{"Unbalanced Open", "(request.body.age > 18", false, true},
+		{"Unbalanced Close", "request.body.age > 18)", false, true},
+		{"Dangling NOT", "request.body.age > 18 AND NOT", false, true},
+		{"Empty Group", "()", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateCondition(tt.expr, ctx)
+			if tt.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got, "Expression: %s", tt.expr)
+			}
+		})
+	}
+}
+
 // TestEvaluateCondition_TypeCoercion ensures that the system is smart enough
 // to compare a string number ("50") with a real number (50).
 func TestEvaluateCondition_TypeCoercion(t *testing.T) {
@@ -137,6 +181,35 @@ func TestEvaluateCondition_TypeCoercion(t *testing.T) {
 	}
 }
 
+// TestEvaluateCondition_LargeIntegers ensures ids decoded as json.Number are compared exactly,
+// not after rounding through float64.
+func TestEvaluateCondition_LargeIntegers(t *testing.T) {
+	var body map[string]interface{}
+	require.NoError(t, DecodeJSON([]byte(`{"id": 12345678901234567891, "ratio": 0.1}`), &body))
+	ctx := EContext{Body: body, Path: map[string]string{"id": "12345678901234567891"}}
+
+	tests := []struct {
+		name string
+		expr string
+		want bool
+	}{
+		{"Exact Match", "request.body.id == 12345678901234567891", true},
+		{"Neighbour Differs", "request.body.id == 12345678901234567890", false},
+		{"Greater Than Neighbour", "request.body.id > 12345678901234567890", true},
+		{"Path String vs Number", "request.path.id == 12345678901234567891", true},
+		{"Decimal", "request.body.ratio == 0.1", true},
+		{"Type Check", "type(request.body.id) == 'number'", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateCondition(tt.expr, ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 // TestEvaluateCondition_TypeCheck verifies the special `type()` function logic.
 func TestEvaluateCondition_TypeCheck(t *testing.T) {
 	ctx := helperContext()
@@ -198,3 +271,184 @@ func TestEvaluateCondition_EdgeCases(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+// TestEvaluateCondition_InOperator verifies membership checks against literal arrays
+// and lists sourced from the request context (JSON arrays and comma-separated headers).
+func TestEvaluateCondition_InOperator(t *testing.T) {
+	ctx := helperContext()
+	ctx.Headers["x-allowed-roles"] = "editor, admin"
+	ctx.Headers["x-allowed-ids"] = "7,25,40"
+	ctx.Body["tags"] = []interface{}{"new", "sale"}
+	ctx.Body["tag"] = "sale"
+
+	tests := []struct {
+		name      string
+		expr      string
+		want      bool
+		expectErr bool
+	}{
+		// Literal arrays
+		{"Literal String Match", "request.body.role in ['admin', 'editor']", true, false},
+		{"Literal String Miss", "request.body.role in ['guest']", false, false},
+		{"Literal Number Match", "request.body.age in [18, 25, 30]", true, false},
+
+		// Context-sourced lists
+		{"Header Comma List Match", "request.body.role in request.headers.x-allowed-roles", true, false},
+		{"Header Comma List Number Match", "request.body.age in request.headers.x-allowed-ids", true, false},
+		{"Body Array Match", "request.body.tag in request.body.tags", true, false},
+		{"Body Array Miss", "request.query.search in request.body.tags", false, false},
+
+		// Combined with logical operators
+		{"In With AND", "request.body.role in request.headers.x-allowed-roles AND request.body.active == true", true, false},
+
+		// Errors
+		{"Missing List Reference", "request.body.role in request.headers.x-missing", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateCondition(tt.expr, ctx)
+			if tt.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got, "Expression: %s", tt.expr)
+			}
+		})
+	}
+}
+
+// TestEvaluateCondition_ContainsOperator verifies substring matching on strings and
+// element matching on arrays, with literal or request-sourced right-hand values.
+func TestEvaluateCondition_ContainsOperator(t *testing.T) {
+	ctx := helperContext()
+	ctx.Headers["accept"] = "application/json, text/plain"
+	ctx.Headers["x-ids"] = "7,25,40"
+	ctx.Body["tags"] = []interface{}{"new", "sale"}
+	ctx.Body["scores"] = []interface{}{10.0, 25.0}
+	ctx.Body["country"] = "CA"
+
+	tests := []struct {
+		name      string
+		expr      string
+		want      bool
+		expectErr bool
+	}{
+		// Strings: substring match
+		{"Header Substring Match", "request.headers.accept contains 'json'", true, false},
+		{"Header Substring Miss", "request.headers.accept contains 'xml'", false, false},
+		{"Substring Is Case Sensitive", "request.headers.accept contains 'JSON'", false, false},
+		{"Number Literal In String", "request.headers.x-ids contains 25", true, false},
+		{"Quoted Operators Are Literal", "request.headers.accept contains ' == '", false, false},
+
+		// Arrays: element match
+		{"Array Element Match", "request.body.tags contains 'sale'", true, false},
+		{"Array Element Miss", "request.body.tags contains 'old'", false, false},
+		{"Array Numeric Element", "request.body.scores contains 25", true, false},
+		{"Array Reference Element", "request.body.tags contains request.query.search", false, false},
+
+		// Literal lists parsed by evalParseLiteral
+		{"In Literal Country List", "request.body.country in ['US','CA','MX']", true, false},
+		{"In Empty List", "request.body.country in []", false, false},
+
+		// Combined with logical operators
+		{"Contains With NOT", "NOT request.body.tags contains 'old' AND request.body.active == true", true, false},
+
+		// Errors
+		{"Contains On Number", "request.body.age contains 2", false, true},
+		{"Missing Right Reference", "request.body.tags contains request.body.missing", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateCondition(tt.expr, ctx)
+			if tt.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got, "Expression: %s", tt.expr)
+			}
+		})
+	}
+}
+
+// TestEvaluateCondition_TimeNamespace verifies business-hours style conditions
+// against a fixed fake clock.
+func TestEvaluateCondition_TimeNamespace(t *testing.T) {
+	ctx := helperContext()
+	businessHours := "time.hour >= 9 AND time.hour < 17 AND time.weekday != 'saturday' AND time.weekday != 'sunday'"
+
+	tests := []struct {
+		name string
+		now  time.Time
+		expr string
+		want bool
+	}{
+		{"Weekday Within Hours", time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC), businessHours, true},
+		{"Weekday After Hours", time.Date(2024, 5, 15, 18, 0, 0, 0, time.UTC), businessHours, false},
+		{"Weekend Within Hours", time.Date(2024, 5, 18, 11, 0, 0, 0, time.UTC), businessHours, false},
+		{"Weekday Number", time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC), "time.weekday_num == 3", true},
+		{"Combined With Request", time.Date(2024, 5, 15, 20, 0, 0, 0, time.UTC), "time.hour > 17 AND request.body.role == 'admin'", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx.Now = func() time.Time { return tt.now }
+
+			got, err := EvaluateCondition(tt.expr, ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got, "Expression: %s", tt.expr)
+		})
+	}
+
+	t.Run("Unknown Field", func(t *testing.T) {
+		_, err := EvaluateCondition("time.century == 21", ctx)
+		require.Error(t, err)
+	})
+}
+
+// TestEvaluateCondition_Proto verifies request.proto resolves to the request protocol.
+func TestEvaluateCondition_Proto(t *testing.T) {
+	ctx := helperContext()
+
+	ctx.Proto = "HTTP/2.0"
+	got, err := EvaluateCondition("request.proto == 'HTTP/2.0'", ctx)
+	require.NoError(t, err)
+	assert.True(t, got)
+
+	ctx.Proto = "HTTP/1.1"
+	got, err = EvaluateCondition("request.proto contains '2' OR request.proto == 'HTTP/1.0'", ctx)
+	require.NoError(t, err)
+	assert.False(t, got)
+}
+
+// TestEvaluateCondition_DeepPaths verifies body references walk nested objects and arrays case-insensitively.
+func TestEvaluateCondition_DeepPaths(t *testing.T) {
+	ctx := helperContext()
+	ctx.Body["user"] = map[string]interface{}{
+		"Address": map[string]interface{}{"city": "Berlin", "zip": 10115},
+		"roles":   []interface{}{"viewer", "editor"},
+	}
+	ctx.Query["filter.name"] = "ada"
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"request.body.user.address.city == 'Berlin'", true},
+		{"request.body.USER.Address.CITY == 'Paris'", false},
+		{"request.body.user.address.zip > 10000", true},
+		{"request.body.user.roles.1 == 'editor'", true},
+		{"request.query.filter.name == 'ada'", true},
+	}
+	for _, tt := range tests {
+		got, err := EvaluateCondition(tt.expr, ctx)
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.want, got, "Expression: %s", tt.expr)
+	}
+
+	_, err := EvaluateCondition("request.body.user.address.street == 'x'", ctx)
+	assert.ErrorContains(t, err, "user.address.street")
+	_, err = EvaluateCondition("request.body.user.roles.5 == 'x'", ctx)
+	assert.Error(t, err)
+}
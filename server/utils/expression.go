@@ -0,0 +1,308 @@
+package server_utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ExpressionPrefix marks a template string as a computed expression, e.g. "= request.body.price * request.body.qty".
+const ExpressionPrefix = "="
+
+// exprToken is a lexical unit of a computed expression.
+type exprToken struct {
+	kind string // "num", "str", "ref", "op", "(", ")"
+	text string
+}
+
+// EvaluateExpression computes a value from the request context.
+// Supports numbers, quoted strings, request.* / time.* references, + - * / %, parentheses and unary minus.
+// '+' adds when both operands are numeric (numeric strings included) and concatenates otherwise;
+// the remaining operators require numeric operands. Numeric results are float64.
+func EvaluateExpression(expr string, ctx EContext) (interface{}, error) {
+	tokens, err := parseExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens, ctx: ctx}
+	val, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token '%s' in expression '%s'", p.tokens[p.pos].text, expr)
+	}
+	return val, nil
+}
+
+// IsExpression reports whether expr is a well-formed expression. Template strings starting with
+// ExpressionPrefix that are not (e.g. "=== header ===" or base64 padding) are kept as literals.
+func IsExpression(expr string) bool {
+	_, err := parseExpression(expr)
+	return err == nil
+}
+
+// parseExpression tokenizes expr and checks its grammar without resolving any reference.
+func parseExpression(expr string) ([]exprToken, error) {
+	tokens, err := tokenizeExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	p := &exprParser{tokens: tokens, syntaxOnly: true}
+	if _, err := p.parseSum(); err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token '%s' in expression '%s'", p.tokens[p.pos].text, expr)
+	}
+	return tokens, nil
+}
+
+// tokenizeExpression splits an expression into tokens.
+// '-' is part of a reference only under request.headers (e.g. request.headers.x-qty).
+func tokenizeExpression(expr string) ([]exprToken, error) {
+	var tokens []exprToken
+	i := 0
+
+	for i < len(expr) {
+		ch := expr[i]
+		switch {
+		case ch == ' ' || ch == '\t':
+			i++
+
+		case strings.ContainsRune("+-*/%", rune(ch)):
+			tokens = append(tokens, exprToken{kind: "op", text: string(ch)})
+			i++
+
+		case ch == '(' || ch == ')':
+			tokens = append(tokens, exprToken{kind: string(ch), text: string(ch)})
+			i++
+
+		case ch == '\'' || ch == '"':
+			end := strings.IndexByte(expr[i+1:], ch)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in expression '%s'", expr)
+			}
+			tokens = append(tokens, exprToken{kind: "str", text: expr[i+1 : i+1+end]})
+			i += end + 2
+
+		case (ch >= '0' && ch <= '9') || ch == '.':
+			start := i
+			for i < len(expr) && ((expr[i] >= '0' && expr[i] <= '9') || expr[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: "num", text: expr[start:i]})
+
+		case isExprIdentChar(ch):
+			start := i
+			for i < len(expr) && (isExprIdentChar(expr[i]) ||
+				(expr[i] == '-' && strings.HasPrefix(expr[start:i], "request.headers."))) {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: "ref", text: expr[start:i]})
+
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' in expression '%s'", ch, expr)
+		}
+	}
+	return tokens, nil
+}
+
+func isExprIdentChar(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch == '_' || ch == '.'
+}
+
+// exprParser is a recursive-descent parser evaluating tokens as it goes.
+// With syntaxOnly, references and operators are not evaluated, only the grammar is checked.
+type exprParser struct {
+	tokens     []exprToken
+	pos        int
+	ctx        EContext
+	syntaxOnly bool
+}
+
+func (p *exprParser) peekOp(ops string) (string, bool) {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == "op" && strings.Contains(ops, p.tokens[p.pos].text) {
+		return p.tokens[p.pos].text, true
+	}
+	return "", false
+}
+
+// parseSum handles '+' and '-' (lowest precedence).
+func (p *exprParser) parseSum() (interface{}, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peekOp("+-")
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		if p.syntaxOnly {
+			continue
+		}
+		if left, err = applyExprOperator(left, right, op); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseProduct handles '*', '/' and '%'.
+func (p *exprParser) parseProduct() (interface{}, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peekOp("*/%")
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if p.syntaxOnly {
+			continue
+		}
+		if left, err = applyExprOperator(left, right, op); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseUnary handles a leading minus sign.
+func (p *exprParser) parseUnary() (interface{}, error) {
+	if _, ok := p.peekOp("-"); ok {
+		p.pos++
+		val, err := p.parseUnary()
+		if err != nil || p.syntaxOnly {
+			return nil, err
+		}
+		n, ok := exprToNumber(val)
+		if !ok {
+			return nil, fmt.Errorf("cannot negate non-numeric value '%v'", val)
+		}
+		return -n, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary handles literals, references and parenthesized sub-expressions.
+func (p *exprParser) parsePrimary() (interface{}, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	switch tok.kind {
+	case "num":
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", tok.text)
+		}
+		return n, nil
+
+	case "str":
+		return tok.text, nil
+
+	case "ref":
+		if p.syntaxOnly {
+			// Bare words ("=Yes") are not references, so the string stays a literal
+			if !strings.HasPrefix(tok.text, "request.") && !strings.HasPrefix(tok.text, "time.") {
+				return nil, fmt.Errorf("unknown reference '%s'", tok.text)
+			}
+			return nil, nil
+		}
+		return evalResolveValue(tok.text, p.ctx)
+
+	case "(":
+		val, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return val, nil
+
+	default:
+		return nil, fmt.Errorf("unexpected token '%s'", tok.text)
+	}
+}
+
+// applyExprOperator evaluates a single binary operation.
+func applyExprOperator(left, right interface{}, op string) (interface{}, error) {
+	a, aok := exprToNumber(left)
+	b, bok := exprToNumber(right)
+
+	if op == "+" && !(aok && bok) {
+		return fmt.Sprintf("%v%v", exprToString(left), exprToString(right)), nil
+	}
+	if !aok || !bok {
+		return nil, fmt.Errorf("operator '%s' requires numeric operands, got '%v' and '%v'", op, left, right)
+	}
+
+	switch op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return a / b, nil
+	case "%":
+		if b == 0 {
+			return nil, fmt.Errorf("modulo by zero")
+		}
+		return math.Mod(a, b), nil
+	}
+	return nil, fmt.Errorf("unknown operator '%s'", op)
+}
+
+// exprToNumber coerces numbers and numeric strings (e.g. query values) to float64.
+func exprToNumber(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f, true
+		}
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// exprToString formats operands for concatenation without trailing ".0" noise on whole numbers.
+func exprToString(val interface{}) string {
+	if f, ok := val.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", val)
+}
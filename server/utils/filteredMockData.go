@@ -1,7 +1,9 @@
 package server_utils
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"net/url"
 	"sort"
 	"strings"
@@ -18,6 +20,21 @@ import (
 //
 // Returns the transformed slice or an error if pagination parameters are invalid.
 func FilteredMockData(data []map[string]interface{}, params map[string]string) ([]map[string]interface{}, error) {
+	filtered, _, err := FilteredMockPage(data, params)
+	return filtered, err
+}
+
+// PageMeta describes a page of FilteredMockPage results. Total counts the items
+// matching the filters before pagination; Limit is 0 when no _limit was given.
+type PageMeta struct {
+	Total   int
+	Page    int
+	Limit   int
+	HasNext bool
+}
+
+// FilteredMockPage works like FilteredMockData and also returns the pagination metadata.
+func FilteredMockPage(data []map[string]interface{}, params map[string]string) ([]map[string]interface{}, PageMeta, error) {
 	filtered := data
 
 	filtered = applyExactFilters(filtered, params)
@@ -26,54 +43,94 @@ func FilteredMockData(data []map[string]interface{}, params map[string]string) (
 
 	applySorting(filtered, params)
 
-	filtered, err := applyPagination(filtered, params)
+	page, limit, err := paginationParams(params)
 	if err != nil {
-		return nil, err
+		return nil, PageMeta{}, err
 	}
 
-	return filtered, nil
+	meta := PageMeta{Total: len(filtered), Page: page, Limit: limit}
+	filtered = applyPagination(filtered, page, limit)
+	if limit > 0 {
+		meta.HasNext = page*limit < meta.Total
+	}
+	return filtered, meta, nil
 }
 
-// Slices the dataset into pages using
-// query parameters `_page` and `_limit`.
+// PageEnvelope wraps a page of items as {"data": [...], "meta": {"total", "page", "limit", "has_next"}}.
+func PageEnvelope(items interface{}, meta PageMeta) map[string]interface{} {
+	return map[string]interface{}{
+		"data": items,
+		"meta": map[string]interface{}{
+			"total":    meta.Total,
+			"page":     meta.Page,
+			"limit":    meta.Limit,
+			"has_next": meta.HasNext,
+		},
+	}
+}
+
+// HasFilterParams reports whether any parameter would be used by FilteredMockData
+// (exact or "like" filters, sorting or pagination).
+func HasFilterParams(params map[string]string) bool {
+	for key := range params {
+		switch key {
+		case "apiKey":
+			continue
+		case "_sort", "_page", "_limit":
+			return true
+		}
+		if !strings.HasPrefix(key, "_") {
+			return true
+		}
+	}
+	return false
+}
+
+// paginationParams reads the query parameters `_page` (default 1) and `_limit` (0 = no pagination).
 // Returns an error if parameters are invalid.
-func applyPagination(data []map[string]interface{}, params map[string]string) ([]map[string]interface{}, error) {
+func paginationParams(params map[string]string) (int, int, error) {
 	limit := 0
 	page := 1
 	if val, ok := params["_limit"]; ok {
 		if _, err := fmt.Sscanf(val, "%d", &limit); err != nil || limit < 0 {
-			return nil, fmt.Errorf("_limit must be a positive number")
+			return 0, 0, fmt.Errorf("_limit must be a positive number")
 		}
 	}
 	if val, ok := params["_page"]; ok {
 		if _, err := fmt.Sscanf(val, "%d", &page); err != nil || page < 1 {
-			return nil, fmt.Errorf("_page must be a positive number")
+			return 0, 0, fmt.Errorf("_page must be a positive number")
 		}
 	}
+	return page, limit, nil
+}
 
+// Slices the dataset into pages of `limit` items.
+func applyPagination(data []map[string]interface{}, page, limit int) []map[string]interface{} {
 	// No pagination requested
 	if limit <= 0 {
-		return data, nil
+		return data
 	}
 
 	start := (page - 1) * limit
 	if start >= len(data) {
-		return []map[string]interface{}{}, nil
+		return []map[string]interface{}{}
 	}
 
 	end := start + limit
 	if end > len(data) {
 		end = len(data)
 	}
-	return data[start:end], nil
+	return data[start:end]
 }
 
 // matchExact checks strict equality between a given value and a target string.
-// Supports float64, string, and bool comparisons.
+// Supports numbers (float64, int, json.Number compared by value, so "2.50" matches 2.5), string, and bool comparisons.
 func matchExact(v interface{}, target string) bool {
 	switch val := v.(type) {
-	case float64:
-		return target == fmt.Sprintf("%.0f", val)
+	case float64, int, int64, json.Number:
+		a, _ := toRat(val)
+		b, ok := new(big.Rat).SetString(strings.TrimSpace(target))
+		return a != nil && ok && a.Cmp(b) == 0
 	case string:
 		return val == target
 	case bool:
@@ -155,12 +212,17 @@ func applySorting(data []map[string]interface{}, params map[string]string) {
 
 func compareValues(a, b interface{}, order string) bool {
 	switch va := a.(type) {
-	case float64:
-		vb, _ := b.(float64)
+	case float64, int, int64, json.Number:
+		// Numbers compare by value whatever their Go type (config values hold float64, request and fixture data json.Number)
+		ra, _ := toRat(va)
+		rb, ok := toRat(b)
+		if ra == nil || !ok {
+			return false
+		}
 		if order == "desc" {
-			return va > vb
+			return ra.Cmp(rb) > 0
 		}
-		return va < vb
+		return ra.Cmp(rb) < 0
 	case string:
 		vb := fmt.Sprintf("%v", b)
 		if order == "desc" {
@@ -0,0 +1,57 @@
+package server_utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFilteredMockPage verifies the page metadata is computed from the filtered, pre-pagination total.
+func TestFilteredMockPage(t *testing.T) {
+	dataset := func() []map[string]interface{} {
+		items := make([]map[string]interface{}, 0, 12)
+		for i := 1; i <= 12; i++ {
+			role := "user"
+			if i%3 == 0 {
+				role = "admin"
+			}
+			items = append(items, map[string]interface{}{"id": float64(i), "role": role})
+		}
+		return items
+	}
+
+	tests := []struct {
+		name   string
+		params map[string]string
+		ids    []float64
+		meta   PageMeta
+	}{
+		{"First page", map[string]string{"_page": "1", "_limit": "5"}, []float64{1, 2, 3, 4, 5}, PageMeta{Total: 12, Page: 1, Limit: 5, HasNext: true}},
+		{"Last partial page", map[string]string{"_page": "3", "_limit": "5"}, []float64{11, 12}, PageMeta{Total: 12, Page: 3, Limit: 5}},
+		{"Exact last page", map[string]string{"_page": "2", "_limit": "6"}, []float64{7, 8, 9, 10, 11, 12}, PageMeta{Total: 12, Page: 2, Limit: 6}},
+		{"Past the end", map[string]string{"_page": "9", "_limit": "5"}, []float64{}, PageMeta{Total: 12, Page: 9, Limit: 5}},
+		{"Filtered total", map[string]string{"role": "admin", "_limit": "3", "_sort": "id", "_order": "desc"}, []float64{12, 9, 6}, PageMeta{Total: 4, Page: 1, Limit: 3, HasNext: true}},
+		{"No limit", map[string]string{"role": "admin"}, []float64{3, 6, 9, 12}, PageMeta{Total: 4, Page: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, meta, err := FilteredMockPage(dataset(), tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.meta, meta)
+
+			ids := make([]float64, 0, len(items))
+			for _, item := range items {
+				ids = append(ids, item["id"].(float64))
+			}
+			assert.Equal(t, tt.ids, ids)
+		})
+	}
+
+	_, _, err := FilteredMockPage(dataset(), map[string]string{"_page": "0", "_limit": "5"})
+	assert.Error(t, err)
+
+	env := PageEnvelope([]map[string]interface{}{}, PageMeta{Total: 12, Page: 9, Limit: 5})
+	assert.Equal(t, map[string]interface{}{"total": 12, "page": 9, "limit": 5, "has_next": false}, env["meta"])
+}
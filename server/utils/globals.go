@@ -0,0 +1,69 @@
+package server_utils
+
+import (
+	"strings"
+	"sync"
+)
+
+// GlobalsPrefix is the template namespace of global variables, e.g. {{global.token}}.
+const GlobalsPrefix = "global."
+
+// GlobalStore holds server-wide variables shared by all requests. Values are deep-copied
+// on the way in and out, so callers never share mutable maps or slices with the store.
+type GlobalStore struct {
+	mu     sync.RWMutex
+	values map[string]interface{}
+}
+
+func NewGlobalStore() *GlobalStore {
+	return &GlobalStore{values: make(map[string]interface{})}
+}
+
+// Lookup resolves a dotted path such as "auth.token" against the stored variables.
+func (g *GlobalStore) Lookup(path string) (interface{}, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	parts := strings.Split(path, ".")
+	current, ok := g.values[parts[0]]
+	for _, part := range parts[1:] {
+		if !ok {
+			break
+		}
+		obj, isObj := current.(map[string]interface{})
+		if !isObj {
+			return nil, false
+		}
+		current, ok = obj[part]
+	}
+	if !ok {
+		return nil, false
+	}
+	return deepCopyValue(current), true
+}
+
+// Set stores or overwrites the given variables; other variables are kept.
+func (g *GlobalStore) Set(values map[string]interface{}) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for k, v := range values {
+		g.values[k] = deepCopyValue(v)
+	}
+}
+
+// Replace discards all variables and stores values instead.
+func (g *GlobalStore) Replace(values map[string]interface{}) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.values = deepCopyMap(values)
+}
+
+// Snapshot returns a copy of all variables.
+func (g *GlobalStore) Snapshot() map[string]interface{} {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return deepCopyMap(g.values)
+}
@@ -0,0 +1,50 @@
+package server_utils
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGlobalStore verifies nested lookups, copy isolation and concurrent access.
+func TestGlobalStore(t *testing.T) {
+	store := NewGlobalStore()
+	source := map[string]interface{}{"auth": map[string]interface{}{"token": "t0"}}
+	store.Replace(source)
+
+	val, ok := store.Lookup("auth.token")
+	require.True(t, ok)
+	assert.Equal(t, "t0", val)
+
+	_, ok = store.Lookup("auth.token.deeper")
+	assert.False(t, ok)
+	_, ok = store.Lookup("missing")
+	assert.False(t, ok)
+
+	// Mutating the seed or a returned value never reaches the store
+	source["auth"].(map[string]interface{})["token"] = "changed"
+	auth, _ := store.Lookup("auth")
+	auth.(map[string]interface{})["token"] = "changed"
+	val, _ = store.Lookup("auth.token")
+	assert.Equal(t, "t0", val)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			store.Set(map[string]interface{}{"counter": i, fmt.Sprintf("k%d", i): i})
+		}(i)
+		go func() {
+			defer wg.Done()
+			store.Lookup("counter")
+			store.Snapshot()
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, store.Snapshot(), 22)
+}
@@ -0,0 +1,41 @@
+package server_utils
+
+// JSONDepth returns the deepest object/array nesting of a JSON document without decoding it:
+// scalars are 0, {} and [] are 1, {"a": [1]} is 2. Brackets inside strings are ignored.
+// Scanning stops once limit is exceeded (limit <= 0 scans everything), so huge bodies cost little.
+func JSONDepth(data []byte, limit int) int {
+	depth, deepest := 0, 0
+	inString, escaped := false, false
+
+	for _, ch := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case ch == '\\':
+				escaped = true
+			case ch == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch ch {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > deepest {
+				deepest = depth
+				if limit > 0 && deepest > limit {
+					return deepest
+				}
+			}
+		case '}', ']':
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+	return deepest
+}
@@ -0,0 +1,33 @@
+package server_utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONDepth(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"Scalar", `42`, 0},
+		{"Empty Object", `{}`, 1},
+		{"Nested", `{"a": [1, {"b": {}}]}`, 4},
+		{"Siblings", `[[1], [2], {"c": 3}]`, 2},
+		{"Brackets In Strings", `{"text": "[[{{ \"]]}}\" "}`, 1},
+		{"Escaped Backslash", `{"path": "C:\\", "n": [1]}`, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, JSONDepth([]byte(tt.body), 0))
+		})
+	}
+
+	// Scanning stops right after the limit is exceeded
+	deep := strings.Repeat("[", 10000) + strings.Repeat("]", 10000)
+	assert.Equal(t, 10000, JSONDepth([]byte(deep), 0))
+	assert.Equal(t, 33, JSONDepth([]byte(deep), 32))
+}
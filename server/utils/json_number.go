@@ -0,0 +1,54 @@
+package server_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DecodeJSON unmarshals data like json.Unmarshal but keeps numbers as json.Number,
+// so integers beyond float64 precision (e.g. large ids) survive untouched.
+func DecodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		// Let json.Unmarshal report the trailing data with its usual *json.SyntaxError
+		return json.Unmarshal(data, new(interface{}))
+	}
+	return nil
+}
+
+// toRat converts numeric values (float64, int, json.Number, numeric strings) to an exact rational.
+// float64 values go through their shortest decimal form, so 0.1 compares equal to the literal 0.1.
+func toRat(val interface{}) (*big.Rat, bool) {
+	var s string
+	switch v := val.(type) {
+	case json.Number:
+		s = v.String()
+	case float64:
+		s = strconv.FormatFloat(v, 'g', -1, 64)
+	case int:
+		s = strconv.Itoa(v)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case string:
+		s = strings.TrimSpace(v)
+	default:
+		return nil, false
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	return r, ok
+}
+
+// isIntegerNumber reports whether a json.Number holds a whole number (e.g. "42", "1e3").
+func isIntegerNumber(n json.Number) bool {
+	r, ok := new(big.Rat).SetString(n.String())
+	return ok && r.IsInt()
+}
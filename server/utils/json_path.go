@@ -0,0 +1,47 @@
+package server_utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// splitJSONPath splits a dotted body path (e.g. "items.0.id") into its segments.
+// mask rules, response_transform and request.body references share this syntax.
+func splitJSONPath(path string) []string {
+	return strings.Split(path, ".")
+}
+
+// lookupJSONSegment descends one path segment: an object key or an array index.
+// With foldCase, a missing key falls back to a case-insensitive match.
+func lookupJSONSegment(value interface{}, segment string, foldCase bool) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if val, ok := v[segment]; ok {
+			return val, true
+		}
+		if foldCase {
+			for k, val := range v {
+				if strings.EqualFold(k, segment) {
+					return val, true
+				}
+			}
+		}
+	case []interface{}:
+		if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(v) {
+			return v[i], true
+		}
+	}
+	return nil, false
+}
+
+// lookupJSONPath walks object keys and array indexes segment by segment.
+func lookupJSONPath(value interface{}, parts []string, foldCase bool) (interface{}, bool) {
+	for _, part := range parts {
+		next, ok := lookupJSONSegment(value, part, foldCase)
+		if !ok {
+			return nil, false
+		}
+		value = next
+	}
+	return value, true
+}
@@ -0,0 +1,30 @@
+package server_utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLookupJSONPath verifies object keys, array indexes and the optional case-insensitive key match.
+func TestLookupJSONPath(t *testing.T) {
+	body := map[string]interface{}{
+		"Items": []interface{}{map[string]interface{}{"id": "a"}, map[string]interface{}{"id": "b"}},
+	}
+
+	val, ok := lookupJSONPath(body, splitJSONPath("Items.1.id"), false)
+	assert.True(t, ok)
+	assert.Equal(t, "b", val)
+
+	_, ok = lookupJSONPath(body, splitJSONPath("items.1.id"), false)
+	assert.False(t, ok, "keys match exactly unless case folding is requested")
+
+	val, ok = lookupJSONPath(body, splitJSONPath("items.0.ID"), true)
+	assert.True(t, ok)
+	assert.Equal(t, "a", val)
+
+	for _, path := range []string{"Items.2.id", "Items.-1", "Items.x", "Items.0.id.deeper"} {
+		_, ok = lookupJSONPath(body, splitJSONPath(path), true)
+		assert.False(t, ok, path)
+	}
+}
@@ -0,0 +1,40 @@
+package server_utils
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Supported corruption modes for MockConfig.Malformed
+const (
+	MalformedTruncate    = "truncate"
+	MalformedInvalidJSON = "invalid-json"
+	MalformedExtraComma  = "extra-comma"
+)
+
+// MalformJSON corrupts a serialized JSON payload in a controlled way for negative testing:
+//   - truncate:     keeps only the first half of the payload
+//   - invalid-json: swaps double quotes for single quotes
+//   - extra-comma:  adds a trailing comma before the closing bracket/brace
+func MalformJSON(encoded []byte, mode string) ([]byte, error) {
+	switch mode {
+	case MalformedTruncate:
+		return encoded[:len(encoded)/2], nil
+
+	case MalformedInvalidJSON:
+		return bytes.ReplaceAll(encoded, []byte(`"`), []byte(`'`)), nil
+
+	case MalformedExtraComma:
+		trimmed := bytes.TrimRight(encoded, " \n\t")
+		if n := len(trimmed); n > 0 && (trimmed[n-1] == '}' || trimmed[n-1] == ']') {
+			out := make([]byte, 0, n+1)
+			out = append(out, trimmed[:n-1]...)
+			out = append(out, ',')
+			return append(out, trimmed[n-1]), nil
+		}
+		return append(trimmed, ','), nil
+
+	default:
+		return nil, fmt.Errorf("unknown malformed mode: '%s'", mode)
+	}
+}
@@ -0,0 +1,38 @@
+package server_utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMalformJSON verifies each corruption mode produces the documented (and invalid) shape.
+func TestMalformJSON(t *testing.T) {
+	encoded := []byte(`{"id":1,"name":"mock"}`)
+
+	tests := []struct {
+		name     string
+		input    []byte
+		mode     string
+		expected string
+	}{
+		{"Truncate", encoded, MalformedTruncate, `{"id":1,"na`},
+		{"Invalid JSON", encoded, MalformedInvalidJSON, `{'id':1,'name':'mock'}`},
+		{"Extra Comma Object", encoded, MalformedExtraComma, `{"id":1,"name":"mock",}`},
+		{"Extra Comma Array", []byte(`[1,2]`), MalformedExtraComma, `[1,2,]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := MalformJSON(tt.input, tt.mode)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(out))
+			assert.False(t, json.Valid(out), "output must not be valid JSON")
+		})
+	}
+
+	_, err := MalformJSON(encoded, "scramble")
+	assert.Error(t, err)
+}
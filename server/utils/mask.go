@@ -0,0 +1,84 @@
+package server_utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+import (
+	config "mockserver/config"
+)
+
+// maskFullValue replaces fully masked fields regardless of their type or length.
+const maskFullValue = "********"
+
+// MaskFields returns body with the fields of every rule masked. Maps and arrays along the masked
+// paths are copied, so shared data (e.g. stateful items returned through {{state.item}}) is never modified.
+func MaskFields(body interface{}, rules []config.MaskRule) interface{} {
+	for _, rule := range rules {
+		body = maskPath(body, splitJSONPath(rule.Field), rule)
+	}
+	return body
+}
+
+// maskPath walks the dotted path, descending into every element of the arrays it meets.
+// Paths that do not exist in the body are left untouched.
+func maskPath(value interface{}, parts []string, rule config.MaskRule) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = maskPath(item, parts, rule)
+		}
+		return out
+
+	case []map[string]interface{}:
+		// Filtered mock files and stateful lists are typed slices
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = maskPath(item, parts, rule)
+		}
+		return out
+
+	case map[string]interface{}:
+		current, ok := lookupJSONSegment(v, parts[0], false)
+		if !ok {
+			return v
+		}
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = item
+		}
+		if len(parts) == 1 {
+			out[parts[0]] = maskValue(current, rule)
+		} else {
+			out[parts[0]] = maskPath(current, parts[1:], rule)
+		}
+		return out
+	}
+	return value
+}
+
+// maskValue applies the rule strategy to a single field. null stays null so absent data is not invented.
+func maskValue(value interface{}, rule config.MaskRule) interface{} {
+	if value == nil {
+		return nil
+	}
+	text := fmt.Sprintf("%v", value)
+
+	switch rule.Strategy {
+	case "partial":
+		runes := []rune(text)
+		if len(runes) <= rule.Keep {
+			return strings.Repeat("*", len(runes))
+		}
+		return strings.Repeat("*", len(runes)-rule.Keep) + string(runes[len(runes)-rule.Keep:])
+
+	case "hash":
+		sum := sha256.Sum256([]byte(text))
+		return hex.EncodeToString(sum[:])
+	}
+	return maskFullValue
+}
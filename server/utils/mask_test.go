@@ -0,0 +1,48 @@
+package server_utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"mockserver/config"
+)
+
+// TestMaskFields verifies each strategy and that the input body is never modified.
+func TestMaskFields(t *testing.T) {
+	item := map[string]interface{}{
+		"user":  map[string]interface{}{"ssn": "123-45-6789", "name": "Ada"},
+		"cards": []interface{}{map[string]interface{}{"pan": "4242"}, map[string]interface{}{"pan": "55"}},
+		"note":  nil,
+	}
+
+	masked := MaskFields(item, []config.MaskRule{
+		{Field: "user.ssn", Strategy: "partial", Keep: 4},
+		{Field: "cards.pan", Strategy: "partial", Keep: 4},
+		{Field: "note", Strategy: "full"},
+		{Field: "missing.path", Strategy: "full"},
+	}).(map[string]interface{})
+
+	assert.Equal(t, map[string]interface{}{"ssn": "*******6789", "name": "Ada"}, masked["user"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"pan": "****"},
+		map[string]interface{}{"pan": "**"},
+	}, masked["cards"], "values no longer than keep are fully masked")
+	assert.Nil(t, masked["note"], "null stays null")
+	assert.NotContains(t, masked, "missing")
+
+	// The source (e.g. a stored stateful item) keeps its original values
+	assert.Equal(t, "123-45-6789", item["user"].(map[string]interface{})["ssn"])
+	assert.Equal(t, "4242", item["cards"].([]interface{})[0].(map[string]interface{})["pan"])
+
+	hashed := MaskFields(map[string]interface{}{"token": "abc"}, []config.MaskRule{{Field: "token", Strategy: "hash"}})
+	assert.Equal(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", hashed.(map[string]interface{})["token"])
+
+	list := []map[string]interface{}{{"token": "abc"}, {"token": "def"}}
+	maskedList := MaskFields(list, []config.MaskRule{{Field: "token", Strategy: "full"}})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"token": "********"},
+		map[string]interface{}{"token": "********"},
+	}, maskedList, "typed slices (filtered mock files, stateful lists) are masked too")
+	assert.Equal(t, "abc", list[0]["token"])
+}
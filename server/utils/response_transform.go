@@ -0,0 +1,88 @@
+package server_utils
+
+import (
+	"fmt"
+	"sort"
+)
+
+import (
+	config "mockserver/config"
+)
+
+// TransformResponse applies fetch.response_transform to a decoded JSON body: extract, rename, pick, then wrap.
+// The body is modified in place, so it must not be shared (e.g. freshly decoded upstream JSON).
+func TransformResponse(body interface{}, t *config.ResponseTransformConfig) (interface{}, error) {
+	if t.Extract != "" {
+		extracted, ok := lookupJSONPath(body, splitJSONPath(t.Extract), false)
+		if !ok {
+			return nil, fmt.Errorf("extract path '%s' not found in upstream response", t.Extract)
+		}
+		body = extracted
+	}
+
+	if len(t.Rename) > 0 || len(t.Pick) > 0 {
+		// Renames run in source path order so overlapping rules give the same result on every request
+		sources := make([]string, 0, len(t.Rename))
+		for from := range t.Rename {
+			sources = append(sources, from)
+		}
+		sort.Strings(sources)
+
+		body = eachObject(body, func(obj map[string]interface{}) map[string]interface{} {
+			for _, from := range sources {
+				to := t.Rename[from]
+				if val, ok := removeJSONPath(obj, splitJSONPath(from)); ok {
+					obj[to] = val
+				}
+			}
+			if len(t.Pick) > 0 {
+				picked := make(map[string]interface{}, len(t.Pick))
+				for _, key := range t.Pick {
+					if val, ok := obj[key]; ok {
+						picked[key] = val
+					}
+				}
+				obj = picked
+			}
+			return obj
+		})
+	}
+
+	if t.Wrap != "" {
+		body = map[string]interface{}{t.Wrap: body}
+	}
+	return body, nil
+}
+
+// eachObject applies fn to an object, or to every object of an array. Other values are returned unchanged.
+func eachObject(value interface{}, fn func(map[string]interface{}) map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return fn(v)
+	case []interface{}:
+		for i, item := range v {
+			if obj, ok := item.(map[string]interface{}); ok {
+				v[i] = fn(obj)
+			}
+		}
+	}
+	return value
+}
+
+// removeJSONPath deletes the object field at the path and returns its value.
+func removeJSONPath(obj map[string]interface{}, parts []string) (interface{}, bool) {
+	parent, ok := lookupJSONPath(obj, parts[:len(parts)-1], false)
+	if !ok {
+		return nil, false
+	}
+	container, ok := parent.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	key := parts[len(parts)-1]
+	val, ok := container[key]
+	if ok {
+		delete(container, key)
+	}
+	return val, ok
+}
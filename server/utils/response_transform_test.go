@@ -0,0 +1,81 @@
+package server_utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"mockserver/config"
+)
+
+// TestTransformResponse verifies each rule and the extract → rename → pick → wrap order.
+func TestTransformResponse(t *testing.T) {
+	upstream := func() interface{} {
+		return map[string]interface{}{
+			"status": "ok",
+			"data": map[string]interface{}{
+				"user": map[string]interface{}{
+					"id":      "u1",
+					"profile": map[string]interface{}{"email": "ada@example.com", "phone": "555"},
+					"secret":  "x",
+				},
+				"items": []interface{}{
+					map[string]interface{}{"sku": "A", "internal": true},
+					map[string]interface{}{"sku": "B", "internal": false},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		transform config.ResponseTransformConfig
+		expected  interface{}
+	}{
+		{
+			"Extract nested field to top level",
+			config.ResponseTransformConfig{Extract: "data.user.profile.email"},
+			"ada@example.com",
+		},
+		{
+			"Extract, rename and pick",
+			config.ResponseTransformConfig{Extract: "data.user", Rename: map[string]string{"profile.email": "email"}, Pick: []string{"id", "email"}},
+			map[string]interface{}{"id": "u1", "email": "ada@example.com"},
+		},
+		{
+			"Array elements and wrap",
+			config.ResponseTransformConfig{Extract: "data.items", Pick: []string{"sku"}, Wrap: "products"},
+			map[string]interface{}{"products": []interface{}{map[string]interface{}{"sku": "A"}, map[string]interface{}{"sku": "B"}}},
+		},
+		{
+			"Array index",
+			config.ResponseTransformConfig{Extract: "data.items.1.sku"},
+			"B",
+		},
+		{
+			"Missing rename source is skipped",
+			config.ResponseTransformConfig{Rename: map[string]string{"meta.total": "total"}, Pick: []string{"status", "total"}},
+			map[string]interface{}{"status": "ok"},
+		},
+		{
+			"Renames onto the same key apply in source path order",
+			config.ResponseTransformConfig{Rename: map[string]string{"status": "result", "data.user.id": "result"}, Pick: []string{"result"}},
+			map[string]interface{}{"result": "ok"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Repeat to catch results that depend on map iteration order
+			for i := 0; i < 20; i++ {
+				out, err := TransformResponse(upstream(), &tt.transform)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, out)
+			}
+		})
+	}
+
+	_, err := TransformResponse(upstream(), &config.ResponseTransformConfig{Extract: "data.items.5"})
+	assert.ErrorContains(t, err, "not found")
+}
@@ -1,7 +1,9 @@
 package server_utils
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -11,6 +13,11 @@ import (
 	msconfig "mockserver/config"
 )
 
+func init() {
+	// Lets config validation check route examples with the same validator used at request time
+	msconfig.SchemaValidator = ValidateJSONSchema
+}
+
 // ValidateJSONSchema performs a recursive validation of data against a JSON schema.
 // It supports structural validation (Objects, Arrays) and constraints (Min/Max, Regex, Enums).
 func ValidateJSONSchema(schema *msconfig.JSONSchema, data interface{}, path string) error {
@@ -65,11 +72,14 @@ func ValidateJSONSchema(schema *msconfig.JSONSchema, data interface{}, path stri
 		}
 
 	case "integer", "number":
-		val, _ := data.(float64)
-		if schema.Minimum != nil && val < *schema.Minimum {
+		val, ok := toRat(data)
+		if !ok {
+			break
+		}
+		if schema.Minimum != nil && val.Cmp(schemaBound(*schema.Minimum)) < 0 {
 			return fmt.Errorf("%s: must be >= %f", path, *schema.Minimum)
 		}
-		if schema.Maximum != nil && val > *schema.Maximum {
+		if schema.Maximum != nil && val.Cmp(schemaBound(*schema.Maximum)) > 0 {
 			return fmt.Errorf("%s: must be <= %f", path, *schema.Maximum)
 		}
 	}
@@ -77,6 +87,13 @@ func ValidateJSONSchema(schema *msconfig.JSONSchema, data interface{}, path stri
 	return nil
 }
 
+// schemaBound converts a minimum/maximum through its shortest decimal form, so a bound
+// written as 0.1 equals the request value 0.1 instead of its binary approximation.
+func schemaBound(v float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(v, 'g', -1, 64))
+	return r
+}
+
 // validateObject enforces 'required' fields and recursively validates nested properties.
 func validateObject(schema *msconfig.JSONSchema, data map[string]interface{}, parentPath string) error {
 	// Required Fields Check
@@ -160,6 +177,18 @@ func validateType(expectedType string, data interface{}, path string) error {
 		}
 		gotType = expectedType // accept "number" or "integer"
 
+	case json.Number:
+		if expectedType == "integer" {
+			if !isIntegerNumber(val) {
+				return fmt.Errorf("%s: expected integer, got float", path)
+			}
+			return nil
+		}
+		if expectedType == "number" {
+			return nil
+		}
+		gotType = "number"
+
 	case bool:
 		gotType = "boolean"
 	case map[string]interface{}:
@@ -0,0 +1,24 @@
+package server_utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	msconfig "mockserver/config"
+)
+
+// TestValidateJSONSchemaDecimalBounds verifies values equal to a decimal minimum/maximum are accepted.
+func TestValidateJSONSchemaDecimalBounds(t *testing.T) {
+	minimum, maximum := 0.1, 0.3
+	schema := &msconfig.JSONSchema{Type: "number", Minimum: &minimum, Maximum: &maximum}
+
+	for _, val := range []interface{}{json.Number("0.1"), json.Number("0.3"), 0.1, 0.3, "0.3", json.Number("0.2")} {
+		assert.NoError(t, ValidateJSONSchema(schema, val, "price"), "%v", val)
+	}
+
+	assert.Error(t, ValidateJSONSchema(schema, json.Number("0.09999"), "price"))
+	assert.Error(t, ValidateJSONSchema(schema, json.Number("0.30001"), "price"))
+	assert.Error(t, ValidateJSONSchema(schema, 0.31, "price"))
+}
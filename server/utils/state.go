@@ -1,14 +1,229 @@
 package server_utils
 
-import "sync"
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+import (
+	mslogger "mockserver/logger"
+)
 
 type StateStore struct {
 	mu          sync.RWMutex
 	collections map[string][]map[string]interface{}
+	subscribers map[chan StateChange]struct{} // guarded by mu
+	lastIDs     map[string]int                // highest auto_id "increment" value per collection, guarded by mu
+
+	// On-disk snapshots (guarded by mu); saveMu serializes file writes so an older
+	// snapshot can never overwrite a newer one
+	persistPath  string
+	persistDelay time.Duration
+	persistTimer *time.Timer
+	saveMu       sync.Mutex
+}
+
+// StateChange describes a single create, update or delete applied to a collection.
+type StateChange struct {
+	Collection string                 `json:"collection"`
+	Action     string                 `json:"action"`
+	ID         string                 `json:"id"`
+	Item       map[string]interface{} `json:"item,omitempty"`
 }
 
 func NewStateStore() *StateStore {
 	return &StateStore{
 		collections: make(map[string][]map[string]interface{}),
+		subscribers: make(map[chan StateChange]struct{}),
+		lastIDs:     make(map[string]int),
+	}
+}
+
+// Subscribe registers a listener for collection changes. The returned cancel func
+// unregisters it and closes the channel; it is safe to call more than once.
+func (s *StateStore) Subscribe(buffer int) (<-chan StateChange, func()) {
+	ch := make(chan StateChange, buffer)
+
+	s.mu.Lock()
+	if s.subscribers == nil {
+		s.subscribers = make(map[chan StateChange]struct{})
+	}
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.subscribers, ch)
+			s.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// notifyLocked fans a change out to subscribers. The caller must hold the write lock,
+// so events are delivered in mutation order. Sends never block: a subscriber whose
+// buffer is full misses the event instead of stalling writes.
+func (s *StateStore) notifyLocked(change StateChange) {
+	if change.Item != nil {
+		change.Item = deepCopyMap(change.Item)
+	}
+	for ch := range s.subscribers {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
+
+// Snapshot returns a deep copy of a collection taken under the read lock.
+// The boolean is false when the collection has never been written to.
+func (s *StateStore) Snapshot(collection string) ([]map[string]interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	col, ok := s.collections[collection]
+	if !ok {
+		return nil, false
+	}
+	items := make([]map[string]interface{}, len(col))
+	for i, item := range col {
+		items[i] = deepCopyMap(item)
+	}
+	return items, true
+}
+
+// deepCopyMap copies nested maps and slices so callers can't alias stored items.
+func deepCopyMap(src map[string]interface{}) map[string]interface{} {
+	dst := make(map[string]interface{}, len(src))
+	for k, v := range src {
+		dst[k] = deepCopyValue(v)
+	}
+	return dst
+}
+
+func deepCopyValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return deepCopyMap(val)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = deepCopyValue(item)
+		}
+		return out
+	default:
+		return val
+	}
+}
+
+// EnablePersistence restores the collections saved at path and snapshots every later write to it.
+// A missing file starts from the current (usually empty) state. Calling it again with the same
+// path only updates the delay, so hot reloads keep the live data.
+func (s *StateStore) EnablePersistence(path string, delay time.Duration) error {
+	s.mu.Lock()
+	samePath := s.persistPath == path
+	s.persistPath = path
+	s.persistDelay = delay
+	s.mu.Unlock()
+
+	if samePath {
+		return nil
+	}
+	if err := s.LoadFromFile(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// LoadFromFile replaces all collections with the snapshot stored at path.
+func (s *StateStore) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Snapshot numbers stay json.Number, matching values written through the API
+	var collections map[string][]map[string]interface{}
+	if err := DecodeJSON(data, &collections); err != nil {
+		return fmt.Errorf("invalid state snapshot '%s': %w", path, err)
+	}
+	if collections == nil {
+		collections = make(map[string][]map[string]interface{})
+	}
+
+	s.mu.Lock()
+	s.collections = collections
+	s.mu.Unlock()
+	return nil
+}
+
+// SaveToFile writes all collections to path. The file is replaced atomically, so a crash
+// mid-write leaves the previous snapshot intact.
+func (s *StateStore) SaveToFile(path string) error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.RLock()
+	data, err := json.MarshalIndent(s.collections, "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode state snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write state snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state snapshot: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// schedulePersistLocked queues a snapshot after a write. The caller must hold the write lock.
+// Writes arriving before the pending save runs are included in it, so the file lags by at most the delay.
+func (s *StateStore) schedulePersistLocked() {
+	if s.persistPath == "" || s.persistTimer != nil {
+		return
+	}
+
+	path := s.persistPath
+	s.persistTimer = time.AfterFunc(s.persistDelay, func() {
+		s.mu.Lock()
+		s.persistTimer = nil
+		s.mu.Unlock()
+
+		if err := s.SaveToFile(path); err != nil {
+			mslogger.LogError(err.Error())
+		}
+	})
+}
+
+// Flush saves a pending snapshot immediately (e.g. on shutdown).
+func (s *StateStore) Flush() error {
+	s.mu.Lock()
+	path := s.persistPath
+	pending := s.persistTimer != nil && s.persistTimer.Stop()
+	if pending {
+		s.persistTimer = nil
+	}
+	s.mu.Unlock()
+
+	if !pending {
+		return nil
 	}
+	return s.SaveToFile(path)
 }
@@ -2,15 +2,25 @@ package server_utils
 
 import "fmt"
 import "errors"
+import "strconv"
+import "strings"
+
+import "github.com/brianvoe/gofakeit/v6"
 
 import (
 	config "mockserver/config"
 )
 
+// MergePatchContentType selects RFC 7386 JSON Merge Patch semantics for PATCH updates.
+const MergePatchContentType = "application/merge-patch+json"
+
 var (
 	StateErrNotFound = errors.New("state: item not found")
 	StateErrConflict = errors.New("state: item already exists")
 	StateErrBadInput = errors.New("state: invalid input")
+	StateErrLimit    = errors.New("state: collection item limit reached")
+
+	StateErrInvalidQuery = errors.New("state: invalid list query")
 )
 
 func ApplyStateful(
@@ -41,6 +51,14 @@ func ApplyStateful(
 	case "create":
 		item := ctx.Body
 		idVal, ok := item[idField]
+		if !ok && cfg.AutoID != "" {
+			if item == nil {
+				item = map[string]interface{}{}
+			}
+			idVal = store.nextIDLocked(cfg.Collection, cfg.AutoID, col, idField)
+			item[idField] = idVal
+			ok = true
+		}
 		if !ok {
 			return StateErrBadInput
 		}
@@ -52,14 +70,29 @@ func ApplyStateful(
 			}
 		}
 
+		// Bounded collections protect memory from runaway clients
+		if cfg.MaxItems > 0 && len(col) >= cfg.MaxItems {
+			return StateErrLimit
+		}
+
 		col = append(col, item)
 		store.collections[cfg.Collection] = col
 
 		ctx.State.Created = item
 		ctx.State.List = col
 
+		store.notifyLocked(StateChange{Collection: cfg.Collection, Action: "create", ID: fmt.Sprint(idVal), Item: item})
+		store.schedulePersistLocked()
+
 	case "list":
-		ctx.State.List = col
+		// Query filters, sorting and pagination work like file-based mocks (?role=admin&_sort=name&_page=2&_limit=10).
+		// Sorting happens on a copy so the stored order is never changed.
+		items := append([]map[string]interface{}{}, col...)
+		filtered, err := FilteredMockData(items, ctx.Query)
+		if err != nil {
+			return fmt.Errorf("%w: %v", StateErrInvalidQuery, err)
+		}
+		ctx.State.List = filtered
 
 	case "get":
 		id := ctx.Path[idField]
@@ -75,13 +108,43 @@ func ApplyStateful(
 		id := ctx.Path[idField]
 		for i, item := range col {
 			if fmt.Sprint(item[idField]) == id {
-				for k, v := range ctx.Body {
-					item[k] = v
+				patch := withoutNullField(ctx.Body, idField)
+				if IsMergePatch(ctx) {
+					item = applyMergePatch(item, patch)
+				} else {
+					for k, v := range patch {
+						item[k] = v
+					}
 				}
 				col[i] = item
 				store.collections[cfg.Collection] = col
 
 				ctx.State.Updated = item
+
+				store.notifyLocked(StateChange{Collection: cfg.Collection, Action: "update", ID: id, Item: item})
+				store.schedulePersistLocked()
+				return nil
+			}
+		}
+		return StateErrNotFound
+
+	case "patch":
+		id := ctx.Path[idField]
+		for i, item := range col {
+			if fmt.Sprint(item[idField]) == id {
+				patch := withoutNullField(ctx.Body, idField)
+				if IsMergePatch(ctx) {
+					item = applyMergePatch(item, patch)
+				} else {
+					item = applyDeepMerge(item, patch)
+				}
+				col[i] = item
+				store.collections[cfg.Collection] = col
+
+				ctx.State.Patched = item
+
+				store.notifyLocked(StateChange{Collection: cfg.Collection, Action: "patch", ID: id, Item: item})
+				store.schedulePersistLocked()
 				return nil
 			}
 		}
@@ -107,9 +170,94 @@ func ApplyStateful(
 		store.collections[cfg.Collection] = newCol
 		ctx.State.List = newCol
 
+		store.notifyLocked(StateChange{Collection: cfg.Collection, Action: "delete", ID: id})
+		store.schedulePersistLocked()
+
 	default:
 		return fmt.Errorf("unknown stateful action: %s", cfg.Action)
 	}
 
 	return nil
 }
+
+// nextIDLocked generates an id for auto_id creates. "increment" continues after the highest
+// integer id ever issued or stored in the collection, so ids are never reused after deletes.
+// The caller must hold the write lock.
+func (s *StateStore) nextIDLocked(collection, strategy string, col []map[string]interface{}, idField string) interface{} {
+	if strategy == "uuid" {
+		return gofakeit.UUID()
+	}
+
+	if s.lastIDs == nil {
+		s.lastIDs = make(map[string]int)
+	}
+	last := s.lastIDs[collection]
+	for _, item := range col {
+		if n, err := strconv.Atoi(fmt.Sprint(item[idField])); err == nil && n > last {
+			last = n
+		}
+	}
+	last++
+	s.lastIDs[collection] = last
+	return last
+}
+
+// IsMergePatch reports whether the request is a PATCH carrying a JSON Merge Patch document.
+func IsMergePatch(ctx *EContext) bool {
+	return strings.EqualFold(ctx.Method, "PATCH") &&
+		strings.HasPrefix(strings.ToLower(ctx.Headers["content-type"]), MergePatchContentType)
+}
+
+// withoutNullField returns patch without field when it is null; a null id would otherwise
+// remove (merge patch) or blank (deep merge) the id and leave the item unreachable.
+func withoutNullField(patch map[string]interface{}, field string) map[string]interface{} {
+	if v, ok := patch[field]; !ok || v != nil {
+		return patch
+	}
+	out := make(map[string]interface{}, len(patch)-1)
+	for k, v := range patch {
+		if k != field {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// applyMergePatch merges patch into target following RFC 7386:
+// null removes the field, objects are merged recursively, any other value replaces the field.
+func applyMergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = map[string]interface{}{}
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(target, k)
+			continue
+		}
+		if patchObj, ok := v.(map[string]interface{}); ok {
+			targetObj, _ := target[k].(map[string]interface{})
+			target[k] = applyMergePatch(targetObj, patchObj)
+			continue
+		}
+		target[k] = v
+	}
+	return target
+}
+
+// applyDeepMerge merges patch into target recursively: objects present on both sides are merged,
+// any other value (arrays and null included) replaces the field.
+func applyDeepMerge(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = map[string]interface{}{}
+	}
+	for k, v := range patch {
+		patchObj, ok := v.(map[string]interface{})
+		targetObj, isObj := target[k].(map[string]interface{})
+		if ok && isObj {
+			target[k] = applyDeepMerge(targetObj, patchObj)
+			continue
+		}
+		target[k] = v
+	}
+	return target
+}
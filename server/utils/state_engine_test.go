@@ -1,7 +1,13 @@
 package server_utils
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -142,4 +148,350 @@ func TestApplyStateful_Delete(t *testing.T) {
 	ctxFail := &EContext{Path: map[string]string{"id": "999"}}
 	errFail := ApplyStateful(store, cfg, ctxFail)
 	assert.Equal(t, StateErrNotFound, errFail)
-}
\ No newline at end of file
+}
+// 5. MERGE PATCH (RFC 7386) TESTS
+func TestApplyStateful_UpdateMergePatch(t *testing.T) {
+	store := newTestStore()
+	store.collections["profiles"] = []map[string]interface{}{
+		{
+			"id":       "u1",
+			"name":     "Ada",
+			"nickname": "countess",
+			"address":  map[string]interface{}{"city": "London", "zip": "NW1"},
+		},
+	}
+
+	cfg := &config.StatefulConfig{Collection: "profiles", Action: "update", IDField: "id"}
+
+	// Scenario 1: null removes fields, nested objects merge, other values update
+	ctx := &EContext{
+		Method:  "PATCH",
+		Headers: map[string]string{"content-type": "application/merge-patch+json"},
+		Path:    map[string]string{"id": "u1"},
+		Body: map[string]interface{}{
+			"name":     "Ada Lovelace",
+			"nickname": nil,
+			"address":  map[string]interface{}{"zip": nil, "country": "UK"},
+		},
+	}
+
+	err := ApplyStateful(store, cfg, ctx)
+	require.NoError(t, err)
+
+	stored := store.collections["profiles"][0]
+	assert.Equal(t, "Ada Lovelace", stored["name"])
+	assert.NotContains(t, stored, "nickname")
+	assert.Equal(t, map[string]interface{}{"city": "London", "country": "UK"}, stored["address"])
+
+	// Scenario 2: plain JSON PATCH keeps the legacy shallow merge (null is stored)
+	ctxPlain := &EContext{
+		Method:  "PATCH",
+		Headers: map[string]string{"content-type": "application/json"},
+		Path:    map[string]string{"id": "u1"},
+		Body:    map[string]interface{}{"name": nil},
+	}
+
+	err = ApplyStateful(store, cfg, ctxPlain)
+	require.NoError(t, err)
+	assert.Contains(t, store.collections["profiles"][0], "name")
+	assert.Nil(t, store.collections["profiles"][0]["name"])
+
+	// Scenario 3: a null id is ignored so the item stays addressable
+	for _, action := range []string{"update", "patch"} {
+		ctxID := &EContext{
+			Method:  "PATCH",
+			Headers: map[string]string{"content-type": "application/merge-patch+json"},
+			Path:    map[string]string{"id": "u1"},
+			Body:    map[string]interface{}{"id": nil, "name": action},
+		}
+		err = ApplyStateful(store, &config.StatefulConfig{Collection: "profiles", Action: action, IDField: "id"}, ctxID)
+		require.NoError(t, err)
+		assert.Equal(t, "u1", store.collections["profiles"][0]["id"], action)
+		assert.Equal(t, action, store.collections["profiles"][0]["name"])
+	}
+}
+
+// 6. COLLECTION SIZE LIMIT TESTS
+func TestApplyStateful_MaxItems(t *testing.T) {
+	store := newTestStore()
+	cfg := &config.StatefulConfig{Collection: "tickets", Action: "create", IDField: "id", MaxItems: 2}
+
+	for i := 1; i <= 2; i++ {
+		require.NoError(t, ApplyStateful(store, cfg, &EContext{Body: map[string]interface{}{"id": i}}))
+	}
+
+	err := ApplyStateful(store, cfg, &EContext{Body: map[string]interface{}{"id": 3}})
+	assert.Equal(t, StateErrLimit, err)
+	assert.Len(t, store.collections["tickets"], 2, "collection must stay capped")
+
+	// A duplicate id still reports the conflict rather than the limit
+	err = ApplyStateful(store, cfg, &EContext{Body: map[string]interface{}{"id": 1}})
+	assert.Equal(t, StateErrConflict, err)
+}
+
+// 7. CHANGE NOTIFICATION TESTS
+func TestApplyStateful_Notifications(t *testing.T) {
+	store := newTestStore()
+	events, cancel := store.Subscribe(4)
+
+	create := &config.StatefulConfig{Collection: "orders", Action: "create", IDField: "id"}
+	require.NoError(t, ApplyStateful(store, create, &EContext{Body: map[string]interface{}{"id": 1, "total": 10}}))
+
+	update := &config.StatefulConfig{Collection: "orders", Action: "update", IDField: "id"}
+	require.NoError(t, ApplyStateful(store, update, &EContext{Path: map[string]string{"id": "1"}, Body: map[string]interface{}{"total": 12}}))
+
+	// Reads never notify
+	list := &config.StatefulConfig{Collection: "orders", Action: "list"}
+	require.NoError(t, ApplyStateful(store, list, &EContext{}))
+
+	remove := &config.StatefulConfig{Collection: "orders", Action: "delete", IDField: "id"}
+	require.NoError(t, ApplyStateful(store, remove, &EContext{Path: map[string]string{"id": "1"}}))
+
+	assert.Equal(t, StateChange{Collection: "orders", Action: "create", ID: "1", Item: map[string]interface{}{"id": 1, "total": 10}}, <-events)
+	assert.Equal(t, StateChange{Collection: "orders", Action: "update", ID: "1", Item: map[string]interface{}{"id": 1, "total": 12}}, <-events)
+	assert.Equal(t, StateChange{Collection: "orders", Action: "delete", ID: "1"}, <-events)
+
+	cancel()
+	_, open := <-events
+	assert.False(t, open, "cancel closes the channel")
+	cancel() // idempotent
+
+	// Unsubscribed listeners no longer receive events and never block writers
+	require.NoError(t, ApplyStateful(store, create, &EContext{Body: map[string]interface{}{"id": 2}}))
+}
+
+// 8. DEEP MERGE PATCH ACTION TESTS
+func TestApplyStateful_Patch(t *testing.T) {
+	store := newTestStore()
+	store.collections["customers"] = []map[string]interface{}{
+		{
+			"id":      "c1",
+			"name":    "Grace",
+			"tags":    []interface{}{"vip", "beta"},
+			"address": map[string]interface{}{"city": "Arlington", "zip": "22201", "geo": map[string]interface{}{"lat": 38.8, "lng": -77.1}},
+		},
+	}
+
+	cfg := &config.StatefulConfig{Collection: "customers", Action: "patch", IDField: "id"}
+
+	// Scenario 1: nested objects are merged at every level, untouched keys survive
+	ctx := &EContext{
+		Method: "PATCH",
+		Path:   map[string]string{"id": "c1"},
+		Body: map[string]interface{}{
+			"address": map[string]interface{}{"city": "X", "geo": map[string]interface{}{"lat": 40.0}},
+		},
+	}
+	require.NoError(t, ApplyStateful(store, cfg, ctx))
+
+	stored := store.collections["customers"][0]
+	assert.Equal(t, "Grace", stored["name"])
+	assert.Equal(t, map[string]interface{}{
+		"city": "X", "zip": "22201", "geo": map[string]interface{}{"lat": 40.0, "lng": -77.1},
+	}, stored["address"])
+	assert.Equal(t, stored, ctx.State.Patched)
+	assert.Nil(t, ctx.State.Updated, "patch exposes its result as state.patched only")
+
+	// Scenario 2: arrays are replaced rather than merged, objects may replace scalars
+	ctxArray := &EContext{
+		Path: map[string]string{"id": "c1"},
+		Body: map[string]interface{}{"tags": []interface{}{"churned"}, "name": map[string]interface{}{"first": "Grace"}},
+	}
+	require.NoError(t, ApplyStateful(store, cfg, ctxArray))
+	assert.Equal(t, []interface{}{"churned"}, store.collections["customers"][0]["tags"])
+	assert.Equal(t, map[string]interface{}{"first": "Grace"}, store.collections["customers"][0]["name"])
+
+	// Scenario 3: null is stored unless the body is a merge patch document
+	require.NoError(t, ApplyStateful(store, cfg, &EContext{Path: map[string]string{"id": "c1"}, Body: map[string]interface{}{"tags": nil}}))
+	assert.Contains(t, store.collections["customers"][0], "tags")
+	assert.Nil(t, store.collections["customers"][0]["tags"])
+
+	// Scenario 4: patching a missing item (Not found)
+	ctxMissing := &EContext{Path: map[string]string{"id": "c9"}, Body: map[string]interface{}{"name": "Nobody"}}
+	assert.Equal(t, StateErrNotFound, ApplyStateful(store, cfg, ctxMissing))
+	assert.Len(t, store.collections["customers"], 1)
+}
+
+// 9. PERSISTENCE TESTS
+func TestApplyStateful_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store := newTestStore()
+	require.NoError(t, store.EnablePersistence(path, 20*time.Millisecond), "a missing snapshot starts empty")
+
+	create := &config.StatefulConfig{Collection: "notes", Action: "create", IDField: "id"}
+	var wg sync.WaitGroup
+	for i := 0; i < 25; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, ApplyStateful(store, create, &EContext{Body: map[string]interface{}{"id": fmt.Sprint(i)}}))
+		}(i)
+	}
+	wg.Wait()
+
+	update := &config.StatefulConfig{Collection: "notes", Action: "update", IDField: "id"}
+	require.NoError(t, ApplyStateful(store, update, &EContext{
+		Path: map[string]string{"id": "3"},
+		Body: map[string]interface{}{"text": "kept"},
+	}))
+
+	// Scenario 1: the debounced snapshot lands on disk without an explicit save
+	require.Eventually(t, func() bool {
+		restarted := newTestStore()
+		if err := restarted.LoadFromFile(path); err != nil {
+			return false
+		}
+		items, _ := restarted.Snapshot("notes")
+		return len(items) == 25
+	}, time.Second, 10*time.Millisecond)
+
+	// Scenario 2: a "restarted" store built from the same file sees all writes
+	remove := &config.StatefulConfig{Collection: "notes", Action: "delete", IDField: "id"}
+	require.NoError(t, ApplyStateful(store, remove, &EContext{Path: map[string]string{"id": "0"}}))
+	require.NoError(t, store.Flush())
+
+	restarted := newTestStore()
+	require.NoError(t, restarted.EnablePersistence(path, time.Second))
+	items, ok := restarted.Snapshot("notes")
+	require.True(t, ok)
+	assert.Len(t, items, 24)
+
+	get := &config.StatefulConfig{Collection: "notes", Action: "get", IDField: "id"}
+	ctx := &EContext{Path: map[string]string{"id": "3"}}
+	require.NoError(t, ApplyStateful(restarted, get, ctx))
+	assert.Equal(t, "kept", ctx.State.Item["text"])
+
+	// Scenario 3: re-enabling the same path (hot reload) keeps the live data
+	require.NoError(t, ApplyStateful(restarted, remove, &EContext{Path: map[string]string{"id": "1"}}))
+	require.NoError(t, restarted.EnablePersistence(path, time.Second))
+	items, _ = restarted.Snapshot("notes")
+	assert.Len(t, items, 23)
+	require.NoError(t, restarted.Flush())
+
+	// Scenario 4: a corrupt snapshot is reported instead of silently dropped
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o644))
+	assert.Error(t, newTestStore().EnablePersistence(path, time.Second))
+
+	// Scenario 5: large numeric ids survive a restart exactly
+	require.NoError(t, os.WriteFile(path, []byte(`{"ledgers": [{"id": 12345678901234567891}]}`), 0o644))
+	restored := newTestStore()
+	require.NoError(t, restored.LoadFromFile(path))
+	ledgers, _ := restored.Snapshot("ledgers")
+	require.Len(t, ledgers, 1)
+	assert.Equal(t, json.Number("12345678901234567891"), ledgers[0]["id"])
+}
+
+// 10. AUTO ID TESTS
+func TestApplyStateful_AutoID(t *testing.T) {
+	store := newTestStore()
+
+	// Scenario 1: sequential increments start at 1 and are injected into the created item
+	inc := &config.StatefulConfig{Collection: "tasks", Action: "create", IDField: "id", AutoID: "increment"}
+	for want := 1; want <= 3; want++ {
+		ctx := &EContext{Body: map[string]interface{}{"title": fmt.Sprintf("task %d", want)}}
+		require.NoError(t, ApplyStateful(store, inc, ctx))
+		assert.Equal(t, want, ctx.State.Created["id"])
+	}
+	assert.Equal(t, 3, store.collections["tasks"][2]["id"])
+
+	// Scenario 2: client-supplied ids are kept and still conflict-checked
+	ctxClient := &EContext{Body: map[string]interface{}{"id": 10, "title": "manual"}}
+	require.NoError(t, ApplyStateful(store, inc, ctxClient))
+	assert.Equal(t, StateErrConflict, ApplyStateful(store, inc, &EContext{Body: map[string]interface{}{"id": 2}}))
+
+	// Scenario 3: increments continue after the highest id and are not reused after deletes
+	ctxNext := &EContext{Body: map[string]interface{}{}}
+	require.NoError(t, ApplyStateful(store, inc, ctxNext))
+	assert.Equal(t, 11, ctxNext.State.Created["id"])
+
+	remove := &config.StatefulConfig{Collection: "tasks", Action: "delete", IDField: "id"}
+	require.NoError(t, ApplyStateful(store, remove, &EContext{Path: map[string]string{"id": "11"}}))
+	ctxAfterDelete := &EContext{Body: map[string]interface{}{}}
+	require.NoError(t, ApplyStateful(store, inc, ctxAfterDelete))
+	assert.Equal(t, 12, ctxAfterDelete.State.Created["id"])
+
+	// Scenario 4: uuid mode produces unique 36-char ids
+	uuid := &config.StatefulConfig{Collection: "sessions", Action: "create", IDField: "sid", AutoID: "uuid"}
+	seen := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		ctx := &EContext{Body: map[string]interface{}{"n": i}}
+		require.NoError(t, ApplyStateful(store, uuid, ctx))
+		id, ok := ctx.State.Created["sid"].(string)
+		require.True(t, ok)
+		assert.Len(t, id, 36)
+		assert.False(t, seen[id], "uuid %s generated twice", id)
+		seen[id] = true
+	}
+
+	// Scenario 5: without auto_id a missing id is still bad input
+	plain := &config.StatefulConfig{Collection: "tasks", Action: "create", IDField: "id"}
+	assert.Equal(t, StateErrBadInput, ApplyStateful(store, plain, &EContext{Body: map[string]interface{}{"title": "x"}}))
+}
+
+// 11. LIST QUERY (FILTER, SORT, PAGINATE) TESTS
+func TestApplyStateful_ListQuery(t *testing.T) {
+	store := newTestStore()
+	store.collections["users"] = []map[string]interface{}{
+		{"id": "u1", "name": "Cem", "role": "admin", "age": float64(41)},
+		{"id": "u2", "name": "Ada", "role": "user", "age": float64(36)},
+		{"id": "u3", "name": "Bora", "role": "admin", "age": float64(29.5)},
+		{"id": "u4", "name": "Deniz", "role": "admin", "age": json.Number("52")},
+		{"id": "u5", "name": "Ece", "role": "admin", "age": 23},
+	}
+	cfg := &config.StatefulConfig{Collection: "users", Action: "list"}
+
+	list := func(query map[string]string) ([]map[string]interface{}, error) {
+		ctx := &EContext{Query: query}
+		err := ApplyStateful(store, cfg, ctx)
+		if err != nil {
+			return nil, err
+		}
+		return ctx.State.List, nil
+	}
+	ids := func(items []map[string]interface{}) []interface{} {
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			out[i] = item["id"]
+		}
+		return out
+	}
+
+	// Scenario 1: filter + sort + paginate combined; numbers of any stored type sort by value
+	page, err := list(map[string]string{"role": "admin", "_sort": "age", "_order": "desc", "_page": "2", "_limit": "2"})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"u3", "u5"}, ids(page))
+
+	page, err = list(map[string]string{"role": "admin", "_sort": "age", "_page": "1", "_limit": "3"})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"u5", "u3", "u1"}, ids(page))
+
+	// Scenario 2: the stored order is untouched by sorting
+	assert.Equal(t, "u1", store.collections["users"][0]["id"])
+
+	// Scenario 3: exact filters on numeric fields, including fractional float64 values
+	page, err = list(map[string]string{"age": "29.5"})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"u3"}, ids(page))
+	page, err = list(map[string]string{"age": "52"})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"u4"}, ids(page))
+	page, err = list(map[string]string{"age": "30"})
+	require.NoError(t, err)
+	assert.Empty(t, page, "29.5 must not round to 30")
+
+	// Scenario 4: out-of-range pages and empty collections give an empty (non-nil) array
+	page, err = list(map[string]string{"_page": "9", "_limit": "10"})
+	require.NoError(t, err)
+	assert.NotNil(t, page)
+	assert.Empty(t, page)
+
+	emptyCtx := &EContext{Query: map[string]string{"_page": "1", "_limit": "5"}}
+	require.NoError(t, ApplyStateful(store, &config.StatefulConfig{Collection: "nobody", Action: "list"}, emptyCtx))
+	assert.NotNil(t, emptyCtx.State.List)
+	assert.Empty(t, emptyCtx.State.List)
+
+	// Scenario 5: invalid pagination is reported as a query error
+	_, err = list(map[string]string{"_limit": "-1"})
+	assert.ErrorIs(t, err, StateErrInvalidQuery)
+}
@@ -1,6 +1,7 @@
 package server_utils
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -9,24 +10,201 @@ import (
 	"github.com/brianvoe/gofakeit/v6"
 )
 
+const (
+	DefaultTemplateOpenDelim  = "{{"
+	DefaultTemplateCloseDelim = "}}"
+)
+
+// defaultFilterRegex matches the optional fallback filter: {{request.body.x | default('N/A')}}
+var defaultFilterRegex = regexp.MustCompile(`^\|\s*default\(\s*(?:'([^']*)'|"([^"]*)")\s*\)$`)
+
+// TemplateOptions configures how template tokens are recognized and resolved.
+type TemplateOptions struct {
+	// Token delimiters (default: "{{" and "}}")
+	OpenDelim  string
+	CloseDelim string
+
+	// If true, unresolved request references are left as the literal token
+	// (legacy behavior) instead of being replaced with an empty string
+	KeepMissingRefs bool
+
+	// If true, a token that fails to evaluate is left unprocessed
+	// instead of failing the whole template
+	Lenient bool
+
+	// Source of {{global.<name>}} references (nil disables them)
+	Globals *GlobalStore
+
+	// Upper bound on the JSON nodes (objects, arrays, values) a single Process call may produce,
+	// including values inserted by references such as {{state.list}} (0 = unlimited)
+	MaxNodes int
+
+	// Fixed "now" for date helpers; when set, {{date}} and {{dateNow}} return its date
+	// and {{dateFuture days=N}} returns the date N days later (zero uses the real clock)
+	FrozenTime time.Time
+}
+
+// TemplateEngine resolves template tokens (faker, request and state references) inside JSON values.
+// The token regex is compiled once per engine.
+type TemplateEngine struct {
+	opts TemplateOptions
+	re   *regexp.Regexp
+}
+
+var defaultTemplateEngine = NewTemplateEngine(TemplateOptions{})
+
+// NewTemplateEngine builds an engine for the given options, falling back to "{{ }}" delimiters.
+func NewTemplateEngine(opts TemplateOptions) *TemplateEngine {
+	if opts.OpenDelim == "" || opts.CloseDelim == "" {
+		opts.OpenDelim = DefaultTemplateOpenDelim
+		opts.CloseDelim = DefaultTemplateCloseDelim
+	}
+
+	pattern := regexp.QuoteMeta(opts.OpenDelim) + `\s*([a-zA-Z0-9_.-]+)(.*?)` + regexp.QuoteMeta(opts.CloseDelim)
+	return &TemplateEngine{
+		opts: opts,
+		re:   regexp.MustCompile(pattern),
+	}
+}
+
+// ProcessTemplateJSON processes the template with the default "{{ }}" engine.
 func ProcessTemplateJSON(template interface{}, ctx EContext) (interface{}, error) {
+	return defaultTemplateEngine.Process(template, ctx)
+}
+
+// ErrTemplateTooLarge reports that a template produced more nodes than TemplateOptions.MaxNodes allows.
+var ErrTemplateTooLarge = errors.New("template expansion too large")
+
+// nodeBudget tracks the nodes left for one Process call; a nil budget is unlimited.
+type nodeBudget struct {
+	limit     int
+	remaining int
+}
+
+// spend consumes n nodes, failing as soon as the limit is crossed.
+func (b *nodeBudget) spend(n int) error {
+	if b == nil {
+		return nil
+	}
+	b.remaining -= n
+	if b.remaining < 0 {
+		return fmt.Errorf("%w: more than %d nodes (server.max_template_nodes)", ErrTemplateTooLarge, b.limit)
+	}
+	return nil
+}
+
+// spendInserted consumes the nodes of a value inserted in place of a template string,
+// which was already counted as one node.
+func (b *nodeBudget) spendInserted(value interface{}) error {
+	if b == nil {
+		return nil
+	}
+	return b.spend(countNodes(value, b.remaining+2) - 1)
+}
+
+// countNodes counts value and its descendants, stopping once max is reached.
+func countNodes(value interface{}, max int) int {
+	n := 1
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, item := range v {
+			if n >= max {
+				break
+			}
+			n += countNodes(item, max-n)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if n >= max {
+				break
+			}
+			n += countNodes(item, max-n)
+		}
+	case []map[string]interface{}:
+		for _, item := range v {
+			if n >= max {
+				break
+			}
+			n += countNodes(item, max-n)
+		}
+	}
+	return n
+}
+
+// Process walks the JSON value and replaces template tokens in every string.
+func (e *TemplateEngine) Process(template interface{}, ctx EContext) (interface{}, error) {
+	var budget *nodeBudget
+	if e.opts.MaxNodes > 0 {
+		budget = &nodeBudget{limit: e.opts.MaxNodes, remaining: e.opts.MaxNodes}
+	}
+	return e.process(template, ctx, budget)
+}
+
+func (e *TemplateEngine) process(template interface{}, ctx EContext, budget *nodeBudget) (interface{}, error) {
+	// Large bodies are CPU-bound, so the deadline is checked per node rather than only between steps
+	if err := ctx.err(); err != nil {
+		return nil, fmt.Errorf("template rendering stopped: %w", err)
+	}
+
 	switch t := template.(type) {
 
 	case string:
+		if err := budget.spend(1); err != nil {
+			return nil, err
+		}
 		trimmed := strings.TrimSpace(t)
-		re := regexp.MustCompile(`{{\s*([a-zA-Z0-9_.-]+)([^}]*)}}`)
+		re := e.re
+
+		// Computed value: "= request.body.price * request.body.qty". Strings that do not parse as an
+		// expression ("=== header ===", "==") fall through and are kept as written
+		if expr := strings.TrimPrefix(trimmed, ExpressionPrefix); expr != trimmed && IsExpression(expr) {
+			val, err := EvaluateExpression(expr, ctx)
+			if err != nil && e.opts.Lenient {
+				return t, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed evaluating expression '%s': %w", trimmed, err)
+			}
+			if err := budget.spendInserted(val); err != nil {
+				return nil, err
+			}
+			return val, nil
+		}
 
 		// state.xxx shortcut handling
 		if matches := re.FindStringSubmatch(trimmed); len(matches) > 1 && trimmed == matches[0] && ctx.State != nil {
+			var val interface{}
+			found := true
 			switch matches[1] {
 			case "state.list":
-				return ctx.State.List, nil
+				val = ctx.State.List
 			case "state.item":
-				return ctx.State.Item, nil
+				val = ctx.State.Item
 			case "state.created":
-				return ctx.State.Created, nil
+				val = ctx.State.Created
 			case "state.updated":
-				return ctx.State.Updated, nil
+				val = ctx.State.Updated
+			case "state.patched":
+				val = ctx.State.Patched
+			default:
+				found = false
+			}
+			if found {
+				if err := budget.spendInserted(val); err != nil {
+					return nil, err
+				}
+				return val, nil
+			}
+		}
+
+		// A template that is a single global reference keeps the value type (numbers, objects...)
+		if matches := re.FindStringSubmatch(trimmed); len(matches) > 1 && trimmed == matches[0] &&
+			strings.TrimSpace(matches[2]) == "" && strings.HasPrefix(matches[1], GlobalsPrefix) && e.opts.Globals != nil {
+			if val, ok := e.opts.Globals.Lookup(strings.TrimPrefix(matches[1], GlobalsPrefix)); ok {
+				if err := budget.spendInserted(val); err != nil {
+					return nil, err
+				}
+				return val, nil
 			}
 		}
 
@@ -42,10 +220,18 @@ func ProcessTemplateJSON(template interface{}, ctx EContext) (interface{}, error
 			// request values
 			if strings.HasPrefix(key, "request.") {
 				val, err := evalResolveValue(key, ctx)
-				if err == nil {
+				if err == nil && val != nil {
 					return fmt.Sprintf("%v", val)
 				}
-				return match
+				return e.resolveMissing(match, args)
+			}
+
+			// shared global variables
+			if strings.HasPrefix(key, GlobalsPrefix) && e.opts.Globals != nil {
+				if val, ok := e.opts.Globals.Lookup(strings.TrimPrefix(key, GlobalsPrefix)); ok && val != nil {
+					return fmt.Sprintf("%v", val)
+				}
+				return e.resolveMissing(match, args)
 			}
 
 			// Faker process
@@ -56,15 +242,26 @@ func ProcessTemplateJSON(template interface{}, ctx EContext) (interface{}, error
 				return gofakeit.UUID()
 			case "email":
 				return gofakeit.Email()
+			case "word":
+				return gofakeit.Word()
 			case "bool":
 				return fmt.Sprintf("%v", gofakeit.Bool())
 			case "date":
+				if frozen := e.opts.FrozenTime; !frozen.IsZero() {
+					return frozen.Format("2006-01-02")
+				}
 				return gofakeit.Date().Format("2006-01-02")
 			case "dateFuture":
 				days := 1
 				fmt.Sscanf(args, "days=%d", &days)
+				if frozen := e.opts.FrozenTime; !frozen.IsZero() {
+					return frozen.AddDate(0, 0, days).Format("2006-01-02")
+				}
 				return gofakeit.DateRange(time.Now(), time.Now().AddDate(0, 0, days)).Format("2006-01-02")
 			case "dateNow":
+				if frozen := e.opts.FrozenTime; !frozen.IsZero() {
+					return frozen.Format("2006-01-02")
+				}
 				return gofakeit.DateRange(time.Now(), time.Now().AddDate(0, 0, 0)).Format("2006-01-02")
 			case "number":
 				min, max := 1, 1000
@@ -78,9 +275,12 @@ func ProcessTemplateJSON(template interface{}, ctx EContext) (interface{}, error
 		return result, nil
 
 	case map[string]interface{}:
+		if err := budget.spend(1); err != nil {
+			return nil, err
+		}
 		res := make(map[string]interface{}, len(t))
 		for k, v := range t {
-			processed, err := ProcessTemplateJSON(v, ctx)
+			processed, err := e.process(v, ctx, budget)
 			if err != nil {
 				return nil, err
 			}
@@ -89,9 +289,12 @@ func ProcessTemplateJSON(template interface{}, ctx EContext) (interface{}, error
 		return res, nil
 
 	case []interface{}:
+		if err := budget.spend(1); err != nil {
+			return nil, err
+		}
 		res := make([]interface{}, len(t))
 		for i, v := range t {
-			processed, err := ProcessTemplateJSON(v, ctx)
+			processed, err := e.process(v, ctx, budget)
 			if err != nil {
 				return nil, err
 			}
@@ -100,6 +303,24 @@ func ProcessTemplateJSON(template interface{}, ctx EContext) (interface{}, error
 		return res, nil
 
 	default:
+		if err := budget.spend(1); err != nil {
+			return nil, err
+		}
 		return t, nil
 	}
 }
+
+// resolveMissing returns the replacement for an unresolved request reference:
+// the default() filter value if present, otherwise a blank or the original token.
+func (e *TemplateEngine) resolveMissing(token, args string) string {
+	if m := defaultFilterRegex.FindStringSubmatch(args); m != nil {
+		if m[1] != "" {
+			return m[1]
+		}
+		return m[2]
+	}
+	if e.opts.KeepMissingRefs {
+		return token
+	}
+	return ""
+}
@@ -1,6 +1,7 @@
 package server_utils
 
 import (
+	"context"
 	"strconv"
 	"testing"
 	"time"
@@ -15,6 +16,10 @@ func getTemplateContext() EContext {
 		Body: map[string]interface{}{
 			"username": "johndoe",
 			"role":     "admin",
+			"profile": map[string]interface{}{
+				"Address": map[string]interface{}{"city": "Berlin"},
+				"tags":    []interface{}{"a", "b"},
+			},
 		},
 		Query: map[string]string{
 			"lang": "en",
@@ -32,6 +37,7 @@ func getTemplateContext() EContext {
 			},
 			Created: map[string]interface{}{"success": true},
 			Updated: map[string]interface{}{"modified": true},
+			Patched: map[string]interface{}{"patched": true},
 		},
 	}
 }
@@ -54,6 +60,16 @@ func TestProcessTemplate_Faker(t *testing.T) {
 				assert.Len(t, s, 36)
 			},
 		},
+		{
+			name:     "Word Generation",
+			template: "{{word}}",
+			verify:   func(t *testing.T, res interface{}) {
+				s, ok := res.(string)
+				require.True(t, ok)
+				assert.NotEmpty(t, s)
+				assert.NotContains(t, s, "{{")
+			},
+		},
 		{
 			name:     "Email Generation",
 			template: "{{email}}",
@@ -109,7 +125,12 @@ func TestProcessTemplate_ContextInjection(t *testing.T) {
 		{"Inject Query", "Language: {{request.query.lang}}", "Language: en"},
 		{"Inject Headers", "Key: {{request.headers.x-api-key}}", "Key: secret-123"},
 		{"Partial Match", "User: {{request.body.username}} - Role: {{request.body.role}}", "User: johndoe - Role: admin"},
-		{"Missing Key", "Missing: {{request.body.notfound}}", "Missing: {{request.body.notfound}}"},
+		{"Missing Key", "Missing: {{request.body.notfound}}", "Missing: "},
+		{"Missing Key With Default", "Missing: {{request.body.notfound | default('N/A')}}", "Missing: N/A"},
+		{"Present Key Ignores Default", "User: {{request.body.username | default('anon')}}", "User: johndoe"},
+		{"Nested Body", "City: {{request.body.profile.address.CITY}}", "City: Berlin"},
+		{"Nested Array Index", "Tag: {{request.body.profile.tags.1}}", "Tag: b"},
+		{"Missing Nested Key", "Zip: {{request.body.profile.address.zip | default('none')}}", "Zip: none"},
 	}
 
 	for _, tt := range tests {
@@ -141,6 +162,11 @@ func TestProcessTemplate_StateShortcuts(t *testing.T) {
 	item, ok := resItem.(map[string]interface{})
 	require.True(t, ok, "state.item should return a map")
 	assert.Equal(t, 99, item["id"])
+
+	// Case 3: {{state.patched}} -> Should return the patched item
+	resPatched, err := ProcessTemplateJSON("{{state.patched}}", ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"patched": true}, resPatched)
 }
 
 // 4. RECURSIVE PROCESSING
@@ -177,4 +203,196 @@ func TestProcessTemplate_Recursion(t *testing.T) {
 
 	item1 := data[0].(map[string]interface{})
 	assert.Len(t, item1["id"], 36)
-}
\ No newline at end of file
+}
+// 5. CUSTOM DELIMITERS
+func TestProcessTemplate_CustomDelimiters(t *testing.T) {
+	ctx := getTemplateContext()
+	engine := NewTemplateEngine(TemplateOptions{OpenDelim: "<%", CloseDelim: "%>"})
+
+	input := map[string]interface{}{
+		"user":    "<% request.body.username %>",
+		"lang":    "lang=<%request.query.lang%>",
+		"literal": "Use {{request.body.username}} in your templates",
+		"list":    "<%state.list%>",
+	}
+
+	res, err := engine.Process(input, ctx)
+	require.NoError(t, err)
+
+	resMap := res.(map[string]interface{})
+	assert.Equal(t, "johndoe", resMap["user"])
+	assert.Equal(t, "lang=en", resMap["lang"])
+	assert.Equal(t, "Use {{request.body.username}} in your templates", resMap["literal"])
+	assert.Len(t, resMap["list"], 2)
+
+	// Default engine keeps the {{ }} syntax
+	def, err := NewTemplateEngine(TemplateOptions{}).Process("{{request.body.role}}", ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", def)
+}
+
+// 6. MISSING REFERENCE MODES
+func TestProcessTemplate_MissingRefModes(t *testing.T) {
+	ctx := getTemplateContext()
+
+	tests := []struct {
+		name     string
+		keep     bool
+		template string
+		expected string
+	}{
+		{"Blank Mode", false, "Hi {{request.query.name}}!", "Hi !"},
+		{"Blank Mode Default", false, `Hi {{request.query.name | default("guest")}}!`, "Hi guest!"},
+		{"Keep Mode", true, "Hi {{request.query.name}}!", "Hi {{request.query.name}}!"},
+		{"Keep Mode Default", true, "Hi {{request.query.name | default('guest')}}!", "Hi guest!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewTemplateEngine(TemplateOptions{KeepMissingRefs: tt.keep})
+			res, err := engine.Process(tt.template, ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, res)
+		})
+	}
+}
+
+// 7. COMPUTED EXPRESSIONS
+func TestProcessTemplate_Expressions(t *testing.T) {
+	ctx := EContext{
+		Body: map[string]interface{}{
+			"price":     12.5,
+			"qty":       float64(4),
+			"firstName": "Jane",
+			"lastName":  "Doe",
+		},
+		Query:   map[string]string{"discount": "5"},
+		Headers: map[string]string{"x-tax-rate": "0.2"},
+	}
+
+	template := map[string]interface{}{
+		"total":      "= request.body.price * request.body.qty",
+		"discounted": "= (request.body.price * request.body.qty - request.query.discount) * (1 + request.headers.x-tax-rate)",
+		"fullName":   "= request.body.firstName + ' ' + request.body.lastName",
+		"label":      "= 'Qty: ' + request.body.qty",
+		"negative":   "= -request.body.qty % 3",
+		"plain":      "not = an expression",
+	}
+
+	res, err := ProcessTemplateJSON(template, ctx)
+	require.NoError(t, err)
+	out := res.(map[string]interface{})
+
+	assert.Equal(t, 50.0, out["total"])
+	assert.InDelta(t, 54.0, out["discounted"], 1e-9)
+	assert.Equal(t, "Jane Doe", out["fullName"])
+	assert.Equal(t, "Qty: 4", out["label"])
+	assert.Equal(t, -1.0, out["negative"])
+	assert.Equal(t, "not = an expression", out["plain"])
+
+	for _, bad := range []string{"= request.body.firstName * 2", "= 1 / 0", "= request.body.missing + 1"} {
+		_, err := ProcessTemplateJSON(bad, ctx)
+		assert.Error(t, err, bad)
+	}
+
+	// Strings that do not parse as an expression are kept as written
+	for _, literal := range []string{"=== header ===", "==", "= (1 + 2", "=Yes", "= see docs"} {
+		res, err := ProcessTemplateJSON(literal, ctx)
+		require.NoError(t, err, literal)
+		assert.Equal(t, literal, res)
+	}
+}
+
+// 8. ERROR MODES
+func TestProcessTemplate_LenientErrors(t *testing.T) {
+	ctx := EContext{Body: map[string]interface{}{"name": "Jane", "qty": float64(2)}}
+	template := map[string]interface{}{
+		"greeting": "Hi {{request.body.name}}",
+		"total":    "= request.body.qty * 'abc'",
+		"double":   "= request.body.qty * 2",
+	}
+
+	_, err := NewTemplateEngine(TemplateOptions{}).Process(template, ctx)
+	assert.Error(t, err, "fail mode aborts on the first bad token")
+
+	res, err := NewTemplateEngine(TemplateOptions{Lenient: true}).Process(template, ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"greeting": "Hi Jane",
+		"total":    "= request.body.qty * 'abc'",
+		"double":   float64(4),
+	}, res)
+}
+
+// 9. FROZEN TIME
+func TestProcessTemplate_FrozenTime(t *testing.T) {
+	frozen := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	engine := NewTemplateEngine(TemplateOptions{FrozenTime: frozen})
+
+	res, err := engine.Process(map[string]interface{}{
+		"date":   "{{date}}",
+		"now":    "{{dateNow}}",
+		"future": "{{dateFuture days=5}}",
+	}, EContext{})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"date":   "2024-01-15",
+		"now":    "2024-01-15",
+		"future": "2024-01-20",
+	}, res)
+}
+
+// 10. EXPANSION LIMIT
+func TestProcessTemplate_MaxNodes(t *testing.T) {
+	// 1 root object + 1 array + 50 objects with 2 values each = 152 nodes
+	rows := make([]interface{}, 50)
+	for i := range rows {
+		rows[i] = map[string]interface{}{"id": "{{uuid}}", "name": "{{name}}"}
+	}
+	template := map[string]interface{}{"rows": rows}
+
+	_, err := NewTemplateEngine(TemplateOptions{MaxNodes: 152}).Process(template, EContext{})
+	assert.NoError(t, err)
+
+	_, err = NewTemplateEngine(TemplateOptions{MaxNodes: 151}).Process(template, EContext{})
+	assert.ErrorIs(t, err, ErrTemplateTooLarge)
+	assert.ErrorContains(t, err, "more than 151 nodes")
+
+	// Values inserted by references count as well
+	list := make([]map[string]interface{}, 1000)
+	for i := range list {
+		list[i] = map[string]interface{}{"id": i, "tags": []interface{}{"a", "b", "c"}}
+	}
+	ctx := EContext{State: &StateContext{List: list}}
+
+	_, err = NewTemplateEngine(TemplateOptions{MaxNodes: 100}).Process(map[string]interface{}{"items": "{{state.list}}"}, ctx)
+	assert.ErrorIs(t, err, ErrTemplateTooLarge)
+
+	res, err := NewTemplateEngine(TemplateOptions{}).Process(map[string]interface{}{"items": "{{state.list}}"}, ctx)
+	require.NoError(t, err)
+	assert.Len(t, res.(map[string]interface{})["items"], 1000, "no limit by default")
+
+	// So do values computed by "=" expressions
+	rowsCtx := EContext{Body: map[string]interface{}{"rows": rows}}
+	_, err = NewTemplateEngine(TemplateOptions{MaxNodes: 100}).Process(map[string]interface{}{"items": "= request.body.rows"}, rowsCtx)
+	assert.ErrorIs(t, err, ErrTemplateTooLarge)
+
+	res, err = NewTemplateEngine(TemplateOptions{MaxNodes: 200}).Process(map[string]interface{}{"items": "= request.body.rows"}, rowsCtx)
+	require.NoError(t, err)
+	assert.Len(t, res.(map[string]interface{})["items"], 50)
+}
+
+func TestProcessTemplate_ContextDone(t *testing.T) {
+	template := map[string]interface{}{"rows": []interface{}{map[string]interface{}{"id": "{{uuid}}"}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	res, err := NewTemplateEngine(TemplateOptions{}).Process(template, EContext{Context: ctx})
+	require.NoError(t, err)
+	assert.NotNil(t, res)
+
+	// A route timeout stops rendering instead of finishing the body
+	cancel()
+	_, err = NewTemplateEngine(TemplateOptions{}).Process(template, EContext{Context: ctx})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -1,18 +1,52 @@
 package server_utils
 
+import (
+	"context"
+	"time"
+)
 
 type StateContext struct {
 	List    []map[string]interface{}
 	Item    map[string]interface{}
 	Created map[string]interface{}
 	Updated map[string]interface{}
+	Patched map[string]interface{}
 }
 
 type EContext struct {
+	Method  string
 	Body    map[string]interface{}
 	Query   map[string]string
 	Headers map[string]string
 	Path    map[string]string
 
+	// Request body exactly as received ({{request.raw_body}}); invalid UTF-8 is replaced with U+FFFD
+	RawBody string
+
+	// Request protocol as sent on the request line ({{request.proto}}), e.g. "HTTP/1.1"
+	Proto string
+
+	// Clock for time.* conditions (nil uses the real clock; fixed under server.frozen_time)
+	Now func() time.Time
+
+	// Request context carrying the route timeout_ms deadline; template rendering stops once it is done
+	Context context.Context
+
 	State *StateContext
+}
+
+// err reports why the request context ended; nil while it is live or when there is none.
+func (c EContext) err() error {
+	if c.Context == nil {
+		return nil
+	}
+	return c.Context.Err()
+}
+
+// now returns the context's current time, falling back to the real clock.
+func (c EContext) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
 }
\ No newline at end of file
@@ -2,6 +2,7 @@ package server
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -110,6 +111,107 @@ func validateRequestParams(route msconfig.RouteConfig) fiber.Handler {
 	}
 }
 
+// requiredHeadersMiddleware enforces the route's request_headers declared as required (and their
+// enums when present). Header names match case-insensitively. Missing headers are rejected with
+// 400 MISSING_REQUIRED_HEADER naming the header.
+func requiredHeadersMiddleware(headers map[string]msconfig.ParamDef) fiber.Handler {
+	// Stable order so the reported header is deterministic when several are missing
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return func(c *fiber.Ctx) error {
+		for _, name := range names {
+			def := headers[name]
+			if !def.Required {
+				continue
+			}
+
+			raw := c.Get(name)
+			if raw == "" {
+				return responseError(c, http.StatusBadRequest, "MISSING_REQUIRED_HEADER",
+					fmt.Sprintf("Missing required header: %s", name), false)
+			}
+			if err := validateEnum(raw, def.Enum); err != nil {
+				return responseError(c, http.StatusBadRequest, "INVALID_ENUM_VALUE",
+					fmt.Sprintf("header %s: %v", name, err), false)
+			}
+		}
+		return c.Next()
+	}
+}
+
+// reservedQueryParams are the filtering/pagination controls always accepted under strict_query.
+var reservedQueryParams = map[string]bool{"_page": true, "_limit": true, "_sort": true, "_order": true}
+
+// strictQueryMiddleware rejects query params the route does not declare in query or match_query
+// with 400 UNKNOWN_QUERY_PARAM. Filtering params (_page, _limit, _sort, _order, <field>_like),
+// the debug status override and the query params carrying the route's credentials are exempt.
+func strictQueryMiddleware(route msconfig.RouteConfig, globalAuth *msconfig.AuthConfig) fiber.Handler {
+	authParams := authQueryParams(route, globalAuth)
+
+	return func(c *fiber.Ctx) error {
+		var unknown []string
+		for key := range c.Queries() {
+			if _, ok := route.Query[key]; ok {
+				continue
+			}
+			if _, ok := route.MatchQuery[key]; ok {
+				continue
+			}
+			if reservedQueryParams[key] || strings.HasSuffix(key, "_like") || key == debugStatusParam || key == variantParam {
+				continue
+			}
+			if authParams[key] {
+				continue
+			}
+			unknown = append(unknown, key)
+		}
+
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			return responseError(c, http.StatusBadRequest, "UNKNOWN_QUERY_PARAM",
+				fmt.Sprintf("Unknown query param: %s", strings.Join(unknown, ", ")), false)
+		}
+		return c.Next()
+	}
+}
+
+// authQueryParams returns the query param names read by the auth schemes in effect for the
+// route (auth_any, else the route auth, else the global auth) when they use in: query.
+func authQueryParams(route msconfig.RouteConfig, globalAuth *msconfig.AuthConfig) map[string]bool {
+	schemes := []*msconfig.AuthConfig{globalAuth}
+	if route.Auth != nil {
+		schemes = []*msconfig.AuthConfig{route.Auth}
+	}
+	if len(route.AuthAny) > 0 {
+		schemes = schemes[:0]
+		for i := range route.AuthAny {
+			schemes = append(schemes, &route.AuthAny[i])
+		}
+	}
+
+	params := map[string]bool{}
+	for _, auth := range schemes {
+		if auth != nil && auth.Enabled && strings.EqualFold(auth.In, "query") && auth.Name != "" {
+			params[auth.Name] = true
+		}
+	}
+	return params
+}
+
+// hasRequiredParam reports whether any parameter definition is marked required.
+func hasRequiredParam(params map[string]msconfig.ParamDef) bool {
+	for _, def := range params {
+		if def.Required {
+			return true
+		}
+	}
+	return false
+}
+
 // Checks raw string against type definition
 func validateType(raw, typ string) error {
 	switch strings.ToLower(typ) {
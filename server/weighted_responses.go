@@ -0,0 +1,63 @@
+package server
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// weightedResponse is a mock.responses entry with its body already resolved ($fileRef inlined).
+type weightedResponse struct {
+	weight  float64
+	status  int
+	body    interface{}
+	headers map[string]string
+}
+
+// weightedPicker selects one of the mock.responses entries per request, in proportion to their weights.
+type weightedPicker struct {
+	mu        sync.Mutex
+	rng       *rand.Rand
+	responses []weightedResponse
+	total     float64
+}
+
+// newWeightedPicker returns nil when the mock has no weighted responses.
+func newWeightedPicker(responses []weightedResponse) *weightedPicker {
+	if len(responses) == 0 {
+		return nil
+	}
+	total := 0.0
+	for _, r := range responses {
+		total += r.weight
+	}
+	return &weightedPicker{
+		rng:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		responses: responses,
+		total:     total,
+	}
+}
+
+// pick draws the response for the next request. Zero-weight entries are never chosen.
+func (p *weightedPicker) pick() *weightedResponse {
+	p.mu.Lock()
+	n := p.rng.Float64() * p.total
+	p.mu.Unlock()
+
+	for i := range p.responses {
+		if r := &p.responses[i]; r.weight > 0 {
+			if n < r.weight {
+				return r
+			}
+			n -= r.weight
+		}
+	}
+
+	// Floating point leftovers land on the last pickable entry
+	for i := len(p.responses) - 1; i >= 0; i-- {
+		if p.responses[i].weight > 0 {
+			return &p.responses[i]
+		}
+	}
+	return nil
+}
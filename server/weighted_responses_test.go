@@ -0,0 +1,33 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWeightedPicker verifies responses are picked in proportion to their weights and zero weights are skipped.
+func TestWeightedPicker(t *testing.T) {
+	const picks = 20000
+
+	p := newWeightedPicker([]weightedResponse{
+		{weight: 0, status: 418},
+		{weight: 7, status: 200},
+		{weight: 2, status: 500},
+		{weight: 1, status: 503},
+	})
+	require.NotNil(t, p)
+
+	counts := map[int]int{}
+	for i := 0; i < picks; i++ {
+		counts[p.pick().status]++
+	}
+
+	assert.Zero(t, counts[418], "zero-weight responses are never picked")
+	assert.InDelta(t, 0.7, float64(counts[200])/picks, 0.02)
+	assert.InDelta(t, 0.2, float64(counts[500])/picks, 0.02)
+	assert.InDelta(t, 0.1, float64(counts[503])/picks, 0.02)
+
+	assert.Nil(t, newWeightedPicker(nil))
+}
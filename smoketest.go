@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+import (
+	msconfig "mockserver/config"
+	msServer "mockserver/server"
+)
+
+// testResult is the outcome of a single config-defined smoke assertion.
+type testResult struct {
+	Name    string
+	Passed  bool
+	Message string
+}
+
+var testCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Run the smoke assertions defined in the config 'tests' block",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := msconfig.LoadConfig(testConfigFile)
+		if err != nil {
+			fmt.Printf("[ERROR] %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(cfg.Tests) == 0 {
+			fmt.Printf("No tests defined in '%s'\n", testConfigFile)
+			return
+		}
+
+		failed := 0
+		for _, r := range runTests(cfg, testConfigFile) {
+			if r.Passed {
+				fmt.Printf("✅ PASS  %s\n", r.Name)
+				continue
+			}
+			failed++
+			fmt.Printf("❌ FAIL  %s: %s\n", r.Name, r.Message)
+		}
+
+		fmt.Printf("\n%d passed, %d failed\n", len(cfg.Tests)-failed, failed)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// runTests builds the app in-memory and executes every assertion via app.Test,
+// without opening a network listener.
+func runTests(cfg *msconfig.Config, configPath string) []testResult {
+	app := msServer.StartServer(cfg, configPath, embedDir, faviconFS)
+
+	results := make([]testResult, 0, len(cfg.Tests))
+	for _, tc := range cfg.Tests {
+		result := testResult{Name: tc.Name}
+
+		req, err := buildTestRequest(tc)
+		if err != nil {
+			result.Message = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			result.Message = fmt.Sprintf("request failed: %v", err)
+			results = append(results, result)
+			continue
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		result.Message = checkExpectation(tc.Expect, resp.StatusCode, string(body))
+		result.Passed = result.Message == ""
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// buildTestRequest converts an assertion into an HTTP request, encoding the body as JSON.
+func buildTestRequest(tc msconfig.TestConfig) (*http.Request, error) {
+	var bodyReader io.Reader
+	if tc.Body != nil {
+		encoded, err := json.Marshal(tc.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode body: %w", err)
+		}
+		bodyReader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(tc.Method, tc.Path, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	if tc.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range tc.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// checkExpectation returns a failure description, or "" when every check passes.
+func checkExpectation(expect msconfig.TestExpectConfig, status int, body string) string {
+	var failures []string
+	if expect.Status != 0 && expect.Status != status {
+		failures = append(failures, fmt.Sprintf("expected status %d, got %d", expect.Status, status))
+	}
+	if expect.BodyContains != "" && !strings.Contains(body, expect.BodyContains) {
+		failures = append(failures, fmt.Sprintf("body does not contain '%s'", expect.BodyContains))
+	}
+	return strings.Join(failures, "; ")
+}
+
+var testConfigFile string
+
+func init() {
+	testCmd.Flags().StringVarP(&testConfigFile, "config", "c", "mockserver.json", "Path to config file")
+}
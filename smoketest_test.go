@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	msconfig "mockserver/config"
+)
+
+const smokeTestYAML = `
+server:
+  port: 8080
+  api_prefix: "/v1"
+routes:
+  - name: "Hello"
+    method: "GET"
+    path: "/hello"
+    mock:
+      status: 200
+      body: { "message": "world" }
+tests:
+  - name: "hello responds"
+    path: "/v1/hello"
+    expect:
+      status: 200
+      body_contains: "world"
+  - name: "hello wrong status"
+    method: "get"
+    path: "/v1/hello"
+    expect:
+      status: 201
+`
+
+// TestRunTests verifies config-defined assertions run in-memory and report pass/fail per assertion.
+func TestRunTests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mockserver.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(smokeTestYAML), 0644))
+
+	cfg, err := msconfig.LoadConfig(path)
+	require.NoError(t, err)
+
+	results := runTests(cfg, path)
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "hello responds", results[0].Name)
+	assert.True(t, results[0].Passed, results[0].Message)
+
+	assert.Equal(t, "hello wrong status", results[1].Name)
+	assert.False(t, results[1].Passed)
+	assert.Equal(t, "expected status 201, got 200", results[1].Message)
+}
@@ -1,15 +1,32 @@
 package tests
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
 	"embed"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
 	"mockserver/config"
 	"mockserver/server"
@@ -20,8 +37,8 @@ import (
 //go:embed server_test.go
 var testEmbedFS embed.FS
 
-//go:embed server_test.go
-var testFaviconFS embed.FS
+// The favicon middleware needs a favicon.ico to serve
+var testFaviconFS = fstest.MapFS{"favicon.ico": &fstest.MapFile{}}
 
 
 func makeRequest(method, url string, body interface{}, headers map[string]string) *http.Request {
@@ -251,3 +268,3848 @@ func TestIntegration_Fetch(t *testing.T) {
 
 	assert.Equal(t, 200, resp.StatusCode)
 }
+
+
+// 6. STATUS HEADERS TEST
+func TestIntegration_StatusHeaders(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.StatusHeaders = map[int]map[string]string{
+		429: {"Retry-After": "30"},
+		503: {"Retry-After": "120"},
+	}
+
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Rate Limited",
+			Method: "GET",
+			Path:   "/limited",
+			Cases: []config.CaseConfig{
+				{
+					When: "request.query.burst == 'true'",
+					Then: config.CResponse{
+						Status: 429,
+						Body:   map[string]interface{}{"error": "slow down"},
+					},
+				},
+				{
+					When: "request.query.burst == 'custom'",
+					Then: config.CResponse{
+						Status:  429,
+						Headers: map[string]string{"Retry-After": "5"},
+					},
+				},
+			},
+			Default: &config.CResponse{
+				Status: 200,
+				Body:   map[string]interface{}{"ok": true},
+			},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	// Scenario A: 429 receives the server-level header
+	resp429, _ := app.Test(makeRequest("GET", "/v1/limited?burst=true", nil, nil))
+	assert.Equal(t, 429, resp429.StatusCode)
+	assert.Equal(t, "30", resp429.Header.Get("Retry-After"))
+
+	// Scenario B: case-level header wins over the status header
+	respCustom, _ := app.Test(makeRequest("GET", "/v1/limited?burst=custom", nil, nil))
+	assert.Equal(t, 429, respCustom.StatusCode)
+	assert.Equal(t, "5", respCustom.Header.Get("Retry-After"))
+
+	// Scenario C: 200 does not carry the header
+	resp200, _ := app.Test(makeRequest("GET", "/v1/limited?burst=false", nil, nil))
+	assert.Equal(t, 200, resp200.StatusCode)
+	assert.Empty(t, resp200.Header.Get("Retry-After"))
+}
+
+
+// 7. MALFORMED / EMPTY BODY TEST
+func TestIntegration_MalformedBody(t *testing.T) {
+	cfg := createSafeConfig()
+
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:       "Create Item",
+			Method:     "POST",
+			Path:       "/items",
+			BodySchema: &config.JSONSchema{Type: "object"},
+			Mock: &config.MockConfig{
+				Status: 201,
+				Body:   map[string]interface{}{"created": true},
+			},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	decode := func(resp *http.Response) server.ApiError {
+		var apiErr server.ApiError
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		require.NoError(t, json.Unmarshal(bodyBytes, &apiErr))
+		return apiErr
+	}
+
+	// Scenario A: malformed JSON reports its position
+	reqBad, _ := http.NewRequest("POST", "/v1/items", bytes.NewReader([]byte("{\n  \"name\": \"x\",\n  \"qty\": }")))
+	reqBad.Header.Set("Content-Type", "application/json")
+	respBad, _ := app.Test(reqBad)
+	assert.Equal(t, 400, respBad.StatusCode)
+	badErr := decode(respBad)
+	assert.Equal(t, "MALFORMED_JSON_BODY", badErr.ErrorCode)
+	assert.Contains(t, badErr.Message, "line 3")
+	assert.Contains(t, badErr.Message, "offset 27")
+
+	// Scenario B: empty body on a route that requires one
+	reqEmpty, _ := http.NewRequest("POST", "/v1/items", nil)
+	reqEmpty.Header.Set("Content-Type", "application/json")
+	respEmpty, _ := app.Test(reqEmpty)
+	assert.Equal(t, 400, respEmpty.StatusCode)
+	emptyErr := decode(respEmpty)
+	assert.Equal(t, "EMPTY_BODY", emptyErr.ErrorCode)
+	assert.Contains(t, emptyErr.Message, "required but was empty")
+
+	// Scenario C: valid body passes through
+	respOK, _ := app.Test(makeRequest("POST", "/v1/items", map[string]interface{}{"name": "x"}, nil))
+	assert.Equal(t, 201, respOK.StatusCode)
+}
+
+
+// fetchDebugLogs reads the captured request logs from the debug endpoint.
+func fetchDebugLogs(t *testing.T, app *fiber.App, debugPath string) []map[string]interface{} {
+	t.Helper()
+	resp, err := app.Test(makeRequest("GET", debugPath+"/requests", nil, nil))
+	require.NoError(t, err)
+	var logs []map[string]interface{}
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	require.NoError(t, json.Unmarshal(bodyBytes, &logs))
+	return logs
+}
+
+// 8. DEBUG HEADER REDACTION TEST
+func TestIntegration_DebugRedactHeaders(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.Debug = &config.DebugConfig{
+		Enabled:       true,
+		Path:          "/__debug",
+		RedactHeaders: []string{"Authorization", "cookie", "X-Api-Secret"},
+	}
+
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Redact Route",
+			Method: "GET",
+			Path:   "/redact",
+			Mock:   &config.MockConfig{Status: 200, Body: map[string]interface{}{"ok": true}},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	req := makeRequest("GET", "/v1/redact", nil, map[string]string{
+		"Authorization": "Bearer top-secret",
+		"Cookie":        "session=abc",
+		"X-Api-Secret":  "shh",
+		"X-Trace-Id":    "trace-42",
+	})
+	resp, _ := app.Test(req)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var headers map[string]interface{}
+	assert.Eventually(t, func() bool {
+		for _, entry := range fetchDebugLogs(t, app, "/__debug") {
+			request := entry["request"].(map[string]interface{})
+			if request["path"] == "/v1/redact" {
+				headers, _ = request["headers"].(map[string]interface{})
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+
+	require.NotNil(t, headers)
+	assert.Equal(t, "****", headers["authorization"])
+	assert.Equal(t, "****", headers["cookie"])
+	assert.Equal(t, "****", headers["x-api-secret"])
+	assert.Equal(t, "trace-42", headers["x-trace-id"])
+}
+
+
+// 9. ECHO ROUTE TEST
+func TestIntegration_Echo(t *testing.T) {
+	cfg := createSafeConfig()
+
+	cfg.Routes = []config.RouteConfig{
+		{Name: "Echo", Method: "POST", Path: "/echo/{id}", Echo: true},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	req := makeRequest("POST", "/v1/echo/42?debug=true", map[string]interface{}{"name": "mock", "qty": 3},
+		map[string]string{"X-Client": "tests"})
+	resp, _ := app.Test(req)
+	require.Equal(t, 200, resp.StatusCode)
+
+	var echo map[string]interface{}
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	require.NoError(t, json.Unmarshal(bodyBytes, &echo))
+
+	assert.Equal(t, "POST", echo["method"])
+	assert.Equal(t, "/v1/echo/42", echo["path"])
+	assert.Equal(t, "42", echo["params"].(map[string]interface{})["id"])
+	assert.Equal(t, "true", echo["query"].(map[string]interface{})["debug"])
+	assert.Equal(t, "tests", echo["headers"].(map[string]interface{})["x-client"])
+	assert.Equal(t, "mock", echo["body"].(map[string]interface{})["name"])
+	assert.Equal(t, float64(3), echo["body"].(map[string]interface{})["qty"])
+}
+
+
+// 10. LOG OPT-OUT TEST
+func TestIntegration_LogRequestsOptOut(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.Debug = &config.DebugConfig{Enabled: true, Path: "/__debug"}
+	disabled := false
+
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:        "Silent Heartbeat",
+			Method:      "GET",
+			Path:        "/silent",
+			LogRequests: &disabled,
+			Mock:        &config.MockConfig{Status: 200, Body: map[string]interface{}{"ok": true}},
+		},
+		{
+			Name:   "Loud Route",
+			Method: "GET",
+			Path:   "/loud",
+			Mock:   &config.MockConfig{Status: 200, Body: map[string]interface{}{"ok": true}},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	respSilent, _ := app.Test(makeRequest("GET", "/v1/silent", nil, nil))
+	assert.Equal(t, 200, respSilent.StatusCode)
+	respLoud, _ := app.Test(makeRequest("GET", "/v1/loud", nil, nil))
+	assert.Equal(t, 200, respLoud.StatusCode)
+
+	var paths []string
+	assert.Eventually(t, func() bool {
+		paths = paths[:0]
+		for _, entry := range fetchDebugLogs(t, app, "/__debug") {
+			paths = append(paths, entry["request"].(map[string]interface{})["path"].(string))
+		}
+		return containsString(paths, "/v1/loud")
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NotContains(t, paths, "/v1/silent")
+}
+
+func containsString(list []string, val string) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+
+// 11. STATIC DIRECTORY TEST
+func TestIntegration_StaticDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("hello static"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "css"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "css", "app.css"), []byte("body{}"), 0644))
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Assets",
+			Method: "GET",
+			Path:   "/assets",
+			Static: &config.StaticConfig{Dir: dir},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	// Scenario A: existing file
+	resp, _ := app.Test(makeRequest("GET", "/v1/assets/hello.txt", nil, nil))
+	require.Equal(t, 200, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "hello static", string(bodyBytes))
+
+	// Scenario B: nested file keeps its content type
+	respCSS, _ := app.Test(makeRequest("GET", "/v1/assets/css/app.css", nil, nil))
+	require.Equal(t, 200, respCSS.StatusCode)
+	assert.Contains(t, respCSS.Header.Get("Content-Type"), "text/css")
+
+	// Scenario C: missing file
+	respMissing, _ := app.Test(makeRequest("GET", "/v1/assets/missing.txt", nil, nil))
+	assert.Equal(t, 404, respMissing.StatusCode)
+}
+
+
+// 12. SIZE-SCALED DELAY TEST
+func TestIntegration_DelayPerKb(t *testing.T) {
+	cfg := createSafeConfig()
+
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Small Payload",
+			Method: "GET",
+			Path:   "/small",
+			Mock:   &config.MockConfig{Status: 200, DelayPerKb: 50, Body: map[string]interface{}{"ok": true}},
+		},
+		{
+			Name:   "Large Payload",
+			Method: "GET",
+			Path:   "/large",
+			Mock: &config.MockConfig{Status: 200, DelayPerKb: 50, Body: map[string]interface{}{
+				"blob": strings.Repeat("x", 4096),
+			}},
+		},
+		{
+			Name:     "Typed Payload",
+			Method:   "GET",
+			Path:     "/typed-payload",
+			Produces: "application/vnd.api+json",
+			Mock:     &config.MockConfig{Status: 200, DelayPerKb: 1, Body: map[string]interface{}{"ok": true}},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	timed := func(url string) time.Duration {
+		start := time.Now()
+		resp, err := app.Test(makeRequest("GET", url, nil, nil), -1)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+		return time.Since(start)
+	}
+
+	small := timed("/v1/small")
+	large := timed("/v1/large")
+
+	// ~4KB at 50ms/KB => ~200ms, a few bytes => ~0ms
+	assert.Less(t, small, 100*time.Millisecond)
+	assert.GreaterOrEqual(t, large, 190*time.Millisecond)
+	assert.Greater(t, large, small)
+
+	// The route's own JSON type is kept on the serialized body
+	resp, err := app.Test(makeRequest("GET", "/v1/typed-payload", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, "application/vnd.api+json", resp.Header.Get("Content-Type"))
+}
+
+
+// 13. DEBUG LOG COALESCING TEST
+func TestIntegration_DebugCoalesceLogs(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.Debug = &config.DebugConfig{Enabled: true, Path: "/__debug", CoalesceLogs: true}
+
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Health Probe",
+			Method: "GET",
+			Path:   "/probe",
+			Mock:   &config.MockConfig{Status: 200, Body: map[string]interface{}{"ok": true}},
+		},
+		{
+			Name:   "Other",
+			Method: "GET",
+			Path:   "/other-probe",
+			Mock:   &config.MockConfig{Status: 200, Body: map[string]interface{}{"ok": true}},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	for i := 0; i < 4; i++ {
+		resp, _ := app.Test(makeRequest("GET", "/v1/probe", nil, nil))
+		require.Equal(t, 200, resp.StatusCode)
+	}
+	resp, _ := app.Test(makeRequest("GET", "/v1/other-probe", nil, nil))
+	require.Equal(t, 200, resp.StatusCode)
+
+	var probeEntries []map[string]interface{}
+	assert.Eventually(t, func() bool {
+		probeEntries = probeEntries[:0]
+		sawOther := false
+		for _, entry := range fetchDebugLogs(t, app, "/__debug") {
+			switch entry["request"].(map[string]interface{})["path"] {
+			case "/v1/probe":
+				probeEntries = append(probeEntries, entry)
+			case "/v1/other-probe":
+				sawOther = true
+			}
+		}
+		return sawOther
+	}, time.Second, 10*time.Millisecond)
+
+	require.Len(t, probeEntries, 1)
+	assert.Equal(t, float64(4), probeEntries[0]["count"])
+
+	// The merged entry spans the first to the latest request
+	first, err := time.Parse(time.RFC3339Nano, probeEntries[0]["first_time"].(string))
+	require.NoError(t, err)
+	last, err := time.Parse(time.RFC3339Nano, probeEntries[0]["time"].(string))
+	require.NoError(t, err)
+	assert.True(t, last.After(first), "time is the latest request")
+}
+
+
+// 14. FETCH TEMPLATED HEADERS TEST
+func TestIntegration_FetchTemplatedHeaders(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"authorization": r.Header.Get("Authorization"),
+			"x-static":      r.Header.Get("X-Static"),
+		})
+	}))
+	defer upstream.Close()
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Upstream Proxy",
+			Method: "GET",
+			Path:   "/upstream",
+			Fetch: &config.FetchConfig{
+				URL: upstream.URL,
+				Headers: map[string]string{
+					"Authorization": "Bearer {{request.headers.x-token}}",
+					"X-Static":      "fixed",
+				},
+			},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/upstream", nil, map[string]string{"X-Token": "client-abc"}), 5000)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+
+	var got map[string]string
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	require.NoError(t, json.Unmarshal(bodyBytes, &got))
+	assert.Equal(t, "Bearer client-abc", got["authorization"])
+	assert.Equal(t, "fixed", got["x-static"])
+}
+
+// 15. MALFORMED JSON RESPONSE TEST
+func TestIntegration_MalformedResponse(t *testing.T) {
+	cfg := createSafeConfig()
+
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Broken Payload",
+			Method: "GET",
+			Path:   "/broken",
+			Mock: &config.MockConfig{
+				Status:    200,
+				Malformed: "extra-comma",
+				Body:      map[string]interface{}{"id": 1},
+			},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/broken", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "application/json")
+
+	raw, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, `{"id":1,}`, string(raw))
+	assert.False(t, json.Valid(raw))
+}
+
+// 16. FETCH GZIP PASSTHROUGH TEST
+func TestIntegration_FetchGzipPassthrough(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		json.NewEncoder(gz).Encode(map[string]string{"message": "compressed"})
+		gz.Close()
+	}))
+	defer upstream.Close()
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Gzip Proxy",
+			Method: "GET",
+			Path:   "/gzip",
+			Fetch:  &config.FetchConfig{URL: upstream.URL},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	for _, acceptEncoding := range []string{"gzip", ""} {
+		headers := map[string]string{}
+		if acceptEncoding != "" {
+			headers["Accept-Encoding"] = acceptEncoding
+		}
+
+		resp, err := app.Test(makeRequest("GET", "/v1/gzip", nil, headers), 5000)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+		require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+
+		gz, err := gzip.NewReader(resp.Body)
+		require.NoError(t, err, "body must still be gzip-encoded to match the header")
+
+		var got map[string]string
+		require.NoError(t, json.NewDecoder(gz).Decode(&got))
+		assert.Equal(t, "compressed", got["message"])
+	}
+}
+
+// 17. ROUTE TIMEOUT TEST
+func TestIntegration_RouteTimeout(t *testing.T) {
+	cfg := createSafeConfig()
+
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:      "Slow Mock",
+			Method:    "GET",
+			Path:      "/slow",
+			TimeoutMs: 50,
+			Mock:      &config.MockConfig{Status: 200, DelayMs: 2000, Body: map[string]interface{}{"ok": true}},
+		},
+		{
+			Name:      "Fast Mock",
+			Method:    "GET",
+			Path:      "/fast",
+			TimeoutMs: 1000,
+			Mock:      &config.MockConfig{Status: 200, Body: map[string]interface{}{"ok": true}},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	start := time.Now()
+	resp, err := app.Test(makeRequest("GET", "/v1/slow", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 504, resp.StatusCode)
+	assert.Less(t, time.Since(start), time.Second, "delay must be cut short by the timeout")
+
+	var body map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&body)
+	assert.Equal(t, "ROUTE_TIMEOUT_ERROR", body["errorCode"])
+
+	resp, err = app.Test(makeRequest("GET", "/v1/fast", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+// 18. METHOD DEFAULT STATUS TEST
+func TestIntegration_MethodDefaultStatus(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.MethodDefaultStatus = map[string]int{"POST": 201, "DELETE": 204}
+
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Create User",
+			Method: "POST",
+			Path:   "/users",
+			Mock:   &config.MockConfig{Body: map[string]interface{}{"id": 1}},
+		},
+		{
+			Name:   "Create Explicit",
+			Method: "POST",
+			Path:   "/explicit",
+			Mock:   &config.MockConfig{Status: 202, Body: map[string]interface{}{"queued": true}},
+		},
+		{
+			Name:   "List Users",
+			Method: "GET",
+			Path:   "/users",
+			Mock:   &config.MockConfig{Body: []interface{}{}},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	tests := []struct {
+		method   string
+		url      string
+		expected int
+	}{
+		{"POST", "/v1/users", 201},
+		{"POST", "/v1/explicit", 202},
+		{"GET", "/v1/users", 200},
+	}
+
+	for _, tt := range tests {
+		resp, err := app.Test(makeRequest(tt.method, tt.url, map[string]interface{}{}, nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, resp.StatusCode, "%s %s", tt.method, tt.url)
+	}
+}
+
+// 19. FETCH PATH PARAMS AS QUERY TEST
+func TestIntegration_FetchPathParamsAsQuery(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"path":  r.URL.Path,
+			"query": r.URL.RawQuery,
+		})
+	}))
+	defer upstream.Close()
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "User Lookup",
+			Method: "GET",
+			Path:   "/users/{id}",
+			Fetch: &config.FetchConfig{
+				URL:               upstream.URL + "/lookup",
+				PathParamsAsQuery: map[string]string{"id": "userId"},
+			},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/users/123", nil, nil), 5000)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+
+	var got map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, "/lookup", got["path"])
+	assert.Equal(t, "userId=123", got["query"])
+}
+
+// 20. SHARED COLLECTION SCHEMA TEST
+func TestIntegration_CollectionSchema(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Collections = map[string]*config.JSONSchema{
+		"members": {
+			Type:     "object",
+			Required: []string{"id", "name"},
+			Properties: map[string]*config.JSONSchema{
+				"id":   {Type: "integer"},
+				"name": {Type: "string"},
+			},
+		},
+	}
+
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:     "Create User",
+			Method:   "POST",
+			Path:     "/members",
+			Stateful: &config.StatefulConfig{Collection: "members", Action: "create", IDField: "id"},
+			Mock:     &config.MockConfig{Status: 201, Body: "{{state.created}}"},
+		},
+		{
+			Name:     "Update User",
+			Method:   "PUT",
+			Path:     "/members/{id}",
+			Stateful: &config.StatefulConfig{Collection: "members", Action: "update", IDField: "id"},
+			Mock:     &config.MockConfig{Status: 200, Body: "{{state.updated}}"},
+		},
+		{
+			Name:     "List Users",
+			Method:   "GET",
+			Path:     "/members",
+			Stateful: &config.StatefulConfig{Collection: "members", Action: "list"},
+			Mock:     &config.MockConfig{Status: 200, Body: "{{state.list}}"},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	// Both write routes enforce the shared schema
+	resp, err := app.Test(makeRequest("POST", "/v1/members", map[string]interface{}{"id": 7}, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+
+	resp, err = app.Test(makeRequest("POST", "/v1/members", map[string]interface{}{"id": 7, "name": "Ada"}, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	resp, err = app.Test(makeRequest("PUT", "/v1/members/7", map[string]interface{}{"id": 7, "nickname": "Countess"}, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+
+	// Rejected writes never reach the store
+	resp, err = app.Test(makeRequest("GET", "/v1/members", nil, nil), -1)
+	require.NoError(t, err)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(raw), "Ada")
+	assert.NotContains(t, string(raw), "Countess")
+}
+
+// 21. NON-JSON CONTENT TYPE TEST
+func TestIntegration_ProducesContentType(t *testing.T) {
+	cfg := createSafeConfig()
+
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:     "Landing Page",
+			Method:   "GET",
+			Path:     "/landing",
+			Produces: "text/html",
+			Mock:     &config.MockConfig{Status: 200, Body: "<h1>Hello {{request.query.name}}</h1>"},
+		},
+		{
+			Name:   "Status JSON",
+			Method: "GET",
+			Path:   "/status",
+			Mock:   &config.MockConfig{Status: 200, Body: map[string]interface{}{"ok": true}},
+		},
+		{
+			Name:   "Accepted Text",
+			Method: "POST",
+			Path:   "/jobs-text",
+			Mock: &config.MockConfig{
+				Status:  202,
+				Headers: map[string]string{"Content-Type": "text/plain"},
+				Body:    "queued",
+			},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	// The HTML body is sent as-is with the configured content type
+	resp, err := app.Test(makeRequest("GET", "/v1/landing?name=Ada", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, "text/html", resp.Header.Get("Content-Type"))
+	raw, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "<h1>Hello Ada</h1>", string(raw))
+
+	// The spec documents the matching content type per route
+	resp, err = app.Test(makeRequest("GET", "/openapi.json", nil, nil), -1)
+	require.NoError(t, err)
+
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Responses map[string]struct {
+				Content map[string]interface{} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&spec))
+
+	landing := spec.Paths["/v1/landing"]["get"].Responses["200"].Content
+	assert.Contains(t, landing, "text/html")
+	assert.NotContains(t, landing, "application/json")
+
+	status := spec.Paths["/v1/status"]["get"].Responses["200"].Content
+	assert.Contains(t, status, "application/json")
+
+	// Inline mocks are documented under their own status and Content-Type header
+	jobs := spec.Paths["/v1/jobs-text"]["post"].Responses
+	assert.NotContains(t, jobs, "200")
+	require.Contains(t, jobs, "202")
+	assert.Contains(t, jobs["202"].Content, "text/plain")
+}
+
+// 22. HEADER VARIANTS TEST
+func TestIntegration_HeaderVariants(t *testing.T) {
+	cfg := createSafeConfig()
+
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Tenant Settings",
+			Method: "GET",
+			Path:   "/settings",
+			Variants: &config.VariantsConfig{
+				Header: "X-Tenant",
+				Values: map[string]config.CResponse{
+					"acme":   {Status: 200, Body: map[string]interface{}{"theme": "red"}},
+					"globex": {Status: 200, Body: map[string]interface{}{"theme": "blue"}, Headers: map[string]string{"X-Variant": "globex"}},
+				},
+			},
+			Mock: &config.MockConfig{Status: 200, Body: map[string]interface{}{"theme": "default"}},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	tests := []struct {
+		tenant   string
+		expected string
+	}{
+		{"acme", "red"},
+		{"globex", "blue"},
+		{"initech", "default"},
+		{"", "default"},
+	}
+
+	for _, tt := range tests {
+		headers := map[string]string{}
+		if tt.tenant != "" {
+			headers["X-Tenant"] = tt.tenant
+		}
+
+		resp, err := app.Test(makeRequest("GET", "/v1/settings", nil, headers), -1)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, tt.expected, body["theme"], "tenant %q", tt.tenant)
+
+		if tt.tenant == "globex" {
+			assert.Equal(t, "globex", resp.Header.Get("X-Variant"))
+		}
+	}
+}
+
+// 23. LARGE INTEGER ID PRECISION TEST
+func TestIntegration_LargeIntegerIDs(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Collections = map[string]*config.JSONSchema{
+		"ledgers": {
+			Type:     "object",
+			Required: []string{"id"},
+			Properties: map[string]*config.JSONSchema{
+				"id": {Type: "integer"},
+			},
+		},
+	}
+
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:     "Create Ledger",
+			Method:   "POST",
+			Path:     "/ledgers",
+			Stateful: &config.StatefulConfig{Collection: "ledgers", Action: "create", IDField: "id"},
+			Mock:     &config.MockConfig{Status: 201, Body: "{{state.created}}"},
+		},
+		{
+			Name:     "Get Ledger",
+			Method:   "GET",
+			Path:     "/ledgers/{id}",
+			Stateful: &config.StatefulConfig{Collection: "ledgers", Action: "get", IDField: "id"},
+			Mock:     &config.MockConfig{Status: 200, Body: "{{state.item}}"},
+		},
+		{
+			Name:   "Lookup Ledger",
+			Method: "POST",
+			Path:   "/ledgers/lookup",
+			Cases: []config.CaseConfig{
+				{
+					When: "request.body.id == 12345678901234567891",
+					Then: config.CResponse{
+						Status: 200,
+						Body:   map[string]interface{}{"id": "= request.body.id", "match": true},
+					},
+				},
+			},
+			Default: &config.CResponse{Status: 200, Body: map[string]interface{}{"match": false}},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	const bigID = "12345678901234567891"
+
+	// float64 would round this id to 12345678901234567000 (and make it equal to its neighbours)
+	resp, err := app.Test(makeRequest("POST", "/v1/ledgers", json.RawMessage(`{"id": `+bigID+`, "owner": "Ada"}`), nil), -1)
+	require.NoError(t, err)
+	require.Equal(t, 201, resp.StatusCode)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(raw), `"id":`+bigID)
+
+	resp, err = app.Test(makeRequest("GET", "/v1/ledgers/"+bigID, nil, nil), -1)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	raw, _ = io.ReadAll(resp.Body)
+	assert.Contains(t, string(raw), `"id":`+bigID)
+
+	// Conditions compare exactly: a neighbouring id must not match
+	resp, err = app.Test(makeRequest("POST", "/v1/ledgers/lookup", json.RawMessage(`{"id": `+bigID+`}`), nil), -1)
+	require.NoError(t, err)
+	raw, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"id": `+bigID+`, "match": true}`, string(raw))
+
+	resp, err = app.Test(makeRequest("POST", "/v1/ledgers/lookup", json.RawMessage(`{"id": 12345678901234567890}`), nil), -1)
+	require.NoError(t, err)
+	raw, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"match": false}`, string(raw))
+}
+
+// 24. QUERY-GUARDED ROUTES TEST
+func TestIntegration_MatchQuery(t *testing.T) {
+	cfg := createSafeConfig()
+
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Report",
+			Method: "GET",
+			Path:   "/reports",
+			Mock:   &config.MockConfig{Status: 200, Body: map[string]interface{}{"view": "full"}},
+		},
+		{
+			Name:       "Report Summary",
+			Method:     "GET",
+			Path:       "/reports",
+			MatchQuery: map[string]string{"type": "summary"},
+			Mock:       &config.MockConfig{Status: 200, Body: map[string]interface{}{"view": "summary"}},
+		},
+		{
+			Name:       "Report Summary CSV",
+			Method:     "GET",
+			Path:       "/reports",
+			MatchQuery: map[string]string{"type": "summary", "format": "csv"},
+			Mock:       &config.MockConfig{Status: 200, Body: map[string]interface{}{"view": "summary-csv"}},
+		},
+		{
+			Name:       "Archive",
+			Method:     "GET",
+			Path:       "/archive",
+			MatchQuery: map[string]string{"year": "2024"},
+			Mock:       &config.MockConfig{Status: 200, Body: map[string]interface{}{"year": 2024}},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	// Guarded routes win over the unguarded one regardless of config order; more required values win ties
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"/v1/reports", "full"},
+		{"/v1/reports?type=detail", "full"},
+		{"/v1/reports?type=summary", "summary"},
+		{"/v1/reports?type=summary&format=csv", "summary-csv"},
+		{"/v1/reports?type=summary&format=pdf", "summary"},
+	}
+
+	for _, tt := range tests {
+		resp, err := app.Test(makeRequest("GET", tt.url, nil, nil), -1)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode, tt.url)
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, tt.expected, body["view"], tt.url)
+	}
+
+	// Without an unguarded fallback a mismatch ends in 404
+	resp, err := app.Test(makeRequest("GET", "/v1/archive?year=2023", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+
+	resp, err = app.Test(makeRequest("GET", "/v1/archive?year=2024", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+// 25. SINGLE-OBJECT FILE MOCK TEST
+func TestIntegration_FileMockSingleObject(t *testing.T) {
+	dir := t.TempDir()
+	profileFile := filepath.Join(dir, "profile.json")
+	usersFile := filepath.Join(dir, "users.json")
+
+	require.NoError(t, os.WriteFile(profileFile, []byte(`{"name": "Ada", "tab": "{{request.query.tab}}"}`), 0644))
+	require.NoError(t, os.WriteFile(usersFile, []byte(`[{"id": 1, "role": "admin"}, {"id": 2, "role": "user"}]`), 0644))
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{Name: "Profile", Method: "GET", Path: "/profile", Mock: &config.MockConfig{Status: 200, File: profileFile}},
+		{Name: "Users", Method: "GET", Path: "/users", Mock: &config.MockConfig{Status: 200, File: usersFile}},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	// Top-level object without filters is returned as a processed object
+	resp, err := app.Test(makeRequest("GET", "/v1/profile", nil, nil), -1)
+	require.NoError(t, err)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"name": "Ada", "tab": ""}`, string(raw))
+
+	// Filter params keep the array behavior (the object is filtered as a one-element set)
+	resp, err = app.Test(makeRequest("GET", "/v1/profile?name=Ada", nil, nil), -1)
+	require.NoError(t, err)
+	raw, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `[{"name": "Ada", "tab": ""}]`, string(raw))
+
+	// Array fixtures still filter
+	resp, err = app.Test(makeRequest("GET", "/v1/users?role=admin", nil, nil), -1)
+	require.NoError(t, err)
+	raw, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `[{"id": 1, "role": "admin"}]`, string(raw))
+}
+
+// 26. CORS ORIGIN PATTERNS TEST
+func TestIntegration_CORSOriginPatterns(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.CORS = &config.CORSConfig{
+		Enabled: true,
+		AllowOrigins: []string{
+			"https://app.example.com",
+			"https://*.preview.example.com",
+			`regex:^https://pr-[0-9]+\.example\.org$`,
+		},
+	}
+	cfg.Routes = []config.RouteConfig{
+		{Name: "Ping", Method: "GET", Path: "/ping", Mock: &config.MockConfig{Status: 200, Body: map[string]interface{}{"ok": true}}},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://app.example.com", true},
+		{"https://feature-x.preview.example.com", true},
+		{"https://a.b.preview.example.com", true},
+		{"https://pr-42.example.org", true},
+		{"https://preview.example.com", false},
+		{"https://evil.com", false},
+		{"https://feature-x.preview.example.com.evil.com", false},
+		{"http://feature-x.preview.example.com", false},
+		{"https://pr-abc.example.org", false},
+	}
+
+	for _, tt := range tests {
+		resp, err := app.Test(makeRequest("GET", "/v1/ping", nil, map[string]string{"Origin": tt.origin}), -1)
+		require.NoError(t, err)
+
+		if tt.allowed {
+			assert.Equal(t, tt.origin, resp.Header.Get("Access-Control-Allow-Origin"), tt.origin)
+		} else {
+			assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"), tt.origin)
+		}
+	}
+}
+
+// 27. MOCK BODY $fileRef TEST
+func TestIntegration_MockBodyFileRef(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "mockserver.json")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "user.json"), []byte(`{"id": 1, "name": "Ada"}`), 0644))
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Me",
+			Method: "GET",
+			Path:   "/me",
+			Mock: &config.MockConfig{Status: 200, Body: map[string]interface{}{
+				"user":  map[string]interface{}{"$fileRef": "./user.json"},
+				"theme": "{{request.query.theme}}",
+			}},
+		},
+	}
+
+	app := server.StartServer(cfg, configPath, testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/me?theme=dark", nil, nil), -1)
+	require.NoError(t, err)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"user": {"id": 1, "name": "Ada"}, "theme": "dark"}`, string(raw))
+}
+
+// 28. RAW REQUEST BODY TEMPLATE TEST
+func TestIntegration_RawBodyTemplate(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Raw Echo",
+			Method: "POST",
+			Path:   "/raw",
+			Mock:   &config.MockConfig{Status: 200, Body: map[string]interface{}{"raw": "{{request.raw_body}}"}},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	// Formatting, key order and number spelling survive verbatim
+	postRaw := func(raw string) map[string]interface{} {
+		req, _ := http.NewRequest("POST", "/v1/raw", strings.NewReader(raw))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	// Formatting, key order and number spelling survive verbatim
+	sent := "{\n  \"b\": 1.50,\n  \"a\": [true, null, \"x\"]\n}"
+	assert.Equal(t, sent, postRaw(sent)["raw"])
+
+	// Invalid UTF-8 is replaced instead of producing broken JSON
+	assert.Equal(t, "{\"name\": \"ab\uFFFDcd\"}", postRaw("{\"name\": \"ab\xffcd\"}")["raw"])
+}
+
+// 29. SERVER PERFORMANCE TUNING TEST
+func TestIntegration_PerformanceConfig(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.Performance = &config.PerformanceConfig{ReadTimeoutMs: 1500, WriteTimeoutMs: 30000, Concurrency: 1024}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	fiberCfg := app.Config()
+	assert.Equal(t, 1500*time.Millisecond, fiberCfg.ReadTimeout)
+	assert.Equal(t, 30*time.Second, fiberCfg.WriteTimeout)
+	assert.Equal(t, 60*time.Second, fiberCfg.IdleTimeout, "idle timeout falls back to its default")
+	assert.Equal(t, 1024, fiberCfg.Concurrency)
+
+	// Negative values are rejected at load
+	bad := createSafeConfig()
+	bad.Server.Performance = &config.PerformanceConfig{WriteTimeoutMs: -1}
+	err := config.ApplyDefaults(bad, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "write_timeout_ms")
+}
+
+// 30. PROGRESSIVE ARRAY STREAMING TEST
+func TestIntegration_StreamedArray(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Feed",
+			Method: "GET",
+			Path:   "/feed",
+			Mock: &config.MockConfig{
+				Status:            200,
+				StreamItemDelayMs: 100,
+				Body: []interface{}{
+					map[string]interface{}{"id": 1},
+					map[string]interface{}{"id": 2},
+					map[string]interface{}{"id": 3},
+				},
+			},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(ln)
+	defer app.Shutdown()
+
+	start := time.Now()
+	resp, err := http.Get("http://" + ln.Addr().String() + "/v1/feed")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, 200, resp.StatusCode)
+
+	// The first element arrives before the remaining delays have elapsed
+	var raw []byte
+	var firstChunkAt time.Duration
+	buf := make([]byte, 1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 && firstChunkAt == 0 {
+			firstChunkAt = time.Since(start)
+		}
+		raw = append(raw, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+	total := time.Since(start)
+
+	assert.Less(t, firstChunkAt, 150*time.Millisecond)
+	assert.GreaterOrEqual(t, total, 200*time.Millisecond)
+
+	var items []map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &items), "streamed body must be a valid JSON array: %s", raw)
+	assert.Len(t, items, 3)
+}
+
+// 31. REQUIRED REQUEST HEADERS TEST
+func TestIntegration_RequiredRequestHeaders(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Orders",
+			Method: "GET",
+			Path:   "/orders",
+			RequestHeaders: map[string]config.ParamDef{
+				"X-Tenant-Id":   {Type: "string", Required: true},
+				"X-Api-Version": {Type: "string", Required: true, Enum: []string{"2023-01", "2024-01"}},
+				"X-Trace":       {Type: "string"},
+			},
+			Mock: &config.MockConfig{Status: 200, Body: map[string]interface{}{"ok": true}},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	call := func(headers map[string]string) (int, map[string]interface{}) {
+		resp, err := app.Test(makeRequest("GET", "/v1/orders", nil, headers), -1)
+		require.NoError(t, err)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body
+	}
+
+	// Missing header is named in the error
+	status, body := call(map[string]string{"X-Api-Version": "2024-01"})
+	assert.Equal(t, 400, status)
+	assert.Equal(t, "MISSING_REQUIRED_HEADER", body["errorCode"])
+	assert.Contains(t, body["message"], "X-Tenant-Id")
+
+	// Enum-constrained header rejects unknown values
+	status, body = call(map[string]string{"X-Tenant-Id": "acme", "X-Api-Version": "2022-01"})
+	assert.Equal(t, 400, status)
+	assert.Equal(t, "INVALID_ENUM_VALUE", body["errorCode"])
+
+	// Names match case-insensitively; optional headers may be omitted
+	status, _ = call(map[string]string{"x-tenant-id": "acme", "x-api-version": "2023-01"})
+	assert.Equal(t, 200, status)
+}
+
+// 32. OPENAPI YAML TEST
+func TestIntegration_OpenAPIYAML(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Get User",
+			Method: "GET",
+			Path:   "/users/{id}",
+			Tag:    "Users",
+			Query:  map[string]config.ParamDef{"expand": {Type: "boolean", Enum: []string{"true", "false"}}},
+			Mock:   &config.MockConfig{Status: 200, Body: map[string]interface{}{"id": 1, "name": "Ada"}},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	fetchSpec := func(path string, headers map[string]string) (string, []byte) {
+		resp, err := app.Test(makeRequest("GET", path, nil, headers), -1)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+		raw, _ := io.ReadAll(resp.Body)
+		return resp.Header.Get("Content-Type"), raw
+	}
+
+	_, rawJSON := fetchSpec("/openapi.json", nil)
+	var fromJSON map[string]interface{}
+	require.NoError(t, json.Unmarshal(rawJSON, &fromJSON))
+
+	contentType, rawYAML := fetchSpec("/openapi.yaml", nil)
+	assert.Contains(t, contentType, "yaml")
+	var fromYAML map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(rawYAML, &fromYAML))
+
+	// Round-trip both through JSON so number types compare equal
+	normalize := func(v interface{}) interface{} {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		var out interface{}
+		require.NoError(t, json.Unmarshal(data, &out))
+		return out
+	}
+	assert.Equal(t, normalize(fromJSON), normalize(fromYAML))
+
+	// Content negotiation on /openapi
+	contentType, _ = fetchSpec("/openapi", map[string]string{"Accept": "application/yaml"})
+	assert.Contains(t, contentType, "yaml")
+	contentType, _ = fetchSpec("/openapi", map[string]string{"Accept": "application/json"})
+	assert.Contains(t, contentType, "json")
+}
+
+// 33. REQUEST TRACE TEST
+func TestIntegration_RequestTrace(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.Debug.Enabled = true
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:     "Create Trace Item",
+			Method:   "POST",
+			Path:     "/trace-items",
+			Stateful: &config.StatefulConfig{Collection: "trace_items", Action: "create", IDField: "id"},
+			Cases: []config.CaseConfig{
+				{When: "request.body.kind == 'vip'", Then: config.CResponse{Status: 202, Body: map[string]interface{}{"vip": true}}},
+			},
+			Mock: &config.MockConfig{Status: 201, Body: "{{state.created}}"},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	parseTrace := func(header string) map[string]float64 {
+		stages := map[string]float64{}
+		for _, part := range strings.Split(header, ", ") {
+			name, dur, ok := strings.Cut(part, ";dur=")
+			require.True(t, ok, "malformed trace entry %q", part)
+			ms, err := strconv.ParseFloat(dur, 64)
+			require.NoError(t, err)
+			stages[name] = ms
+		}
+		return stages
+	}
+
+	resp, err := app.Test(makeRequest("POST", "/v1/trace-items", map[string]interface{}{"id": 1, "kind": "basic"},
+		map[string]string{"X-Debug-Trace": "1"}), -1)
+	require.NoError(t, err)
+	require.Equal(t, 201, resp.StatusCode)
+
+	stages := parseTrace(resp.Header.Get("X-Trace"))
+	for _, stage := range []string{"context", "stateful", "cases", "delay", "template", "send", "total"} {
+		assert.Greater(t, stages[stage], 0.0, "stage %s", stage)
+	}
+
+	// Without the request header (or outside debug mode) nothing is traced
+	resp, err = app.Test(makeRequest("POST", "/v1/trace-items", map[string]interface{}{"id": 2}, nil), -1)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Header.Get("X-Trace"))
+
+	cfg.Server.Debug.Enabled = false
+	app = server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+	resp, err = app.Test(makeRequest("POST", "/v1/trace-items", map[string]interface{}{"id": 3},
+		map[string]string{"X-Debug-Trace": "1"}), -1)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Header.Get("X-Trace"))
+}
+
+// 34. DEBUG STATUS OVERRIDE TEST
+func TestIntegration_DebugStatusOverride(t *testing.T) {
+	newApp := func(debug bool) *fiber.App {
+		cfg := createSafeConfig()
+		cfg.Server.Debug.Enabled = debug
+		cfg.Routes = []config.RouteConfig{
+			{Name: "Health", Method: "GET", Path: "/health", Mock: &config.MockConfig{Status: 200, Body: map[string]interface{}{"ok": true}}},
+		}
+		return server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+	}
+
+	call := func(app *fiber.App, url string) (int, string) {
+		resp, err := app.Test(makeRequest("GET", url, nil, nil), -1)
+		require.NoError(t, err)
+		raw, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(raw)
+	}
+
+	// Debug mode: status is overridden, body kept
+	app := newApp(true)
+	status, body := call(app, "/v1/health?__status=503")
+	assert.Equal(t, 503, status)
+	assert.JSONEq(t, `{"ok": true}`, body)
+
+	status, _ = call(app, "/v1/health?__status=abc")
+	assert.Equal(t, 200, status, "invalid values are ignored")
+
+	// Outside debug mode the parameter has no effect
+	status, _ = call(newApp(false), "/v1/health?__status=503")
+	assert.Equal(t, 200, status)
+}
+
+// 35. CASE EVALUATION ERROR DETAIL TEST
+func TestIntegration_CaseEvalErrorDetail(t *testing.T) {
+	call := func(debug bool) map[string]interface{} {
+		cfg := createSafeConfig()
+		cfg.Server.Debug.Enabled = debug
+		cfg.Routes = []config.RouteConfig{
+			{
+				Name:   "Pricing",
+				Method: "POST",
+				Path:   "/pricing",
+				Cases: []config.CaseConfig{
+					{When: "request.body.tier == 'gold'", Then: config.CResponse{Status: 200, Body: map[string]interface{}{"discount": 20}}},
+					{When: "request.body.amount >> 10", Then: config.CResponse{Status: 200, Body: map[string]interface{}{"discount": 5}}},
+				},
+				Default: &config.CResponse{Status: 200, Body: map[string]interface{}{"discount": 0}},
+			},
+		}
+		app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+		resp, err := app.Test(makeRequest("POST", "/v1/pricing", map[string]interface{}{"tier": "silver", "amount": 50}, nil), -1)
+		require.NoError(t, err)
+		require.Equal(t, 500, resp.StatusCode)
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "CASE_EVAL_ERROR", body["errorCode"])
+		return body
+	}
+
+	// Debug mode names the route, the case index and the expression
+	message := call(true)["message"].(string)
+	assert.Contains(t, message, "'Pricing'")
+	assert.Contains(t, message, "cases[1]")
+	assert.Contains(t, message, "request.body.amount >> 10")
+
+	// Otherwise the response stays generic
+	message = call(false)["message"].(string)
+	assert.Equal(t, "Failed to evaluate a route condition", message)
+}
+
+// 36. STATEFUL COLLECTION LIMIT TEST
+func TestIntegration_StateMaxItems(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.StateMaxItems = 2
+	cfg.Collections = map[string]*config.JSONSchema{
+		"seats": {Type: "object", Required: []string{"id"}},
+	}
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:     "Book Seat",
+			Method:   "POST",
+			Path:     "/seats",
+			Stateful: &config.StatefulConfig{Collection: "seats", Action: "create", IDField: "id"},
+			Mock:     &config.MockConfig{Status: 201, Body: "{{state.created}}"},
+		},
+		{
+			Name:     "List Seats",
+			Method:   "GET",
+			Path:     "/seats",
+			Stateful: &config.StatefulConfig{Collection: "seats", Action: "list"},
+			Mock:     &config.MockConfig{Status: 200, Body: "{{state.list}}"},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+	assert.Equal(t, 2, cfg.Routes[0].Stateful.MaxItems, "server default applies to routes without max_items")
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	for _, id := range []int{1, 2} {
+		resp, err := app.Test(makeRequest("POST", "/v1/seats", map[string]interface{}{"id": id}, nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, 201, resp.StatusCode)
+	}
+
+	resp, err := app.Test(makeRequest("POST", "/v1/seats", map[string]interface{}{"id": 3}, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 507, resp.StatusCode)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(raw), "STATE_LIMIT_REACHED")
+
+	resp, err = app.Test(makeRequest("GET", "/v1/seats", nil, nil), -1)
+	require.NoError(t, err)
+	var seats []map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&seats))
+	assert.Len(t, seats, 2)
+}
+
+// 37. STATE EXPORT TEST
+func TestIntegration_StateExport(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.Debug.Enabled = true
+	cfg.Collections = map[string]*config.JSONSchema{
+		"fixtures": {Type: "object", Required: []string{"id"}},
+	}
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:     "Create Fixture",
+			Method:   "POST",
+			Path:     "/fixtures",
+			Stateful: &config.StatefulConfig{Collection: "fixtures", Action: "create", IDField: "id"},
+			Mock:     &config.MockConfig{Status: 201, Body: "{{state.created}}"},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	for _, item := range []map[string]interface{}{{"id": 1, "name": "Ada"}, {"id": 2, "name": "Linus"}} {
+		resp, err := app.Test(makeRequest("POST", "/v1/fixtures", item, nil), -1)
+		require.NoError(t, err)
+		require.Equal(t, 201, resp.StatusCode)
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/__debug/state/export?collection=fixtures", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, `attachment; filename="fixtures.json"`, resp.Header.Get("Content-Disposition"))
+	raw, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `[{"id":1,"name":"Ada"},{"id":2,"name":"Linus"}]`, string(raw))
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/__debug/state/export?collection=missing_export", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/__debug/state/export", nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+// 38. AUTH ANY-OF TEST
+func TestIntegration_AuthAny(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Dual Auth",
+			Method: "GET",
+			Path:   "/dual-auth",
+			AuthAny: []config.AuthConfig{
+				{Enabled: true, Type: "apiKey", In: "header", Name: "X-Api-Key", Keys: []string{"key-123"}},
+				{Enabled: true, Type: "bearer", In: "header", Name: "Authorization", Keys: []string{"token-abc"}},
+			},
+			Mock: &config.MockConfig{Status: 200, Body: "ok"},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/dual-auth", nil, map[string]string{"X-Api-Key": "key-123"}), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode, "api key alone must pass")
+
+	resp, err = app.Test(makeRequest("GET", "/v1/dual-auth", nil, map[string]string{"Authorization": "Bearer token-abc"}), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode, "bearer token alone must pass")
+
+	resp, err = app.Test(makeRequest("GET", "/v1/dual-auth", nil, map[string]string{"Authorization": "Bearer wrong"}), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "AUTH_FAILED", body["errorCode"])
+	assert.Contains(t, body["message"], "apikey: Missing authentication credential")
+	assert.Contains(t, body["message"], "bearer: Invalid bearer token")
+}
+
+// 39. OPENAPI RESPONSE EXAMPLE TEST
+func TestIntegration_OpenAPIResponseExample(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:            "Create Order",
+			Method:          "POST",
+			Path:            "/orders",
+			Stateful:        &config.StatefulConfig{Collection: "openapi_orders", Action: "create", IDField: "id"},
+			Mock:            &config.MockConfig{Status: 201, Body: "{{state.created}}"},
+			ResponseExample: map[string]interface{}{"id": 7, "status": "pending"},
+		},
+		{
+			Name:   "Quote",
+			Method: "GET",
+			Path:   "/quote",
+			Cases: []config.CaseConfig{
+				{When: "request.query.tier == 'gold'", Then: config.CResponse{Status: 402, Body: map[string]interface{}{"error": "upgrade"}}},
+			},
+			ResponseExample: map[string]interface{}{"price": 9.5},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/openapi.json", nil, nil), -1)
+	require.NoError(t, err)
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Responses map[string]struct {
+				Content map[string]struct {
+					Example interface{} `json:"example"`
+				} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&spec))
+
+	created := spec.Paths["/v1/orders"]["post"].Responses["201"].Content["application/json"].Example
+	assert.Equal(t, map[string]interface{}{"id": float64(7), "status": "pending"}, created)
+
+	quote := spec.Paths["/v1/quote"]["get"].Responses
+	assert.Equal(t, map[string]interface{}{"price": 9.5}, quote["200"].Content["application/json"].Example)
+	assert.Contains(t, quote, "402", "case responses are kept alongside the example")
+}
+
+// 40. STRICT QUERY TEST
+func TestIntegration_StrictQuery(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:        "Strict Products",
+			Method:      "GET",
+			Path:        "/strict-products",
+			Query:       map[string]config.ParamDef{"category": {Type: "string"}},
+			StrictQuery: true,
+			Mock: &config.MockConfig{Status: 200, Body: []interface{}{
+				map[string]interface{}{"id": 1, "name": "Desk", "category": "office"},
+				map[string]interface{}{"id": 2, "name": "Lamp", "category": "home"},
+			}},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/strict-products?category=office&colour=red", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "UNKNOWN_QUERY_PARAM", body["errorCode"])
+	assert.Contains(t, body["message"], "colour")
+
+	resp, err = app.Test(makeRequest("GET", "/v1/strict-products?category=office&_page=1&_limit=5&_sort=name&_order=asc&name_like=de", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode, "declared and reserved filter params are accepted")
+}
+
+// 41. STATE CHANGE EVENTS (SSE) TEST
+func TestIntegration_StateEvents(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.Debug.Enabled = true
+	cfg.Collections = map[string]*config.JSONSchema{
+		"live_tasks": {Type: "object", Required: []string{"id"}},
+	}
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:     "Create Task",
+			Method:   "POST",
+			Path:     "/live-tasks",
+			Stateful: &config.StatefulConfig{Collection: "live_tasks", Action: "create", IDField: "id"},
+			Mock:     &config.MockConfig{Status: 201, Body: "{{state.created}}"},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(ln)
+	defer app.Shutdown()
+	base := "http://" + ln.Addr().String()
+
+	stream, err := http.Get(base + "/__debug/state/events?collection=live_tasks")
+	require.NoError(t, err)
+	defer stream.Body.Close()
+	require.Equal(t, 200, stream.StatusCode)
+	assert.Equal(t, "text/event-stream", stream.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(stream.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, ": connected\n", line, "subscription is active once the stream opens")
+
+	created, err := http.Post(base+"/v1/live-tasks", "application/json", strings.NewReader(`{"id":"t1","title":"Ship"}`))
+	require.NoError(t, err)
+	created.Body.Close()
+	require.Equal(t, 201, created.StatusCode)
+
+	var event, data string
+	deadline := time.Now().Add(2 * time.Second)
+	for data == "" && time.Now().Before(deadline) {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event: "))
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data: "))
+		}
+	}
+
+	assert.Equal(t, "create", event)
+	var change map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(data), &change))
+	assert.Equal(t, "live_tasks", change["collection"])
+	assert.Equal(t, "t1", change["id"])
+	assert.Equal(t, map[string]interface{}{"id": "t1", "title": "Ship"}, change["item"])
+}
+
+// 42. HMAC SIGNATURE AUTH TEST
+func TestIntegration_HMACAuth(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Webhook Receiver",
+			Method: "POST",
+			Path:   "/webhooks/payments",
+			Auth:   &config.AuthConfig{Enabled: true, Type: "hmac", Name: "X-Signature", Secret: "whsec_test"},
+			Mock:   &config.MockConfig{Status: 202, Body: map[string]interface{}{"received": true}},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	payload := `{"event":"payment.succeeded","amount":4200}`
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte(payload))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	send := func(sig string) *http.Response {
+		req := httptest.NewRequest("POST", "/v1/webhooks/payments", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		if sig != "" {
+			req.Header.Set("X-Signature", sig)
+		}
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		return resp
+	}
+
+	assert.Equal(t, 202, send(signature).StatusCode)
+	assert.Equal(t, 202, send("sha256="+signature).StatusCode, "webhook-style algorithm prefix is accepted")
+
+	resp := send(strings.Repeat("0", len(signature)))
+	assert.Equal(t, 401, resp.StatusCode)
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "INVALID_SIGNATURE", body["errorCode"])
+
+	assert.Equal(t, 401, send("").StatusCode)
+}
+
+// 43. TEMPLATE ERROR MODE TEST
+func TestIntegration_TemplateErrorMode(t *testing.T) {
+	newApp := func(mode string) *fiber.App {
+		cfg := createSafeConfig()
+		cfg.Server.TemplateErrorMode = mode
+		cfg.Routes = []config.RouteConfig{
+			{
+				Name:   "Invoice",
+				Method: "POST",
+				Path:   "/invoice",
+				Mock: &config.MockConfig{Status: 200, Body: map[string]interface{}{
+					"customer": "{{request.body.customer}}",
+					"total":    "= request.body.qty * request.body.customer",
+				}},
+			},
+		}
+		require.NoError(t, config.ApplyDefaults(cfg, ""))
+		return server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+	}
+	payload := map[string]interface{}{"customer": "acme", "qty": 3}
+
+	resp, err := newApp("fail").Test(makeRequest("POST", "/v1/invoice", payload, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(raw), "TEMPLATE_ERROR")
+
+	resp, err = newApp("lenient").Test(makeRequest("POST", "/v1/invoice", payload, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "acme", body["customer"])
+	assert.Equal(t, "= request.body.qty * request.body.customer", body["total"], "failing token is left unprocessed")
+}
+
+// 44. PATH NORMALIZATION MODES TEST
+func TestIntegration_NormalizePaths(t *testing.T) {
+	newApp := func(mode string) *fiber.App {
+		cfg := createSafeConfig()
+		cfg.Server.NormalizePaths = mode
+		cfg.Routes = []config.RouteConfig{
+			{
+				Name:   "Users",
+				Method: "GET",
+				Path:   "/users",
+				Mock:   &config.MockConfig{Status: 200, Body: map[string]interface{}{"q": "{{request.query.q}}"}},
+			},
+		}
+		require.NoError(t, config.ApplyDefaults(cfg, ""))
+		return server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+	}
+
+	t.Run("redirect", func(t *testing.T) {
+		resp, err := newApp("redirect").Test(httptest.NewRequest("GET", "/v1//users?q=x", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, 301, resp.StatusCode)
+		assert.Equal(t, "/v1/users?q=x", resp.Header.Get("Location"))
+	})
+
+	t.Run("rewrite", func(t *testing.T) {
+		resp, err := newApp("rewrite").Test(httptest.NewRequest("GET", "/v1//users?q=x", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Location"))
+		raw, _ := io.ReadAll(resp.Body)
+		assert.JSONEq(t, `{"q":"x"}`, string(raw))
+	})
+
+	t.Run("off", func(t *testing.T) {
+		resp, err := newApp("off").Test(httptest.NewRequest("GET", "/v1//users?q=x", nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, 404, resp.StatusCode, "paths are routed exactly as sent")
+	})
+}
+
+// 45. BODY EXAMPLE CONTRACT TEST
+func TestIntegration_BodyExampleMatchesSchema(t *testing.T) {
+	newCfg := func(example interface{}) *config.Config {
+		cfg := createSafeConfig()
+		cfg.Routes = []config.RouteConfig{
+			{
+				Name:   "Create Account",
+				Method: "POST",
+				Path:   "/accounts",
+				BodySchema: &config.JSONSchema{
+					Type:     "object",
+					Required: []string{"email", "plan"},
+					Properties: map[string]*config.JSONSchema{
+						"email": {Type: "string"},
+						"plan":  {Type: "string", Enum: []interface{}{"free", "pro"}},
+					},
+				},
+				BodyExample: example,
+				Mock:        &config.MockConfig{Status: 201, Body: map[string]interface{}{"ok": true}},
+			},
+		}
+		return cfg
+	}
+
+	err := config.ApplyDefaults(newCfg(map[string]interface{}{"email": "a@b.co", "plan": "enterprise"}), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "body_example does not match body_schema")
+
+	err = config.ApplyDefaults(newCfg(map[string]interface{}{"email": "a@b.co"}), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing required field 'plan'")
+
+	assert.NoError(t, config.ApplyDefaults(newCfg(map[string]interface{}{"email": "a@b.co", "plan": "pro"}), ""))
+}
+
+// 46. CORS CREDENTIALS ORIGIN REFLECTION TEST
+func TestIntegration_CORSCredentials(t *testing.T) {
+	newApp := func(origins []string) *fiber.App {
+		cfg := createSafeConfig()
+		cfg.Server.CORS = &config.CORSConfig{Enabled: true, AllowOrigins: origins, AllowCredentials: true}
+		cfg.Routes = []config.RouteConfig{
+			{Name: "Me", Method: "GET", Path: "/me", Mock: &config.MockConfig{Status: 200, Body: map[string]interface{}{"ok": true}}},
+		}
+		require.NoError(t, config.ApplyDefaults(cfg, ""))
+		return server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+	}
+
+	t.Run("default wildcard is rejected", func(t *testing.T) {
+		cfg := createSafeConfig()
+		cfg.Server.CORS = &config.CORSConfig{Enabled: true, AllowCredentials: true} // origins default to ["*"]
+		assert.ErrorContains(t, config.ApplyDefaults(cfg, ""), "allow_credentials")
+	})
+
+	t.Run("allowlist reflects only listed origins", func(t *testing.T) {
+		app := newApp([]string{"https://app.example.com"})
+
+		resp, err := app.Test(makeRequest("GET", "/v1/me", nil, map[string]string{"Origin": "https://app.example.com"}), -1)
+		require.NoError(t, err)
+		assert.Equal(t, "https://app.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", resp.Header.Get("Access-Control-Allow-Credentials"))
+		assert.Contains(t, resp.Header.Get("Vary"), "Origin")
+
+		resp, err = app.Test(makeRequest("GET", "/v1/me", nil, map[string]string{"Origin": "https://evil.com"}), -1)
+		require.NoError(t, err)
+		assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, resp.Header.Get("Access-Control-Allow-Credentials"))
+	})
+}
+
+// 47. FETCH REQUEST BODY TRANSFORM TEST
+func TestIntegration_FetchRequestTransform(t *testing.T) {
+	var received []byte
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer upstream.Close()
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Reshape Proxy",
+			Method: "POST",
+			Path:   "/reshape",
+			Fetch: &config.FetchConfig{
+				URL: upstream.URL,
+				RequestTransform: map[string]interface{}{
+					"full_name": "{{request.body.name}}",
+					"name":      nil,
+					"source":    "mockserver",
+					"qty":       "= request.body.qty * 2",
+				},
+			},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("POST", "/v1/reshape", map[string]interface{}{"name": "Ada", "qty": 3, "keep": true}, nil), 5000)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	assert.JSONEq(t, `{"full_name":"Ada","source":"mockserver","qty":6,"keep":true}`, string(received))
+
+	// Non-JSON bodies are forwarded untouched
+	req := httptest.NewRequest("POST", "/v1/reshape", strings.NewReader("name=Ada&qty=3"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err = app.Test(req, 5000)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "name=Ada&qty=3", string(received))
+}
+
+// 48. FETCH ERROR RESPONSE REPLACEMENT TEST
+func TestIntegration_FetchErrorResponses(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"upstream":"not here"}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"upstream":"down"}`))
+		}
+	}))
+	defer upstream.Close()
+
+	fetch := func(path string) *config.FetchConfig {
+		return &config.FetchConfig{
+			URL:        upstream.URL + path,
+			PassStatus: true,
+			ErrorResponses: map[int]interface{}{
+				404: map[string]interface{}{"code": "NOT_FOUND", "path": "{{request.path.id}}"},
+			},
+		}
+	}
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{Name: "Missing", Method: "GET", Path: "/missing/{id}", Fetch: fetch("/missing")},
+		{Name: "Down", Method: "GET", Path: "/down", Fetch: fetch("/down")},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/missing/42", nil, nil), 5000)
+	require.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"code":"NOT_FOUND","path":"42"}`, string(raw))
+
+	resp, err = app.Test(makeRequest("GET", "/v1/down", nil, nil), 5000)
+	require.NoError(t, err)
+	assert.Equal(t, 503, resp.StatusCode, "unmapped statuses pass through")
+	raw, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"upstream":"down"}`, string(raw))
+}
+
+// 49. MOCK RESPONSE COOKIES TEST
+func TestIntegration_MockCookies(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Login", Method: "POST", Path: "/login",
+			Cases: []config.CaseConfig{{
+				When: "request.body.username == 'locked'",
+				Then: config.CResponse{
+					Status:  403,
+					Body:    map[string]interface{}{"error": "locked"},
+					Cookies: []config.CookieConfig{{Name: "attempts", Value: "3", MaxAge: 60}},
+				},
+			}},
+			Mock: &config.MockConfig{
+				Body: map[string]interface{}{"ok": true},
+				Cookies: []config.CookieConfig{{
+					Name:     "session",
+					Value:    "{{request.body.username}}-token",
+					Path:     "/v1",
+					MaxAge:   3600,
+					HTTPOnly: true,
+					SameSite: "strict",
+				}},
+			},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("POST", "/v1/login", map[string]string{"username": "alice"}, nil), 5000)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	cookie := resp.Header.Get("Set-Cookie")
+	assert.Contains(t, cookie, "session=alice-token")
+	assert.Contains(t, cookie, "max-age=3600")
+	assert.Contains(t, cookie, "path=/v1")
+	assert.Contains(t, cookie, "HttpOnly")
+	assert.Contains(t, cookie, "SameSite=Strict")
+
+	resp, err = app.Test(makeRequest("POST", "/v1/login", map[string]string{"username": "locked"}, nil), 5000)
+	require.NoError(t, err)
+	assert.Equal(t, 403, resp.StatusCode)
+
+	cookie = resp.Header.Get("Set-Cookie")
+	assert.Contains(t, cookie, "attempts=3")
+	assert.Contains(t, cookie, "max-age=60")
+	assert.Len(t, resp.Header.Values("Set-Cookie"), 1, "case responses skip the mock cookies")
+}
+
+// 50. MOCK NAMED VARIANTS TEST
+func TestIntegration_MockNamedVariants(t *testing.T) {
+	variants := map[string]interface{}{
+		"empty": []interface{}{},
+		"one":   []interface{}{map[string]interface{}{"id": "{{request.query.id}}"}},
+	}
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Orders", Method: "GET", Path: "/orders",
+			Mock: &config.MockConfig{
+				Body:          []interface{}{map[string]interface{}{"id": 1}, map[string]interface{}{"id": 2}},
+				NamedVariants: variants,
+				VariantQuery:  true,
+			},
+		},
+		{
+			Name: "Invoices", Method: "GET", Path: "/invoices",
+			Mock: &config.MockConfig{
+				Body:          []interface{}{map[string]interface{}{"id": 1}},
+				NamedVariants: variants,
+			},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	get := func(url string) (int, string) {
+		resp, err := app.Test(makeRequest("GET", url, nil, nil), 5000)
+		require.NoError(t, err)
+		raw, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(raw)
+	}
+
+	status, body := get("/v1/orders")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `[{"id":1},{"id":2}]`, body)
+
+	status, body = get("/v1/orders?__variant=empty")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `[]`, body)
+
+	status, body = get("/v1/orders?__variant=one&id=7")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `[{"id":"7"}]`, body)
+
+	status, body = get("/v1/orders?__variant=missing")
+	assert.Equal(t, 400, status)
+	assert.Contains(t, body, "UNKNOWN_VARIANT")
+	assert.Contains(t, body, "empty, one")
+
+	// Without variant_query the override needs debug mode
+	status, body = get("/v1/invoices?__variant=empty")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `[{"id":1}]`, body)
+
+	cfg.Server.Debug.Enabled = true
+	app = server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+	status, body = get("/v1/invoices?__variant=empty")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `[]`, body)
+}
+
+// 51. CUSTOM STATEFUL ERROR RESPONSES TEST
+func TestIntegration_StatefulCustomErrors(t *testing.T) {
+	responses := &config.StatefulResponses{
+		Conflict: &config.CResponse{
+			Body:    map[string]interface{}{"type": "duplicate", "detail": "Account {{request.body.id}} is already registered"},
+			Headers: map[string]string{"X-Error-Kind": "conflict"},
+		},
+		NotFound: &config.CResponse{
+			Status: 410,
+			Body:   map[string]interface{}{"type": "gone", "id": "{{request.path.id}}"},
+		},
+	}
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Create Account", Method: "POST", Path: "/accounts",
+			Stateful: &config.StatefulConfig{Collection: "custom_error_accounts", Action: "create", IDField: "id", Responses: responses},
+			Mock:     &config.MockConfig{Status: 201, Body: "{{state.created}}"},
+			BodySchema: &config.JSONSchema{
+				Type:       "object",
+				Properties: map[string]*config.JSONSchema{"id": {Type: "string"}, "owner": {Type: "string"}},
+			},
+		},
+		{
+			Name: "Get Account", Method: "GET", Path: "/accounts/{id}",
+			Stateful: &config.StatefulConfig{Collection: "custom_error_accounts", Action: "get", IDField: "id", Responses: responses},
+			Mock:     &config.MockConfig{Body: "{{state.item}}"},
+		},
+		{
+			Name: "Delete Account", Method: "DELETE", Path: "/accounts/{id}",
+			Stateful: &config.StatefulConfig{Collection: "custom_error_accounts", Action: "delete", IDField: "id"},
+			Mock:     &config.MockConfig{Status: 204, Body: map[string]interface{}{}},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	account := map[string]interface{}{"id": "acc-1", "owner": "Ada"}
+	resp, err := app.Test(makeRequest("POST", "/v1/accounts", account, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	// Duplicate id: configured conflict body, default 409 status
+	resp, err = app.Test(makeRequest("POST", "/v1/accounts", account, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 409, resp.StatusCode)
+	assert.Equal(t, "conflict", resp.Header.Get("X-Error-Kind"))
+	raw, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"type":"duplicate","detail":"Account acc-1 is already registered"}`, string(raw))
+
+	resp, err = app.Test(makeRequest("GET", "/v1/accounts/acc-2", nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 410, resp.StatusCode)
+	raw, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"type":"gone","id":"acc-2"}`, string(raw))
+
+	// Routes without stateful.responses keep the built-in body
+	resp, err = app.Test(makeRequest("DELETE", "/v1/accounts/acc-2", nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+	raw, _ = io.ReadAll(resp.Body)
+	assert.Contains(t, string(raw), "STATE_NOT_FOUND")
+}
+
+// 52. ROUTE QUOTA TEST
+func TestIntegration_RouteQuota(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.Debug.Enabled = true
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Quota Reports", Method: "GET", Path: "/reports",
+			Quota: &config.QuotaConfig{Max: 3},
+			Mock:  &config.MockConfig{Body: map[string]interface{}{"ok": true}},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	for i := 0; i < 3; i++ {
+		resp, err := app.Test(makeRequest("GET", "/v1/reports", nil, nil))
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode, "request %d is within the quota", i+1)
+		assert.Equal(t, strconv.Itoa(2-i), resp.Header.Get("X-Quota-Remaining"))
+	}
+
+	resp, err := app.Test(makeRequest("GET", "/v1/reports", nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Retry-After"), "total quotas have no window to wait for")
+	raw, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(raw), "QUOTA_EXCEEDED")
+
+	resp, err = app.Test(httptest.NewRequest("POST", "/__debug/quota/reset?route=Quota%20Reports", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	raw, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"success":true,"route":"Quota Reports","reset":1}`, string(raw))
+
+	resp, err = app.Test(makeRequest("GET", "/v1/reports", nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode, "quota is available again after a reset")
+}
+
+// 53. BASIC AUTH TEST
+func TestIntegration_BasicAuth(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Basic Secured",
+			Method: "GET",
+			Path:   "/basic",
+			Auth:   &config.AuthConfig{Enabled: true, Type: "basic", Keys: []string{"ops:s3cret", "ci:token:with:colons"}},
+			Mock:   &config.MockConfig{Status: 200, Body: map[string]interface{}{"ok": true}},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	basic := func(userPass string) map[string]string {
+		return map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(userPass))}
+	}
+
+	// Scenario 1: No credentials (Fail + challenge)
+	resp, err := app.Test(makeRequest("GET", "/v1/basic", nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+	assert.Equal(t, `Basic realm="mockserver"`, resp.Header.Get("WWW-Authenticate"))
+
+	// Scenario 2: Wrong password (Fail)
+	resp, err = app.Test(makeRequest("GET", "/v1/basic", nil, basic("ops:wrong")))
+	require.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("WWW-Authenticate"))
+	raw, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(raw), "INVALID_BASIC_CREDENTIALS")
+
+	// Scenario 3: Not base64 (Fail)
+	resp, err = app.Test(makeRequest("GET", "/v1/basic", nil, map[string]string{"Authorization": "Basic !!!"}))
+	require.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	// Scenario 4: Valid pairs (Success), passwords may contain ':'
+	for _, userPass := range []string{"ops:s3cret", "ci:token:with:colons"} {
+		resp, err = app.Test(makeRequest("GET", "/v1/basic", nil, basic(userPass)))
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode, userPass)
+		assert.Empty(t, resp.Header.Get("WWW-Authenticate"))
+	}
+}
+
+// 54. ROUTES FROM OPENAPI TEST
+func TestIntegration_RoutesFromOpenAPI(t *testing.T) {
+	spec := `
+openapi: 3.0.3
+info: {title: Pets, version: "1.0"}
+servers:
+  - url: https://api.example.com/v2
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      responses:
+        "404": {description: missing}
+        "200":
+          description: ok
+          content:
+            application/json:
+              example: {id: 7, name: Rex, tags: [good]}
+  /owners:
+    post:
+      responses:
+        "201":
+          description: created
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Owner"}
+components:
+  schemas:
+    Owner:
+      type: object
+      properties:
+        email: {type: string, format: email}
+        active: {type: boolean}
+        age: {type: integer, minimum: 18, maximum: 120}
+        score: {type: number, minimum: 0.5, maximum: 0.9}
+        created_at: {type: string, format: date-time}
+        nickname: {type: string}
+`
+	specPath := filepath.Join(t.TempDir(), "openapi.yaml")
+	require.NoError(t, os.WriteFile(specPath, []byte(spec), 0o644))
+
+	cfg, err := config.LoadOpenAPI(specPath)
+	require.NoError(t, err)
+	require.Len(t, cfg.Routes, 2)
+	assert.Equal(t, "/v2", cfg.Server.APIPrefix, "server URL path becomes the api prefix")
+	assert.Equal(t, 5000, cfg.Server.Port, "the port comes from the server defaults")
+
+	app := server.StartServer(cfg, specPath, testEmbedFS, testFaviconFS)
+
+	// Scenario 1: Example-driven response from the lowest 2xx code
+	resp, err := app.Test(makeRequest("GET", "/v2/pets/7", nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"id":7,"name":"Rex","tags":["good"]}`, string(raw))
+
+	// Scenario 2: Schema-derived faker data through a $ref
+	resp, err = app.Test(makeRequest("POST", "/v2/owners", nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	var owner map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&owner))
+	assert.Contains(t, owner["email"], "@")
+	assert.Equal(t, true, owner["active"], "booleans are typed literals")
+	assert.Equal(t, 18.0, owner["age"], "integers are typed literals within the bounds")
+	assert.Equal(t, 0.9, owner["score"])
+	_, err = time.Parse(time.RFC3339, owner["created_at"].(string))
+	assert.NoError(t, err, "date-time fields are full timestamps")
+	assert.NotEmpty(t, owner["nickname"])
+	assert.NotEqual(t, "string", owner["nickname"], "plain strings get faker words")
+
+	// Scenario 3: Non-OpenAPI documents are rejected
+	badPath := filepath.Join(t.TempDir(), "swagger.json")
+	require.NoError(t, os.WriteFile(badPath, []byte(`{"swagger":"2.0","paths":{}}`), 0o644))
+	_, err = config.LoadOpenAPI(badPath)
+	assert.ErrorContains(t, err, "only OpenAPI 3.x")
+}
+
+// 55. RESPONSE FIELD MASKING TEST
+func TestIntegration_ResponseMask(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Masked Payments", Method: "GET", Path: "/payments",
+			Mask: []config.MaskRule{
+				{Field: "card.number", Strategy: "partial"},
+				{Field: "card.cvv"},
+				{Field: "history.iban", Strategy: "hash"},
+			},
+			Mock: &config.MockConfig{Body: map[string]interface{}{
+				"id":   "pay_1",
+				"card": map[string]interface{}{"number": "4111111111111111", "cvv": "123", "brand": "visa"},
+				"history": []interface{}{
+					map[string]interface{}{"iban": "DE89370400440532013000", "amount": 10},
+				},
+			}},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/payments", nil, nil))
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+	card := body["card"].(map[string]interface{})
+	assert.Equal(t, "************1111", card["number"], "partial keeps the last 4 characters")
+	assert.Equal(t, "********", card["cvv"])
+	assert.Equal(t, "visa", card["brand"], "unmasked fields are untouched")
+	assert.Equal(t, "pay_1", body["id"])
+
+	entry := body["history"].([]interface{})[0].(map[string]interface{})
+	assert.Len(t, entry["iban"], 64, "hash is a SHA-256 hex digest")
+	assert.NotEqual(t, "DE89370400440532013000", entry["iban"])
+	assert.EqualValues(t, 10, entry["amount"])
+
+	// Invalid strategies are rejected at load time
+	bad := createSafeConfig()
+	bad.Routes = []config.RouteConfig{{
+		Name: "Bad Mask", Method: "GET", Path: "/bad",
+		Mask: []config.MaskRule{{Field: "card", Strategy: "blur"}},
+		Mock: &config.MockConfig{Body: map[string]interface{}{}},
+	}}
+	assert.ErrorContains(t, config.ApplyDefaults(bad, ""), "mask[0].strategy")
+}
+
+// 56. GLOBAL VARIABLES TEST
+func TestIntegration_Globals(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.Debug.Enabled = true
+	cfg.Server.Globals = map[string]interface{}{
+		"token":  "tok-1",
+		"limits": map[string]interface{}{"max": 5},
+	}
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Session", Method: "GET", Path: "/session",
+			Mock: &config.MockConfig{Body: map[string]interface{}{
+				"token":  "{{global.token}}",
+				"header": "Bearer {{global.token}}",
+				"max":    "{{global.limits.max}}",
+			}},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	session := func() map[string]interface{} {
+		resp, err := app.Test(makeRequest("GET", "/v1/session", nil, nil))
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	// Scenario 1: Config values are injected, whole-token references keep their type
+	body := session()
+	assert.Equal(t, "tok-1", body["token"])
+	assert.Equal(t, "Bearer tok-1", body["header"])
+	assert.EqualValues(t, 5, body["max"])
+
+	// Scenario 2: The debug endpoint rotates a value for subsequent requests
+	resp, err := app.Test(makeRequest("PUT", "/__debug/globals", map[string]interface{}{"token": "tok-2"}, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"success":true,"globals":{"token":"tok-2","limits":{"max":5}}}`, string(raw))
+
+	body = session()
+	assert.Equal(t, "tok-2", body["token"])
+	assert.Equal(t, "Bearer tok-2", body["header"])
+
+	// Scenario 3: Invalid updates are rejected
+	resp, err = app.Test(makeRequest("PUT", "/__debug/globals", []string{"token"}, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	resp, err = app.Test(makeRequest("PUT", "/__debug/globals", map[string]interface{}{"a.b": 1}, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/__debug/globals", nil))
+	require.NoError(t, err)
+	raw, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"success":true,"globals":{"token":"tok-2","limits":{"max":5}}}`, string(raw))
+}
+
+// 57. ABORTED PARTIAL RESPONSE TEST
+func TestIntegration_MockAbortAfterBytes(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Interrupted", Method: "GET", Path: "/interrupted",
+			Mock: &config.MockConfig{AbortAfterBytes: 10, Body: map[string]interface{}{"message": "this body is cut short"}},
+		},
+		{
+			Name: "Short Body", Method: "GET", Path: "/short",
+			Mock: &config.MockConfig{AbortAfterBytes: 100, Body: map[string]interface{}{"ok": true}},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(ln)
+	defer app.Shutdown()
+
+	// Scenario 1: Headers announce the full body, only the first bytes arrive, then the connection drops
+	resp, err := http.Get("http://" + ln.Addr().String() + "/v1/interrupted")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.EqualValues(t, len(`{"message":"this body is cut short"}`), resp.ContentLength)
+
+	raw, err := io.ReadAll(resp.Body)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, `{"message"`, string(raw))
+
+	// Scenario 2: Bodies within the limit are sent complete
+	resp, err = http.Get("http://" + ln.Addr().String() + "/v1/short")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	raw, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ok":true}`, string(raw))
+}
+
+// 58. STATEFUL LIST QUERY TEST
+func TestIntegration_StatefulListQuery(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Create Member", Method: "POST", Path: "/list-members",
+			BodySchema: &config.JSONSchema{Type: "object"},
+			Stateful:   &config.StatefulConfig{Collection: "list_query_members", Action: "create", IDField: "id"},
+			Mock:       &config.MockConfig{Status: 201, Body: "{{state.created}}"},
+		},
+		{
+			Name: "List Members", Method: "GET", Path: "/list-members",
+			Stateful: &config.StatefulConfig{Collection: "list_query_members", Action: "list"},
+			Mock:     &config.MockConfig{Body: "{{state.list}}"},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	for i, role := range []string{"admin", "user", "admin", "admin"} {
+		member := map[string]interface{}{"id": i + 1, "role": role, "score": 10 * (i + 1)}
+		resp, err := app.Test(makeRequest("POST", "/v1/list-members", member, nil))
+		require.NoError(t, err)
+		require.Equal(t, 201, resp.StatusCode)
+	}
+
+	resp, err := app.Test(makeRequest("GET", "/v1/list-members?role=admin&_sort=score&_order=desc&_page=1&_limit=2", nil, nil))
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `[{"id":4,"role":"admin","score":40},{"id":3,"role":"admin","score":30}]`, string(raw))
+
+	resp, err = app.Test(makeRequest("GET", "/v1/list-members?_page=0&_limit=2", nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+	raw, _ = io.ReadAll(resp.Body)
+	assert.Contains(t, string(raw), "INVALID_QUERY")
+}
+
+// 59. SERVER-TIMING HEADER TEST
+func TestIntegration_ServerTiming(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.ServerTiming = true
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Timed", Method: "GET", Path: "/timed",
+			Mock: &config.MockConfig{DelayMs: 30, Body: map[string]interface{}{"id": "{{uuid}}"}},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/timed", nil, nil), -1)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+
+	header := resp.Header.Get("Server-Timing")
+	require.NotEmpty(t, header)
+	assert.Empty(t, resp.Header.Get("X-Trace"), "the debug trace header stays opt-in")
+
+	metrics := map[string]float64{}
+	for _, part := range strings.Split(header, ", ") {
+		name, dur, ok := strings.Cut(part, ";dur=")
+		require.True(t, ok, "malformed Server-Timing entry %q", part)
+		ms, err := strconv.ParseFloat(dur, 64)
+		require.NoError(t, err)
+		metrics[name] = ms
+	}
+
+	assert.GreaterOrEqual(t, metrics["delay"], 30.0, "delay covers the configured delay_ms")
+	assert.Less(t, metrics["delay"], 1000.0)
+	assert.Greater(t, metrics["template"], 0.0)
+	assert.GreaterOrEqual(t, metrics["total"], metrics["delay"]+metrics["template"])
+
+	// Disabled by default
+	cfg.Server.ServerTiming = false
+	app = server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+	resp, err = app.Test(makeRequest("GET", "/v1/timed", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Header.Get("Server-Timing"))
+}
+
+// 60. FROZEN TIME TEST
+func TestIntegration_FrozenTime(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.FrozenTime = "2024-01-15T10:00:00Z"
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Frozen", Method: "GET", Path: "/frozen",
+			Mock: &config.MockConfig{Body: map[string]interface{}{"today": "{{date}}", "due": "{{dateFuture days=5}}"}},
+			Cases: []config.CaseConfig{
+				{
+					When: "time.year == 2024 AND time.hour == 10",
+					Then: config.CResponse{Status: 200, Body: map[string]interface{}{"today": "{{dateNow}}", "due": "{{dateFuture days=5}}", "matched": true}},
+				},
+			},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/frozen", nil, nil), -1)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, map[string]interface{}{"today": "2024-01-15", "due": "2024-01-20", "matched": true}, body)
+
+	// Error timestamps use the frozen clock as well
+	resp, err = app.Test(makeRequest("GET", "/v1/missing", nil, nil), -1)
+	require.NoError(t, err)
+	require.Equal(t, 404, resp.StatusCode)
+
+	var apiErr map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&apiErr))
+	assert.Equal(t, float64(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).UnixMilli()), apiErr["timestamp"])
+
+	// Without frozen_time the real clock is restored
+	cfg.Server.FrozenTime = ""
+	realApp := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+	resp, err = realApp.Test(makeRequest("GET", "/v1/missing", nil, nil), -1)
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&apiErr))
+	assert.Greater(t, apiErr["timestamp"], float64(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()))
+
+	// The previous runtime keeps its own clock (e.g. requests still draining during a reload)
+	resp, err = app.Test(makeRequest("GET", "/v1/frozen", nil, nil), -1)
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, true, body["matched"])
+}
+
+// 61. WEIGHTED RANDOM RESPONSES TEST
+func TestIntegration_WeightedResponses(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Flaky", Method: "GET", Path: "/flaky",
+			Mock: &config.MockConfig{
+				Responses: []config.WeightedResponse{
+					{Weight: 80, Body: map[string]interface{}{"ok": true, "id": "{{uuid}}"}},
+					{Weight: 15, Status: 500, Body: map[string]interface{}{"error": "boom"}},
+					{Weight: 5, Status: 503, Body: map[string]interface{}{"error": "unavailable"}, Headers: map[string]string{"Retry-After": "1"}},
+				},
+			},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	const requests = 2000
+	counts := map[int]int{}
+	for i := 0; i < requests; i++ {
+		resp, err := app.Test(makeRequest("GET", "/v1/flaky", nil, nil), -1)
+		require.NoError(t, err)
+		counts[resp.StatusCode]++
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		switch resp.StatusCode {
+		case 200:
+			assert.Equal(t, true, body["ok"])
+			assert.Len(t, body["id"], 36, "templates are processed in the picked body")
+		case 503:
+			assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+		}
+	}
+
+	assert.Len(t, counts, 3)
+	assert.InDelta(t, 0.80, float64(counts[200])/requests, 0.05)
+	assert.InDelta(t, 0.15, float64(counts[500])/requests, 0.05)
+	assert.InDelta(t, 0.05, float64(counts[503])/requests, 0.03)
+}
+
+// 62. SEQUENTIAL RESPONSES TEST
+func TestIntegration_SequenceResponses(t *testing.T) {
+	steps := []config.CResponse{
+		{Status: 503, Body: map[string]interface{}{"attempt": 1}, Headers: map[string]string{"Retry-After": "0"}},
+		{Status: 503, Body: map[string]interface{}{"attempt": 2}, Headers: map[string]string{"Retry-After": "0"}},
+		{Body: map[string]interface{}{"attempt": 3}},
+	}
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Retry Sticky", Method: "GET", Path: "/retry/{id}",
+			Mock: &config.MockConfig{Status: 200, Sequence: &config.SequenceConfig{Responses: steps}},
+		},
+		{
+			Name: "Retry Cycle", Method: "GET", Path: "/retry-cycle",
+			Mock: &config.MockConfig{Status: 201, Sequence: &config.SequenceConfig{Responses: steps[1:], Cycle: true}},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	call := func(path string) (int, float64, string) {
+		resp, err := app.Test(makeRequest("GET", path, nil, nil), -1)
+		require.NoError(t, err)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body["attempt"].(float64), resp.Header.Get("Retry-After")
+	}
+
+	// Without cycle the last response repeats
+	expected := []struct {
+		status  int
+		attempt float64
+		retry   string
+	}{
+		{503, 1, "0"}, {503, 2, "0"}, {200, 3, ""}, {200, 3, ""}, {200, 3, ""},
+	}
+	for i, want := range expected {
+		status, attempt, retry := call("/v1/retry/42")
+		assert.Equal(t, want.status, status, "call %d", i+1)
+		assert.Equal(t, want.attempt, attempt, "call %d", i+1)
+		assert.Equal(t, want.retry, retry, "call %d", i+1)
+	}
+
+	// With cycle the sequence wraps around; status 0 falls back to the mock status
+	var statuses []int
+	var attempts []float64
+	for i := 0; i < 5; i++ {
+		status, attempt, _ := call("/v1/retry-cycle")
+		statuses = append(statuses, status)
+		attempts = append(attempts, attempt)
+	}
+	assert.Equal(t, []int{503, 201, 503, 201, 503}, statuses)
+	assert.Equal(t, []float64{2, 3, 2, 3, 2}, attempts)
+
+	// Reloading the config restarts the sequence
+	app = server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+	status, attempt, _ := call("/v1/retry/42")
+	assert.Equal(t, 503, status)
+	assert.Equal(t, 1.0, attempt)
+}
+
+// 63. PAGINATED ENVELOPE TEST
+func TestIntegration_PaginatedEnvelope(t *testing.T) {
+	dir := t.TempDir()
+	productsFile := filepath.Join(dir, "products.json")
+	require.NoError(t, os.WriteFile(productsFile, []byte(`[
+		{"id": 1, "category": "book"}, {"id": 2, "category": "game"}, {"id": 3, "category": "book"},
+		{"id": 4, "category": "book"}, {"id": 5, "category": "game"}, {"id": 6, "category": "book"},
+		{"id": 7, "category": "book"}
+	]`), 0644))
+
+	inline := []interface{}{}
+	for i := 1; i <= 5; i++ {
+		inline = append(inline, map[string]interface{}{"id": i, "secret": "s3cret"})
+	}
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{Name: "Products", Method: "GET", Path: "/paged-products", Mock: &config.MockConfig{File: productsFile, Paginated: true}},
+		{
+			Name: "Inline", Method: "GET", Path: "/paged-inline",
+			Mock: &config.MockConfig{Body: inline, Paginated: true},
+			Mask: []config.MaskRule{{Field: "secret"}},
+		},
+		{Name: "Profile", Method: "GET", Path: "/paged-profile", Mock: &config.MockConfig{Body: map[string]interface{}{"name": "Ada"}, Paginated: true}},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	get := func(path string) (int, map[string]interface{}) {
+		resp, err := app.Test(makeRequest("GET", path, nil, nil), -1)
+		require.NoError(t, err)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body
+	}
+	ids := func(body map[string]interface{}) []float64 {
+		var out []float64
+		for _, item := range body["data"].([]interface{}) {
+			out = append(out, item.(map[string]interface{})["id"].(float64))
+		}
+		return out
+	}
+
+	// The total counts the filtered items before pagination
+	status, body := get("/v1/paged-products?category=book&_page=2&_limit=2")
+	require.Equal(t, 200, status)
+	assert.Equal(t, []float64{4, 6}, ids(body))
+	assert.Equal(t, map[string]interface{}{"total": 5.0, "page": 2.0, "limit": 2.0, "has_next": true}, body["meta"])
+
+	status, body = get("/v1/paged-products?category=book&_page=3&_limit=2")
+	require.Equal(t, 200, status)
+	assert.Equal(t, []float64{7}, ids(body))
+	assert.Equal(t, false, body["meta"].(map[string]interface{})["has_next"])
+
+	// Inline bodies are paged too; masking applies to the items, not the envelope
+	status, body = get("/v1/paged-inline?_limit=2&_sort=id&_order=desc")
+	require.Equal(t, 200, status)
+	assert.Equal(t, []float64{5, 4}, ids(body))
+	assert.Equal(t, "********", body["data"].([]interface{})[0].(map[string]interface{})["secret"])
+	assert.Equal(t, map[string]interface{}{"total": 5.0, "page": 1.0, "limit": 2.0, "has_next": true}, body["meta"])
+
+	// Invalid pagination parameters are client errors
+	status, body = get("/v1/paged-inline?_page=0&_limit=2")
+	assert.Equal(t, 400, status)
+	assert.Equal(t, "INVALID_QUERY", body["errorCode"])
+
+	// Non-array bodies are sent without an envelope
+	status, body = get("/v1/paged-profile")
+	require.Equal(t, 200, status)
+	assert.Equal(t, map[string]interface{}{"name": "Ada"}, body)
+}
+
+// 64. REMOVE DEFAULT HEADERS TEST
+func TestIntegration_RemoveDefaultHeaders(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.DefaultHeaders = map[string]string{
+		"Cache-Control": "no-store",
+		"X-Powered-By":  "mockserver",
+		"X-Env":         "dev",
+	}
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Cacheable", Method: "GET", Path: "/cacheable",
+			RemoveHeaders: []string{"x-powered-by", "Cache-Control"},
+			Headers:       map[string]string{"Cache-Control": "max-age=60"},
+			Mock:          &config.MockConfig{Body: map[string]interface{}{"ok": true}},
+		},
+		{
+			Name: "Private", Method: "GET", Path: "/private",
+			Mock: &config.MockConfig{Body: map[string]interface{}{"ok": true}, Headers: map[string]string{"cache-control": "private"}},
+		},
+		{Name: "Defaults", Method: "GET", Path: "/defaults", Mock: &config.MockConfig{Body: map[string]interface{}{"ok": true}}},
+		{
+			Name: "Untyped", Method: "GET", Path: "/untyped",
+			RemoveHeaders: []string{"content-type"},
+			Mock:          &config.MockConfig{Body: map[string]interface{}{"ok": true}},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/cacheable", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Empty(t, resp.Header.Values("X-Powered-By"), "removed default header must not be sent")
+	assert.Equal(t, "max-age=60", resp.Header.Get("Cache-Control"), "removed default replaced by the route header")
+	assert.Equal(t, "dev", resp.Header.Get("X-Env"), "other defaults are kept")
+
+	// Overrides replace defaults regardless of header name case
+	for i := 0; i < 5; i++ {
+		resp, err = app.Test(makeRequest("GET", "/v1/private", nil, nil), -1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"private"}, resp.Header.Values("Cache-Control"))
+		assert.Equal(t, "mockserver", resp.Header.Get("X-Powered-By"))
+	}
+
+	resp, err = app.Test(makeRequest("GET", "/v1/defaults", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, "mockserver", resp.Header.Get("X-Powered-By"))
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	// The type set while writing the body is removed too
+	resp, err = app.Test(makeRequest("GET", "/v1/untyped", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Empty(t, resp.Header.Values("Content-Type"))
+	raw, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"ok": true}`, string(raw))
+
+	cfg.Routes[0].RemoveHeaders = []string{" "}
+	assert.ErrorContains(t, config.ApplyDefaults(cfg, ""), "remove_headers[0] cannot be empty")
+}
+
+// 65. FETCH BODY TEMPLATE TEST
+func TestIntegration_FetchBodyTemplate(t *testing.T) {
+	type received struct {
+		contentType string
+		tenant      string
+		body        string
+	}
+	got := make(chan received, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		got <- received{r.Header.Get("Content-Type"), r.Header.Get("X-Tenant"), string(raw)}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer upstream.Close()
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Create Account", Method: "POST", Path: "/accounts",
+			Fetch: &config.FetchConfig{
+				URL:     upstream.URL + "/v2/accounts",
+				Headers: map[string]string{"X-Tenant": "{{request.body.tenant}}"},
+				BodyTemplate: map[string]interface{}{
+					"account": map[string]interface{}{
+						"displayName": "{{request.body.name}}",
+						"seats":       "= request.body.seats * 2",
+					},
+					"source": "mockserver",
+				},
+			},
+		},
+		{
+			Name: "Notify", Method: "POST", Path: "/notify",
+			Fetch: &config.FetchConfig{URL: upstream.URL + "/notify", BodyTemplate: "name={{request.body.name}}"},
+		},
+		{
+			Name: "Forward", Method: "POST", Path: "/forward",
+			Fetch: &config.FetchConfig{URL: upstream.URL + "/forward"},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	input := map[string]interface{}{"name": "Acme", "seats": 5, "tenant": "t-42"}
+
+	// The templated body replaces the client body; header values are templated too
+	resp, err := app.Test(makeRequest("POST", "/v1/accounts", input, map[string]string{"Content-Type": "text/plain"}), 5000)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	upstreamReq := <-got
+	assert.Equal(t, "application/json", upstreamReq.contentType)
+	assert.Equal(t, "t-42", upstreamReq.tenant)
+	assert.JSONEq(t, `{"account": {"displayName": "Acme", "seats": 10}, "source": "mockserver"}`, upstreamReq.body)
+
+	// String templates are sent as-is
+	resp, err = app.Test(makeRequest("POST", "/v1/notify", input, nil), 5000)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "name=Acme", (<-got).body)
+
+	// Without body_template the client body is forwarded verbatim
+	resp, err = app.Test(makeRequest("POST", "/v1/forward", input, nil), 5000)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	assert.JSONEq(t, `{"name": "Acme", "seats": 5, "tenant": "t-42"}`, (<-got).body)
+}
+
+// 66. FETCH RESPONSE TRANSFORM TEST
+func TestIntegration_FetchResponseTransform(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/text" {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("plain upstream"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"meta": {"request_id": "r-1"}, "result": {"account": {"id": 9007199254740993, "owner": {"email": "ada@example.com"}}}}`))
+	}))
+	defer upstream.Close()
+
+	transform := &config.ResponseTransformConfig{
+		Extract: "$.result.account",
+		Rename:  map[string]string{"owner.email": "email"},
+		Pick:    []string{"id", "email"},
+		Wrap:    "data",
+	}
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{Name: "Account", Method: "GET", Path: "/account", Fetch: &config.FetchConfig{URL: upstream.URL + "/account", ResponseTransform: transform}},
+		{Name: "Text", Method: "GET", Path: "/text", Fetch: &config.FetchConfig{URL: upstream.URL + "/text", ResponseTransform: transform}},
+		{Name: "Missing", Method: "GET", Path: "/missing", Fetch: &config.FetchConfig{
+			URL: upstream.URL + "/account", ResponseTransform: &config.ResponseTransformConfig{Extract: "result.invoice"},
+		}},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	// The nested fields are extracted to the top level; large ids keep their precision
+	resp, err := app.Test(makeRequest("GET", "/v1/account", nil, nil), 5000)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"data": {"id": 9007199254740993, "email": "ada@example.com"}}`, string(raw))
+	assert.Contains(t, string(raw), "9007199254740993")
+
+	// Non-JSON responses pass through untouched
+	resp, err = app.Test(makeRequest("GET", "/v1/text", nil, nil), 5000)
+	require.NoError(t, err)
+	raw, _ = io.ReadAll(resp.Body)
+	assert.Equal(t, "plain upstream", string(raw))
+
+	resp, err = app.Test(makeRequest("GET", "/v1/missing", nil, nil), 5000)
+	require.NoError(t, err)
+	assert.Equal(t, 502, resp.StatusCode)
+}
+
+// 67. MAX TEMPLATE NODES TEST
+func TestIntegration_MaxTemplateNodes(t *testing.T) {
+	rows := make([]interface{}, 20)
+	for i := range rows {
+		rows[i] = map[string]interface{}{"id": "{{uuid}}"}
+	}
+
+	cfg := createSafeConfig()
+	cfg.Server.MaxTemplateNodes = 25
+	cfg.Routes = []config.RouteConfig{
+		{Name: "Small", Method: "GET", Path: "/small-template", Mock: &config.MockConfig{Body: map[string]interface{}{"id": "{{uuid}}"}}},
+		{Name: "Large", Method: "GET", Path: "/large-template", Mock: &config.MockConfig{Body: rows}},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/small-template", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = app.Test(makeRequest("GET", "/v1/large-template", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 500, resp.StatusCode)
+	var apiErr map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&apiErr))
+	assert.Contains(t, apiErr["message"], "more than 25 nodes")
+
+	cfg.Server.MaxTemplateNodes = -1
+	assert.ErrorContains(t, config.ApplyDefaults(cfg, ""), "max_template_nodes cannot be negative")
+}
+
+// 68. FETCH RETRY WITH BACKOFF TEST
+func TestIntegration_FetchRetry(t *testing.T) {
+	var flakyHits, dropHits, missingHits, postHits atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			if flakyHits.Add(1) <= 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/drop":
+			if dropHits.Add(1) == 1 {
+				// Connection failure: close the socket without a response
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+				return
+			}
+		case "/missing":
+			missingHits.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case "/create":
+			postHits.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer upstream.Close()
+
+	retrying := func(path string) *config.FetchConfig {
+		return &config.FetchConfig{URL: upstream.URL + path, Retries: 3, RetryBackoffMs: 10, PassStatus: true}
+	}
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{Name: "Flaky", Method: "GET", Path: "/flaky", Fetch: retrying("/flaky")},
+		{Name: "Drop", Method: "GET", Path: "/drop", Fetch: retrying("/drop")},
+		{Name: "Missing", Method: "GET", Path: "/missing", Fetch: retrying("/missing")},
+		{Name: "Create", Method: "POST", Path: "/create", Fetch: retrying("/create")},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	// Two 503s, then success: the client only sees the final 200
+	start := time.Now()
+	resp, err := app.Test(makeRequest("GET", "/v1/flaky", nil, nil), 5000)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, int32(3), flakyHits.Load())
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond, "backoff doubles: 10ms + 20ms")
+
+	// Connection errors are retried as well
+	resp, err = app.Test(makeRequest("GET", "/v1/drop", nil, nil), 5000)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	// 4xx is never retried
+	resp, err = app.Test(makeRequest("GET", "/v1/missing", nil, nil), 5000)
+	require.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.Equal(t, int32(1), missingHits.Load())
+
+	// Non-idempotent methods are sent once
+	resp, err = app.Test(makeRequest("POST", "/v1/create", map[string]interface{}{"name": "x"}, nil), 5000)
+	require.NoError(t, err)
+	assert.Equal(t, 503, resp.StatusCode)
+	assert.Equal(t, int32(1), postHits.Load())
+}
+
+// 69. REQUEST PROTOCOL TEST
+func TestIntegration_RequestProto(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Proto Branch",
+			Method: "GET",
+			Path:   "/proto",
+			Cases: []config.CaseConfig{
+				{
+					When: "request.proto == 'HTTP/2.0'",
+					Then: config.CResponse{Status: 200, Body: map[string]interface{}{"client": "h2"}},
+				},
+			},
+			Default: &config.CResponse{
+				Status: 200,
+				Body:   map[string]interface{}{"client": "h1", "proto": "{{request.proto}}"},
+			},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	// app.Test always writes an HTTP/1.1 request line
+	resp, err := app.Test(makeRequest("GET", "/v1/proto", nil, nil), -1)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"client": "h1", "proto": "HTTP/1.1"}`, string(raw))
+}
+
+// 70. FETCH RESPONSE CACHE TEST
+func TestIntegration_FetchCache(t *testing.T) {
+	var hits atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		if strings.HasSuffix(r.URL.Path, "/broken") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Upstream-Call", strconv.Itoa(int(n)))
+		w.Write([]byte(`{"path": ` + strconv.Quote(r.URL.Path) + `, "call": ` + strconv.Itoa(int(n)) + `}`))
+	}))
+	defer upstream.Close()
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{Name: "Cached Item", Method: "GET", Path: "/cached/{id}", Fetch: &config.FetchConfig{URL: upstream.URL + "/items/{id}", CacheTTLMs: 60000, PassStatus: true}},
+		{Name: "Cached Post", Method: "POST", Path: "/cached-post", Fetch: &config.FetchConfig{URL: upstream.URL + "/items", CacheTTLMs: 60000}},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	get := func(method, path string) (*http.Response, string) {
+		resp, err := app.Test(makeRequest(method, path, nil, nil), 5000)
+		require.NoError(t, err)
+		raw, _ := io.ReadAll(resp.Body)
+		return resp, string(raw)
+	}
+
+	// Second request within the TTL is served from memory with the upstream status, headers and body
+	resp, first := get("GET", "/v1/cached/7")
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "MISS", resp.Header.Get("X-Mock-Cache"))
+
+	resp, second := get("GET", "/v1/cached/7")
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "HIT", resp.Header.Get("X-Mock-Cache"))
+	assert.Equal(t, "1", resp.Header.Get("X-Upstream-Call"))
+	assert.JSONEq(t, first, second)
+	assert.Equal(t, int32(1), hits.Load())
+
+	// Another target URL is a separate entry
+	resp, _ = get("GET", "/v1/cached/8")
+	assert.Equal(t, "MISS", resp.Header.Get("X-Mock-Cache"))
+	assert.Equal(t, int32(2), hits.Load())
+
+	// Non-2xx responses are not cached
+	get("GET", "/v1/cached/broken")
+	resp, _ = get("GET", "/v1/cached/broken")
+	assert.Equal(t, 500, resp.StatusCode)
+	assert.Equal(t, "MISS", resp.Header.Get("X-Mock-Cache"))
+	assert.Equal(t, int32(4), hits.Load())
+
+	// Unsafe methods always reach the upstream
+	get("POST", "/v1/cached-post")
+	resp, _ = get("POST", "/v1/cached-post")
+	assert.Empty(t, resp.Header.Get("X-Mock-Cache"))
+	assert.Equal(t, int32(6), hits.Load())
+}
+
+// 71. NON-JSON BODIES FROM RESOLVED HEADERS TEST
+func TestIntegration_NonJSONBodies(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Page", Method: "GET", Path: "/nonjson/page",
+			Produces: "text/html",
+			Cases: []config.CaseConfig{
+				{When: "request.query.name != ''", Then: config.CResponse{Status: 200, Body: "<h1>Hello {{request.query.name}}</h1>"}},
+			},
+			Default: &config.CResponse{Status: 404, Body: "<h1>Not found</h1>"},
+		},
+		{
+			Name: "Soap", Method: "POST", Path: "/nonjson/soap",
+			Default: &config.CResponse{
+				Status:  200,
+				Headers: map[string]string{"Content-Type": "application/xml"},
+				Body:    `<Envelope><Body><Id>{{request.body.id}}</Id></Body></Envelope>`,
+			},
+		},
+		{
+			Name: "Report", Method: "GET", Path: "/nonjson/report",
+			Mock: &config.MockConfig{Sequence: &config.SequenceConfig{Responses: []config.CResponse{
+				{Status: 200, Headers: map[string]string{"content-type": "text/csv"}, Body: "id,name\n1,Ada\n"},
+				{Status: 200, Body: map[string]interface{}{"done": true}},
+			}}},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	send := func(method, path string, body interface{}) (*http.Response, string) {
+		resp, err := app.Test(makeRequest(method, path, body, nil), -1)
+		require.NoError(t, err)
+		raw, _ := io.ReadAll(resp.Body)
+		return resp, string(raw)
+	}
+
+	// Case and default bodies follow the route content type; templates still run
+	resp, body := send("GET", "/v1/nonjson/page?name=Ada", nil)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "text/html", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "<h1>Hello Ada</h1>", body)
+
+	resp, body = send("GET", "/v1/nonjson/page?name=", nil)
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.Equal(t, "<h1>Not found</h1>", body)
+
+	// The response's own Content-Type header selects XML
+	resp, body = send("POST", "/v1/nonjson/soap", map[string]interface{}{"id": 42})
+	assert.Equal(t, "application/xml", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "<Envelope><Body><Id>42</Id></Body></Envelope>", body)
+
+	// Sequence step headers apply to that step only
+	resp, body = send("GET", "/v1/nonjson/report", nil)
+	assert.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "id,name\n1,Ada\n", body)
+
+	resp, body = send("GET", "/v1/nonjson/report", nil)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"done": true}`, body)
+}
+
+// 72. DEEP BODY PATH TEST
+func TestIntegration_DeepBodyPath(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Deep Shipping", Method: "POST", Path: "/deep/shipping",
+			Cases: []config.CaseConfig{
+				{
+					When: "request.body.order.customer.address.country == 'DE'",
+					Then: config.CResponse{Status: 200, Body: map[string]interface{}{"carrier": "DHL", "city": "{{request.body.order.customer.address.city}}"}},
+				},
+			},
+			Default: &config.CResponse{Status: 200, Body: map[string]interface{}{"carrier": "UPS", "city": "{{request.body.Order.Customer.Address.City}}"}},
+		},
+	}
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	ship := func(country, city string) string {
+		body := map[string]interface{}{"order": map[string]interface{}{
+			"customer": map[string]interface{}{"address": map[string]interface{}{"country": country, "city": city}},
+		}}
+		resp, err := app.Test(makeRequest("POST", "/v1/deep/shipping", body, nil), -1)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+		raw, _ := io.ReadAll(resp.Body)
+		return string(raw)
+	}
+
+	assert.JSONEq(t, `{"carrier": "DHL", "city": "Berlin"}`, ship("DE", "Berlin"))
+	assert.JSONEq(t, `{"carrier": "UPS", "city": "Lyon"}`, ship("FR", "Lyon"))
+}
+
+// 73. MAX BODY DEPTH TEST
+func TestIntegration_MaxBodyDepth(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.MaxBodyDepth = 3
+	cfg.Routes = []config.RouteConfig{
+		{Name: "Depth Echo", Method: "POST", Path: "/depth", Echo: true},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	post := func(raw string) *http.Response {
+		req, _ := http.NewRequest("POST", "/v1/depth", strings.NewReader(raw))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// Within the limit: {} -> [] -> {}
+	resp := post(`{"a": [{"b": 1}]}`)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp = post(`{"a": [{"b": {"c": 1}}]}`)
+	assert.Equal(t, 400, resp.StatusCode)
+	var apiErr map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&apiErr))
+	assert.Equal(t, "BODY_TOO_DEEP", apiErr["errorCode"])
+
+	// Pathological input is rejected without being decoded
+	resp = post(strings.Repeat("[", 100000) + strings.Repeat("]", 100000))
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+// 74. MOCK SOURCE DEBUG HEADERS TEST
+func TestIntegration_MockSourceHeaders(t *testing.T) {
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "fixture.json")
+	require.NoError(t, os.WriteFile(fixture, []byte(`{"from": "file"}`), 0644))
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"from": "upstream"}`))
+	}))
+	defer upstream.Close()
+
+	routes := []config.RouteConfig{
+		{Name: "Source Inline", Method: "GET", Path: "/source/inline", Mock: &config.MockConfig{Body: map[string]interface{}{"from": "inline"}}},
+		{Name: "Source File", Method: "GET", Path: "/source/file", Mock: &config.MockConfig{File: fixture}},
+		{Name: "Source Sequence", Method: "GET", Path: "/source/sequence", Mock: &config.MockConfig{
+			Sequence: &config.SequenceConfig{Responses: []config.CResponse{{Status: 200, Body: "step"}}},
+		}},
+		{
+			Name: "Source Case", Method: "GET", Path: "/source/case",
+			Cases: []config.CaseConfig{{When: "request.query.pick == 'case'", Then: config.CResponse{Status: 200, Body: "case"}}},
+		},
+		{
+			Name: "Source Routed", Method: "GET", Path: "/source/routed",
+			Variants: &config.VariantsConfig{Header: "X-Source", Values: map[string]config.CResponse{"v": {Status: 200, Body: "variant"}}},
+			Default:  &config.CResponse{Status: 200, Body: "default"},
+		},
+		{Name: "Source Fetch", Method: "GET", Path: "/source/fetch", Fetch: &config.FetchConfig{URL: upstream.URL, CacheTTLMs: 60000}},
+	}
+
+	cfg := createSafeConfig()
+	cfg.Server.Debug = &config.DebugConfig{Enabled: true, Path: "/__debug"}
+	cfg.Routes = routes
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	get := func(app *fiber.App, path string) *http.Response {
+		resp, err := app.Test(makeRequest("GET", path, nil, nil), -1)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+		return resp
+	}
+
+	resp := get(app, "/v1/source/inline")
+	assert.Equal(t, "inline", resp.Header.Get("X-Mock-Source"))
+	assert.Empty(t, resp.Header.Get("X-Mock-File"))
+
+	resp = get(app, "/v1/source/file")
+	assert.Equal(t, "file", resp.Header.Get("X-Mock-Source"))
+	assert.Equal(t, fixture, resp.Header.Get("X-Mock-File"))
+
+	resp = get(app, "/v1/source/sequence")
+	assert.Equal(t, "sequence", resp.Header.Get("X-Mock-Source"))
+
+	// Route-level cases, header variants and the default response report themselves too
+	resp = get(app, "/v1/source/case?pick=case")
+	assert.Equal(t, "case", resp.Header.Get("X-Mock-Source"))
+
+	resp, err := app.Test(makeRequest("GET", "/v1/source/routed", nil, map[string]string{"X-Source": "v"}), -1)
+	require.NoError(t, err)
+	assert.Equal(t, "header_variant", resp.Header.Get("X-Mock-Source"))
+
+	resp = get(app, "/v1/source/routed")
+	assert.Equal(t, "default", resp.Header.Get("X-Mock-Source"))
+	assert.Empty(t, resp.Header.Get("X-Mock-File"))
+
+	// Fetch routes tell cached answers from upstream ones
+	resp = get(app, "/v1/source/fetch")
+	assert.Equal(t, "upstream", resp.Header.Get("X-Mock-Source"))
+	assert.Equal(t, "MISS", resp.Header.Get("X-Mock-Cache"))
+
+	resp = get(app, "/v1/source/fetch")
+	assert.Equal(t, "cache", resp.Header.Get("X-Mock-Source"))
+	assert.Equal(t, "HIT", resp.Header.Get("X-Mock-Cache"))
+
+	// Outside debug mode fixture paths are never exposed
+	quiet := createSafeConfig()
+	quiet.Routes = routes
+	require.NoError(t, config.ApplyDefaults(quiet, ""))
+	quietApp := server.StartServer(quiet, "", testEmbedFS, testFaviconFS)
+
+	resp = get(quietApp, "/v1/source/file")
+	assert.Empty(t, resp.Header.Get("X-Mock-Source"))
+	assert.Empty(t, resp.Header.Get("X-Mock-File"))
+
+	resp = get(quietApp, "/v1/source/routed")
+	assert.Empty(t, resp.Header.Get("X-Mock-Source"))
+}
+
+// 75. AUTH ANY-OF CHALLENGE TEST
+func TestIntegration_AuthAnyChallenge(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Basic Or Key",
+			Method: "GET",
+			Path:   "/basic-or-key",
+			AuthAny: []config.AuthConfig{
+				{Enabled: true, Type: "basic", Keys: []string{"admin:s3cret"}},
+				{Enabled: true, Type: "apiKey", In: "header", Name: "X-Api-Key", Keys: []string{"key-123"}},
+			},
+			Mock: &config.MockConfig{Status: 200, Body: "ok"},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/basic-or-key", nil, map[string]string{"X-Api-Key": "key-123"}), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("WWW-Authenticate"), "accepted requests must not carry a challenge")
+
+	resp, err = app.Test(makeRequest("GET", "/v1/basic-or-key", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+	assert.Equal(t, `Basic realm="mockserver"`, resp.Header.Get("WWW-Authenticate"))
+}
+
+// 76. FETCH CACHE CREDENTIALS TEST
+func TestIntegration_FetchCacheCredentials(t *testing.T) {
+	var hits atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if strings.HasSuffix(r.URL.Path, "/private") {
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"user": ` + strconv.Quote(r.Header.Get("Authorization")) + `}`))
+	}))
+	defer upstream.Close()
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{Name: "Cached Profile", Method: "GET", Path: "/cached-profile", Fetch: &config.FetchConfig{URL: upstream.URL + "/profile", CacheTTLMs: 60000}},
+		{Name: "Cached Private", Method: "GET", Path: "/cached-private", Fetch: &config.FetchConfig{URL: upstream.URL + "/private", CacheTTLMs: 60000}},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	get := func(path, authorization string) (*http.Response, string) {
+		headers := map[string]string{}
+		if authorization != "" {
+			headers["Authorization"] = authorization
+		}
+		resp, err := app.Test(makeRequest("GET", path, nil, headers), 5000)
+		require.NoError(t, err)
+		raw, _ := io.ReadAll(resp.Body)
+		return resp, string(raw)
+	}
+
+	// Each caller's credentials reach the upstream and get their own answer
+	resp, alice := get("/v1/cached-profile", "Bearer alice")
+	assert.Equal(t, "BYPASS", resp.Header.Get("X-Mock-Cache"))
+	assert.JSONEq(t, `{"user": "Bearer alice"}`, alice)
+
+	resp, bob := get("/v1/cached-profile", "Bearer bob")
+	assert.Equal(t, "BYPASS", resp.Header.Get("X-Mock-Cache"))
+	assert.JSONEq(t, `{"user": "Bearer bob"}`, bob)
+	assert.Equal(t, int32(2), hits.Load())
+
+	// Anonymous requests are still cached
+	get("/v1/cached-profile", "")
+	resp, _ = get("/v1/cached-profile", "")
+	assert.Equal(t, "HIT", resp.Header.Get("X-Mock-Cache"))
+	assert.Equal(t, int32(3), hits.Load())
+
+	// Upstream Cache-Control: private is honored
+	get("/v1/cached-private", "")
+	resp, _ = get("/v1/cached-private", "")
+	assert.Equal(t, "MISS", resp.Header.Get("X-Mock-Cache"))
+	assert.Equal(t, int32(5), hits.Load())
+}
+
+// 77. DEBUG LOG AUTH REDACTION TEST
+func TestIntegration_DebugRedactAuthParams(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.Debug = &config.DebugConfig{Enabled: true, Path: "/__debug"}
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:   "Redact Header Key",
+			Method: "GET",
+			Path:   "/redact-auth-header",
+			Auth:   &config.AuthConfig{Enabled: true, Type: "apiKey", In: "header", Name: "X-Partner-Key", Keys: []string{"partner-secret"}},
+			Mock:   &config.MockConfig{Status: 200, Body: "ok"},
+		},
+		{
+			Name:   "Redact Query Key",
+			Method: "GET",
+			Path:   "/redact-auth-query",
+			AuthAny: []config.AuthConfig{
+				{Enabled: true, Type: "apiKey", In: "query", Name: "access_token", Keys: []string{"query-secret"}},
+			},
+			Mock: &config.MockConfig{Status: 200, Body: "ok"},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/redact-auth-header", nil, map[string]string{
+		"X-Partner-Key": "partner-secret",
+		"Set-Cookie":    "session=abc",
+	}), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	resp, err = app.Test(makeRequest("GET", "/v1/redact-auth-query?page=2&access_token=query-secret", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	find := func(prefix string) map[string]interface{} {
+		var request map[string]interface{}
+		require.Eventually(t, func() bool {
+			for _, entry := range fetchDebugLogs(t, app, "/__debug") {
+				r := entry["request"].(map[string]interface{})
+				if strings.HasPrefix(r["path"].(string), prefix) {
+					request = r
+					return true
+				}
+			}
+			return false
+		}, time.Second, 10*time.Millisecond)
+		return request
+	}
+
+	headers := find("/v1/redact-auth-header")["headers"].(map[string]interface{})
+	assert.Equal(t, "****", headers["x-partner-key"])
+	assert.Equal(t, "****", headers["set-cookie"])
+
+	request := find("/v1/redact-auth-query")
+	assert.Equal(t, "/v1/redact-auth-query?page=2&access_token=****", request["path"])
+	query := request["query"].(map[string]interface{})
+	assert.Equal(t, "****", query["access_token"])
+	assert.Equal(t, "2", query["page"])
+	raw, _ := json.Marshal(request)
+	assert.NotContains(t, string(raw), "secret")
+}
+
+// 78. FETCH AND STATE ERROR MASKING TEST
+func TestIntegration_ResponseMaskFetchAndState(t *testing.T) {
+	var acceptEncoding atomic.Value
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding.Store(r.Header.Get("Accept-Encoding"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 12345678901234567891, "card": {"number": "4111111111111111", "brand": "visa"}}`))
+	}))
+	defer upstream.Close()
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Masked Upstream Card", Method: "GET", Path: "/masked-upstream-card",
+			Mask:  []config.MaskRule{{Field: "card.number", Strategy: "partial"}},
+			Fetch: &config.FetchConfig{URL: upstream.URL + "/card"},
+		},
+		{
+			Name: "Masked Missing Account", Method: "GET", Path: "/masked-accounts/{id}",
+			Mask: []config.MaskRule{{Field: "lookup.token"}},
+			Stateful: &config.StatefulConfig{
+				Collection: "masked_accounts", Action: "get", IDField: "id",
+				Responses: &config.StatefulResponses{
+					NotFound: &config.CResponse{Body: map[string]interface{}{"lookup": map[string]interface{}{"token": "tok_live_123", "id": "{{request.path.id}}"}}},
+				},
+			},
+			Mock: &config.MockConfig{Status: 200, Body: "{{state.item}}"},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/masked-upstream-card", nil, map[string]string{"Accept-Encoding": "gzip"}), 5000)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"id": 12345678901234567891, "card": {"number": "************1111", "brand": "visa"}}`, string(raw))
+	assert.Contains(t, string(raw), "12345678901234567891", "unmasked numbers are kept exact")
+	assert.Empty(t, acceptEncoding.Load(), "masked upstream bodies are requested uncompressed")
+
+	resp, err = app.Test(makeRequest("GET", "/v1/masked-accounts/42", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+	raw, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"lookup": {"token": "********", "id": "42"}}`, string(raw))
+}
+
+// 79. ROUTE TIMEOUT RESET AND FETCH TEST
+func TestIntegration_RouteTimeoutResetAndFetch(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	cfg := createSafeConfig()
+	cfg.Server.CORS = &config.CORSConfig{Enabled: true, AllowOrigins: []string{"https://app.example.com"}}
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:      "Slow Case Headers",
+			Method:    "GET",
+			Path:      "/slow-case-headers",
+			TimeoutMs: 50,
+			Cases: []config.CaseConfig{{
+				When: "request.query.slow == 'yes'",
+				Then: config.CResponse{
+					Status:  200,
+					DelayMs: 2000,
+					Headers: map[string]string{"X-Case": "late"},
+					Cookies: []config.CookieConfig{{Name: "late_session", Value: "abc"}},
+					Body:    map[string]interface{}{"ok": true},
+				},
+			}},
+			Default: &config.CResponse{Status: 200, Body: map[string]interface{}{"ok": true}},
+		},
+		{
+			Name:      "Slow Upstream Route",
+			Method:    "GET",
+			Path:      "/slow-upstream-route",
+			TimeoutMs: 50,
+			Fetch:     &config.FetchConfig{URL: upstream.URL + "/slow", TimeoutMs: 5000},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("GET", "/v1/slow-case-headers?slow=yes", nil, map[string]string{"Origin": "https://app.example.com"}), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 504, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("X-Case"), "headers written after the deadline are dropped")
+	assert.Empty(t, resp.Header.Values("Set-Cookie"), "cookies written after the deadline are dropped")
+	assert.Equal(t, "https://app.example.com", resp.Header.Get("Access-Control-Allow-Origin"), "middleware headers are kept")
+
+	start := time.Now()
+	resp, err = app.Test(makeRequest("GET", "/v1/slow-upstream-route", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 504, resp.StatusCode)
+	assert.Less(t, time.Since(start), time.Second, "the route timeout cancels the upstream call")
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "ROUTE_TIMEOUT_ERROR", body["errorCode"])
+}
+
+// 80. STRICT QUERY AUTH AND PAGINATION TEST
+func TestIntegration_StrictQueryAuthParams(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Server.Auth = &config.AuthConfig{Enabled: true, Type: "apiKey", In: "query", Name: "api_key", Keys: []string{"global-key"}}
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name:        "Strict Global Auth",
+			Method:      "GET",
+			Path:        "/strict-global-auth",
+			StrictQuery: true,
+			Mock: &config.MockConfig{Status: 200, Paginated: true, Body: []interface{}{
+				map[string]interface{}{"id": 1}, map[string]interface{}{"id": 2},
+			}},
+		},
+		{
+			Name:        "Strict Any Auth",
+			Method:      "GET",
+			Path:        "/strict-any-auth",
+			StrictQuery: true,
+			AuthAny: []config.AuthConfig{
+				{Enabled: true, Type: "apiKey", In: "query", Name: "token", Keys: []string{"any-key"}},
+				{Enabled: true, Type: "bearer", In: "header", Name: "Authorization", Keys: []string{"any-token"}},
+			},
+			Mock: &config.MockConfig{Status: 200, Body: "ok"},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	status := func(path string) int {
+		resp, err := app.Test(makeRequest("GET", path, nil, nil), -1)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, 200, status("/v1/strict-global-auth?api_key=global-key&_page=2&_limit=1"))
+	assert.Equal(t, 200, status("/v1/strict-any-auth?token=any-key"))
+
+	// Only the schemes in effect for the route are exempt
+	assert.Equal(t, 400, status("/v1/strict-any-auth?token=any-key&api_key=global-key"))
+	assert.Equal(t, 400, status("/v1/strict-global-auth?api_key=global-key&token=any-key"))
+}
+
+// 81. STATIC MOUNT METHODS TEST
+func TestIntegration_StaticMountMethods(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("hello static"), 0644))
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Read Only Assets", Method: "GET", Path: "/ro-assets",
+			Static: &config.StaticConfig{Dir: dir},
+			Quota:  &config.QuotaConfig{Max: 2},
+		},
+		{
+			Name: "Read Only Upload", Method: "POST", Path: "/ro-assets/upload",
+			Mock: &config.MockConfig{Status: 201, Body: map[string]interface{}{"uploaded": true}},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	// Writes under the mount reach their own route without consuming the static quota
+	resp, err := app.Test(makeRequest("POST", "/v1/ro-assets/upload", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("X-Quota-Limit"))
+
+	resp, err = app.Test(makeRequest("DELETE", "/v1/ro-assets/hello.txt", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("X-Quota-Limit"))
+
+	// GET and HEAD are served by the mount
+	resp, err = app.Test(makeRequest("HEAD", "/v1/ro-assets/hello.txt", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("X-Quota-Remaining"))
+
+	resp, err = app.Test(makeRequest("GET", "/v1/ro-assets/hello.txt", nil, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "hello static", string(raw))
+}
+
+// 82. STATEFUL PATCH SCHEMA TEST
+func TestIntegration_StatefulPatchSchema(t *testing.T) {
+	schema := &config.JSONSchema{
+		Type:     "object",
+		Required: []string{"id", "name"},
+		Properties: map[string]*config.JSONSchema{
+			"id":   {Type: "integer"},
+			"name": {Type: "string"},
+			"age":  {Type: "integer"},
+		},
+	}
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Create Patient", Method: "POST", Path: "/patients",
+			Stateful:   &config.StatefulConfig{Collection: "patients", Action: "create", IDField: "id"},
+			BodySchema: schema,
+			Mock:       &config.MockConfig{Status: 201, Body: "{{state.created}}"},
+		},
+		{
+			// Answered by a case, so the mock handler never sees the body
+			Name: "Patch Patient", Method: "PATCH", Path: "/patients/{id}",
+			Stateful:   &config.StatefulConfig{Collection: "patients", Action: "patch", IDField: "id"},
+			BodySchema: schema,
+			Cases: []config.CaseConfig{
+				{When: "request.path.id == '7'", Then: config.CResponse{Status: 200, Body: "{{state.patched}}"}},
+			},
+		},
+		{
+			Name: "Get Patient", Method: "GET", Path: "/patients/{id}",
+			Stateful: &config.StatefulConfig{Collection: "patients", Action: "get", IDField: "id"},
+			Mock:     &config.MockConfig{Status: 200, Body: "{{state.item}}"},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	resp, err := app.Test(makeRequest("POST", "/v1/patients", map[string]interface{}{"id": 7, "name": "Ada", "age": 36}, nil), -1)
+	require.NoError(t, err)
+	require.Equal(t, 201, resp.StatusCode)
+
+	// An invalid field is rejected before the store changes
+	resp, err = app.Test(makeRequest("PATCH", "/v1/patients/7", map[string]interface{}{"age": "old"}, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 400, resp.StatusCode)
+
+	resp, err = app.Test(makeRequest("GET", "/v1/patients/7", nil, nil), -1)
+	require.NoError(t, err)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"id": 7, "name": "Ada", "age": 36}`, string(raw))
+
+	// Required fields are not needed in a partial body
+	resp, err = app.Test(makeRequest("PATCH", "/v1/patients/7", map[string]interface{}{"age": 37}, nil), -1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	raw, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"id": 7, "name": "Ada", "age": 37}`, string(raw))
+}
+
+// 83. STATEFUL LIST MATCH QUERY TEST
+func TestIntegration_StatefulListMatchQuery(t *testing.T) {
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{
+			Name: "Create Ward", Method: "POST", Path: "/wards",
+			Stateful:   &config.StatefulConfig{Collection: "wards", Action: "create", IDField: "id"},
+			BodySchema: &config.JSONSchema{Type: "object"},
+			Mock:       &config.MockConfig{Status: 201, Body: "{{state.created}}"},
+		},
+		{
+			Name: "List Wards", Method: "GET", Path: "/wards",
+			MatchQuery: map[string]string{"view": "all"},
+			Auth:       &config.AuthConfig{Enabled: true, Type: "apikey", In: "query", Name: "key", Keys: []string{"k1"}},
+			Stateful:   &config.StatefulConfig{Collection: "wards", Action: "list", IDField: "id"},
+			Mock:       &config.MockConfig{Status: 200, Body: "{{state.list}}"},
+		},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	for _, ward := range []map[string]interface{}{{"id": 1, "floor": "2"}, {"id": 2, "floor": "3"}} {
+		resp, err := app.Test(makeRequest("POST", "/v1/wards", ward, nil), -1)
+		require.NoError(t, err)
+		require.Equal(t, 201, resp.StatusCode)
+	}
+
+	// The selector and the api key are not treated as item filters
+	resp, err := app.Test(makeRequest("GET", "/v1/wards?view=all&key=k1", nil, nil), -1)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	raw, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `[{"id": 1, "floor": "2"}, {"id": 2, "floor": "3"}]`, string(raw))
+
+	// Other params still filter
+	resp, err = app.Test(makeRequest("GET", "/v1/wards?view=all&key=k1&floor=3", nil, nil), -1)
+	require.NoError(t, err)
+	raw, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `[{"id": 2, "floor": "3"}]`, string(raw))
+}
+
+// 84. FETCH CACHE VARY TEST
+func TestIntegration_FetchCacheVary(t *testing.T) {
+	var hits atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Vary", "Accept-Language")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"lang": ` + strconv.Quote(r.Header.Get("Accept-Language")) + `}`))
+	}))
+	defer upstream.Close()
+
+	cfg := createSafeConfig()
+	cfg.Routes = []config.RouteConfig{
+		{Name: "Cached Greeting", Method: "GET", Path: "/cached-greeting", Fetch: &config.FetchConfig{URL: upstream.URL + "/greeting", CacheTTLMs: 60000}},
+	}
+	require.NoError(t, config.ApplyDefaults(cfg, ""))
+
+	app := server.StartServer(cfg, "", testEmbedFS, testFaviconFS)
+
+	get := func(lang string) (*http.Response, string) {
+		resp, err := app.Test(makeRequest("GET", "/v1/cached-greeting", nil, map[string]string{"Accept-Language": lang}), 5000)
+		require.NoError(t, err)
+		raw, _ := io.ReadAll(resp.Body)
+		return resp, string(raw)
+	}
+
+	get("fr")
+	resp, body := get("fr")
+	assert.Equal(t, "HIT", resp.Header.Get("X-Mock-Cache"))
+	assert.JSONEq(t, `{"lang": "fr"}`, body)
+
+	// Another language is not answered with the cached French response
+	resp, body = get("de")
+	assert.Equal(t, "MISS", resp.Header.Get("X-Mock-Cache"))
+	assert.JSONEq(t, `{"lang": "de"}`, body)
+	assert.Equal(t, int32(2), hits.Load())
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 
 	msconfig "mockserver/config"
 	mslogger "mockserver/logger"
@@ -12,8 +17,31 @@ import (
 )
 
 
+// startConfigPath picks the file to serve. An OpenAPI document replaces the config file (and is
+// watched for live reload instead), so an explicit --config next to it is rejected rather than ignored.
+func startConfigPath(configFile, openAPIFile string, configSet bool) (string, error) {
+	if openAPIFile != "" {
+		if configSet {
+			return "", fmt.Errorf("--config and --routes-from-openapi cannot be used together")
+		}
+		return openAPIFile, nil
+	}
+	if configFile == "" {
+		return "", fmt.Errorf("Config file is required. Example: mockserver start --config mockserver.json")
+	}
+	return configFile, nil
+}
+
+// loadConfig reads the config file, or builds one from an OpenAPI document with --routes-from-openapi
+func loadConfig(path string) (*msconfig.Config, error) {
+	if routesFromOpenAPI != "" {
+		return msconfig.LoadOpenAPI(path)
+	}
+	return msconfig.LoadConfig(path)
+}
+
 func mustLoadAndStart(configPath string) *Runtime {
-	cfg, err := msconfig.LoadConfig(configPath)
+	cfg, err := loadConfig(configPath)
 	if err != nil {
 		fatalExit(fmt.Sprintf("Failed to load config: %v", err))
 	}
@@ -26,13 +54,75 @@ func mustLoadAndStart(configPath string) *Runtime {
 	}
 }
 
-// listenApp starts the Fiber server
-func listenApp(app *fiber.App, addr string) {
-	if err := app.Listen(addr); err != nil {
+// listenApp serves the Fiber app on an already bound listener
+func listenApp(app *fiber.App, ln net.Listener) {
+	if err := app.Listener(ln); err != nil {
 		mslogger.LogError(fmt.Sprintf("Server stopped unexpectedly: %v", err))
 	}
 }
 
+// newH2CServer bridges the Fiber app into net/http so it can accept HTTP/2 cleartext.
+// fasthttp has no HTTP/2 support; only prior-knowledge h2c is served (no "Upgrade: h2c").
+func newH2CServer(app *fiber.App, addr string) *http.Server {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+
+	// The adaptor rebuilds every request as HTTP/1.1; the real protocol travels in a header
+	fiberHandler := adaptor.FiberApp(app)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set(msServer.ProtoHeader, r.Proto)
+		fiberHandler.ServeHTTP(w, r)
+	})
+
+	// The bridge owns the connections, so it applies the tuned timeouts itself
+	fiberCfg := app.Config()
+	return &http.Server{
+		Addr:         addr,
+		Handler:      handler,
+		Protocols:    protocols,
+		ReadTimeout:  fiberCfg.ReadTimeout,
+		WriteTimeout: fiberCfg.WriteTimeout,
+		IdleTimeout:  fiberCfg.IdleTimeout,
+	}
+}
+
+// listenH2C serves the h2c bridge on an already bound listener
+func listenH2C(srv *http.Server, ln net.Listener) {
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		mslogger.LogError(fmt.Sprintf("Server stopped unexpectedly: %v", err))
+	}
+}
+
+// serveRuntime starts the listener matching the runtime config (native Fiber or h2c bridge).
+// The address is bound before returning, so the runtime accepts connections once it returns.
+func serveRuntime(rt *Runtime, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	if rt.Cfg.Server.H2C {
+		rt.H2C = newH2CServer(rt.App, addr)
+		go listenH2C(rt.H2C, ln)
+		return nil
+	}
+
+	rt.H2C = nil
+	go listenApp(rt.App, ln)
+	return nil
+}
+
+// shutdownRuntime stops whichever listener is currently active
+func shutdownRuntime(rt *Runtime) {
+	if rt.H2C != nil {
+		_ = rt.H2C.Shutdown(context.Background())
+	}
+	if rt.App != nil {
+		_ = rt.App.Shutdown()
+	}
+}
+
 
 func reloadServer(configFile string, rt *Runtime) {
 	rt.Mu.Lock()
@@ -40,29 +130,46 @@ func reloadServer(configFile string, rt *Runtime) {
 
 	mslogger.LogWarn("Config file changed. Reloading server...")
 
-	cfg, err := msconfig.LoadConfig(configFile)
+	cfg, err := loadConfig(configFile)
 	if err != nil {
 		mslogger.LogError("Reload failed: " + err.Error())
 		return
 	}
 
+	addr := fmt.Sprintf(":%d", cfg.Server.Port)
+
 	// close old server
-	if rt.App != nil {
-		_ = rt.App.Shutdown()
+	shutdownRuntime(rt)
+
+	// Answer 503 RELOADING on the port while the new app is built
+	gate, err := startReloadGate(addr)
+	if err != nil {
+		mslogger.LogWarn("Reload gate unavailable: " + err.Error())
 	}
 
 	newApp := msServer.StartServer(cfg, configFile, embedDir, faviconFS)
-	addr := fmt.Sprintf(":%d", cfg.Server.Port)
-
-	go listenApp(newApp, addr)
 
 	rt.App = newApp
 	rt.Cfg = cfg
 
+	// The gate hands the port straight to the new listener; the reload is only reported once it is bound
+	if gate != nil {
+		gate.Close()
+	}
+	if err := serveRuntime(rt, addr); err != nil {
+		mslogger.LogError(fmt.Sprintf("Reload failed: cannot listen on %s: %v", addr, err))
+		return
+	}
+
 	mslogger.LogSuccess(
 		fmt.Sprintf("Server reloaded and listening on %s", mslogger.GetServerHost(addr, "")),
 		1,
 	)
+
+	// Post-reload hook runs in the background so a slow command never blocks the next reload
+	if rt.ReloadCommand != "" {
+		go runReloadHook(rt.ReloadCommand, reloadHookTimeout)
+	}
 }
 
 
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+import (
+	mslogger "mockserver/logger"
+)
+
+// Poll interval used while waiting for a renamed/removed config file to reappear
+const rewatchInterval = 50 * time.Millisecond
+
+// watchConfig watches configFile and calls onChange (debounced) after every write.
+// Editors that save atomically (write temp file + rename) or delete and recreate the file drop the
+// underlying watch, so on Rename/Remove the path is re-added once it reappears and a reload is scheduled.
+// It returns when stop is closed.
+func watchConfig(configFile string, debounce time.Duration, onChange func(), stop <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start config watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(configFile); err != nil {
+		return fmt.Errorf("failed to watch config file: %w", err)
+	}
+
+	var reloadTimer *time.Timer
+	var mu sync.Mutex
+	scheduleReload := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if reloadTimer != nil {
+			reloadTimer.Stop()
+		}
+		reloadTimer = time.AfterFunc(debounce, onChange)
+	}
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if event.Op&fsnotify.Write == fsnotify.Write {
+				scheduleReload()
+			}
+
+			if event.Op&(fsnotify.Rename|fsnotify.Remove) != 0 {
+				mslogger.LogWarn(fmt.Sprintf("Config file %s (%s), waiting for it to reappear...", configFile, event.Op))
+				if !rewatch(watcher, configFile, stop) {
+					return nil
+				}
+				mslogger.LogInfo("Config file is back, watch re-established")
+				scheduleReload()
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			mslogger.LogError(fmt.Sprintf("Config watcher error: %v", err))
+
+		case <-stop:
+			return nil
+		}
+	}
+}
+
+// rewatch drops the stale watch and re-adds configFile as soon as it exists again.
+// It returns false if stop was closed before the file reappeared.
+func rewatch(watcher *fsnotify.Watcher, configFile string, stop <-chan struct{}) bool {
+	_ = watcher.Remove(configFile)
+
+	for {
+		if err := watcher.Add(configFile); err == nil {
+			return true
+		}
+
+		select {
+		case <-stop:
+			return false
+		case <-time.After(rewatchInterval):
+		}
+	}
+}
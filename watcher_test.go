@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestWatchConfig_AtomicSave verifies reloads keep firing after an editor-style atomic save
+// (write temp file + rename over the config), which replaces the watched inode.
+func TestWatchConfig_AtomicSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mockserver.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("v: 1\n"), 0644))
+
+	reloads := make(chan struct{}, 10)
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- watchConfig(path, 10*time.Millisecond, func() { reloads <- struct{}{} }, stop)
+	}()
+	defer func() {
+		close(stop)
+		require.NoError(t, <-done)
+	}()
+
+	// Give the watcher a moment to register
+	time.Sleep(50 * time.Millisecond)
+
+	expectReload := func(step string) {
+		t.Helper()
+		select {
+		case <-reloads:
+		case <-time.After(3 * time.Second):
+			t.Fatalf("no reload after %s", step)
+		}
+	}
+
+	// Atomic save: the original file is replaced by rename
+	tmp := filepath.Join(dir, ".mockserver.yaml.tmp")
+	require.NoError(t, os.WriteFile(tmp, []byte("v: 2\n"), 0644))
+	require.NoError(t, os.Rename(tmp, path))
+	expectReload("atomic save")
+
+	// A plain write to the new file must still be seen
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, os.WriteFile(path, []byte("v: 3\n"), 0644))
+	expectReload("write after atomic save")
+}